@@ -0,0 +1,47 @@
+package authenticator
+
+import (
+	"net/http"
+
+	"github.com/abbot/go-http-auth"
+	"github.com/fxnn/gopath"
+)
+
+// A Backend verifies the passwords of users.
+// It's what an auth.SecretProvider is for go-http-auth, but doesn't need to
+// reveal password hashes, so that sources like LDAP servers can be used.
+type Backend interface {
+	// CheckPassword returns true iff the password is valid for the given
+	// user.
+	CheckPassword(user string, password string) bool
+}
+
+// secretProviderBackend checks passwords against the hashes delivered by an
+// auth.SecretProvider.
+// It supports all hash formats supported by go-http-auth.
+type secretProviderBackend struct {
+	basicAuth *auth.BasicAuth
+}
+
+func newSecretProviderBackend(secrets auth.SecretProvider) Backend {
+	return &secretProviderBackend{auth.NewBasicAuthenticator(authenticationRealmName, secrets)}
+}
+
+// NewHtpasswdBackend creates a Backend reading users and password hashes
+// from the given htpasswd file.
+// The file is reloaded when it changes.
+// Without the file, all passwords are rejected.
+func NewHtpasswdBackend(htpasswdFile gopath.GoPath) Backend {
+	var secretProvider = noSecrets
+	if !htpasswdFile.HasErr() && !htpasswdFile.IsEmpty() && htpasswdFile.IsExists() {
+		secretProvider = auth.HtpasswdFileProvider(htpasswdFile.Path())
+	}
+	return newSecretProviderBackend(secretProvider)
+}
+
+func (b *secretProviderBackend) CheckPassword(user string, password string) bool {
+	// HINT: go-http-auth only checks requests
+	var request = &http.Request{Header: make(http.Header)}
+	request.SetBasicAuth(user, password)
+	return user != "" && b.basicAuth.CheckAuth(request) == user
+}
@@ -8,12 +8,19 @@ import (
 
 // ContextAuthenticator saves authentication information in the rqeuest context.
 type ContextAuthenticator struct {
+	groups GroupSource
 }
 
 func NewContextAuthenticator() *ContextAuthenticator {
 	return &ContextAuthenticator{}
 }
 
+// NewContextAuthenticatorWithGroups creates an instance that looks up the
+// groups of authenticated users in the given GroupSource.
+func NewContextAuthenticatorWithGroups(groups GroupSource) *ContextAuthenticator {
+	return &ContextAuthenticator{groups}
+}
+
 func (a *ContextAuthenticator) IsAuthenticated(request *http.Request) bool {
 	return context.Load(request).IsAuthenticated()
 }
@@ -22,6 +29,14 @@ func (a *ContextAuthenticator) UserID(request *http.Request) string {
 	return context.Load(request).UserId
 }
 
+func (a *ContextAuthenticator) Groups(request *http.Request) []string {
+	var userID = a.UserID(request)
+	if userID == "" || a.groups == nil {
+		return nil
+	}
+	return a.groups.GroupsOf(userID)
+}
+
 func (a *ContextAuthenticator) SetUserID(writer http.ResponseWriter, request *http.Request, userId string) {
 	var ctx = context.Load(request)
 	ctx.UserId = userId
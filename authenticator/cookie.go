@@ -23,12 +23,23 @@ type CookieAuthenticator struct {
 	cookieStore sessions.Store
 }
 
+// NewCookieAuthenticator creates an instance with a random key, so that
+// sessions end when the process ends.
 func NewCookieAuthenticator() *CookieAuthenticator {
 	var cookieStore = createCookieStoreWithRandomKey()
 	cookieStore.MaxAge(int(cookieMaxAge / time.Second))
 	return &CookieAuthenticator{cookieStore}
 }
 
+// NewCookieAuthenticatorWithKeys creates an instance using the given pairs of
+// authentication and encryption keys, as returned by LoadSessionKeys.
+// The first pair is used for new cookies, all pairs for reading cookies.
+func NewCookieAuthenticatorWithKeys(keyPairs [][]byte) *CookieAuthenticator {
+	var cookieStore = sessions.NewCookieStore(keyPairs...)
+	cookieStore.MaxAge(int(cookieMaxAge / time.Second))
+	return &CookieAuthenticator{cookieStore}
+}
+
 func createCookieStoreWithRandomKey() *sessions.CookieStore {
 	var authenticationKey = securecookie.GenerateRandomKey(cookieAuthenticationKeyLengthInBytes)
 	if authenticationKey == nil {
@@ -56,6 +67,12 @@ func (s *CookieAuthenticator) UserID(request *http.Request) string {
 	return ""
 }
 
+// Groups always returns nil, as groups aren't stored in the cookie, but
+// looked up for each request.
+func (s *CookieAuthenticator) Groups(request *http.Request) []string {
+	return nil
+}
+
 func (s *CookieAuthenticator) SetUserID(writer http.ResponseWriter, request *http.Request, userId string) {
 	if userId == "" {
 		s.removeCookie(writer, request)
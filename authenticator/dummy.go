@@ -21,6 +21,10 @@ func (AlwaysAuthenticated) UserID(request *http.Request) string {
 	return ""
 }
 
+func (AlwaysAuthenticated) Groups(request *http.Request) []string {
+	return nil
+}
+
 func (AlwaysAuthenticated) SetUserID(responseWriter http.ResponseWriter, request *http.Request, userId string) {
 	// nothing to do
 }
@@ -44,6 +48,10 @@ func (NeverAuthenticated) UserID(request *http.Request) string {
 	return ""
 }
 
+func (NeverAuthenticated) Groups(request *http.Request) []string {
+	return nil
+}
+
 func (NeverAuthenticated) SetUserID(responseWriter http.ResponseWriter, request *http.Request, userId string) {
 	// nothing to do
 }
@@ -67,6 +75,10 @@ func (a *mockAuthenticator) UserID(request *http.Request) string {
 	return a.userID
 }
 
+func (a *mockAuthenticator) Groups(request *http.Request) []string {
+	return nil
+}
+
 func (a *mockAuthenticator) SetUserID(responseWriter http.ResponseWriter, request *http.Request, userID string) {
 	a.userID = userID
 }
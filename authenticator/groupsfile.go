@@ -0,0 +1,102 @@
+package authenticator
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fxnn/gone/log"
+)
+
+// A GroupSource tells which groups a user is a member of.
+type GroupSource interface {
+	// GroupsOf returns the names of all groups the given user is a member
+	// of.
+	GroupsOf(user string) []string
+}
+
+// groupsFile reads group memberships from a file in the format of Apache's
+// AuthGroupFile, with one group per line, followed by a colon and the
+// space separated names of its members:
+//
+//	# comments are allowed
+//	docs-editors: Aladdin Jasmine
+//
+// The file is reloaded when it changes.
+type groupsFile struct {
+	path    string
+	mutex   sync.Mutex
+	modTime time.Time
+	groups  map[string][]string
+}
+
+// NewGroupsFile creates a GroupSource reading the given file.
+// Without the file, users are members of no groups.
+func NewGroupsFile(path string) GroupSource {
+	return &groupsFile{path: path, groups: make(map[string][]string)}
+}
+
+func (f *groupsFile) GroupsOf(user string) []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := f.reloadIfNeeded(); err != nil && !os.IsNotExist(err) {
+		log.Warnf("couldn't read groups file: %s", err)
+	}
+	return f.groups[user]
+}
+
+// reloadIfNeeded reads the file, if it changed since the last read.
+// When the file is missing, all groups are dropped; on other errors, the
+// previous groups are kept.
+func (f *groupsFile) reloadIfNeeded() error {
+	var info, err = os.Stat(f.path)
+	if os.IsNotExist(err) {
+		f.groups, f.modTime = make(map[string][]string), time.Time{}
+	}
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(f.modTime) {
+		return nil
+	}
+
+	content, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	groups, err := parseGroupsFile(f.path, content)
+	if err != nil {
+		return err
+	}
+
+	f.groups, f.modTime = groups, info.ModTime()
+	return nil
+}
+
+// parseGroupsFile returns the groups of each user.
+func parseGroupsFile(path string, content []byte) (map[string][]string, error) {
+	var groups = make(map[string][]string)
+	var scanner = bufio.NewScanner(bytes.NewReader(content))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		var line = strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var colon = strings.Index(line, ":")
+		if colon <= 0 {
+			return nil, fmt.Errorf("%s, line %d: expected group name and colon", path, lineNumber)
+		}
+		var group = strings.TrimSpace(line[:colon])
+		for _, user := range strings.Fields(line[colon+1:]) {
+			groups[user] = append(groups[user], group)
+		}
+	}
+	return groups, scanner.Err()
+}
@@ -0,0 +1,58 @@
+package authenticator
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseGroupsFile(t *testing.T) {
+	var groups, err = parseGroupsFile("groups", []byte("# groups\neditors: Jasmine Aladdin\nadmins:Jasmine\nempty:\n"))
+	if err != nil {
+		t.Fatalf("couldn't parse groups file: %s", err)
+	}
+
+	if actual := groups["Jasmine"]; !reflect.DeepEqual(actual, []string{"editors", "admins"}) {
+		t.Fatalf("unexpected groups of Jasmine: %v", actual)
+	}
+	if actual := groups["Aladdin"]; !reflect.DeepEqual(actual, []string{"editors"}) {
+		t.Fatalf("unexpected groups of Aladdin: %v", actual)
+	}
+}
+
+func TestParseGroupsFileRejectsMissingColon(t *testing.T) {
+	if _, err := parseGroupsFile("groups", []byte("editors Jasmine\n")); err == nil {
+		t.Fatalf("expected error on missing colon")
+	}
+}
+
+func TestGroupsOfWithoutGroupsFile(t *testing.T) {
+	if groups := NewGroupsFile("/nonexistent/.htgroups").GroupsOf("Jasmine"); len(groups) != 0 {
+		t.Fatalf("expected no groups, got %v", groups)
+	}
+}
+
+func TestGroupsOfWhenGroupsFileIsDeleted(t *testing.T) {
+	var dir, err = ioutil.TempDir("", "gone_test_")
+	if err != nil {
+		t.Fatalf("couldn't create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	var path = filepath.Join(dir, ".htgroups")
+	if err := ioutil.WriteFile(path, []byte("editors: Jasmine\n"), 0600); err != nil {
+		t.Fatalf("couldn't write groups file: %s", err)
+	}
+
+	var sut = NewGroupsFile(path)
+	if groups := sut.GroupsOf("Jasmine"); len(groups) != 1 {
+		t.Fatalf("expected one group, got %v", groups)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("couldn't remove groups file: %s", err)
+	}
+	if groups := sut.GroupsOf("Jasmine"); len(groups) != 0 {
+		t.Fatalf("expected no groups without groups file, got %v", groups)
+	}
+}
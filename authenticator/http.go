@@ -1,64 +1,87 @@
 package authenticator
 
 import (
+	"bytes"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
-	"github.com/abbot/go-http-auth"
 	"github.com/fxnn/gone/authenticator/bruteblocker"
 	"github.com/fxnn/gone/http/failer"
 	"github.com/fxnn/gone/http/router"
+	"github.com/fxnn/gone/http/templates"
 	"github.com/fxnn/gone/log"
-	"github.com/fxnn/gopath"
 )
 
 const (
 	authenticationRealmName = "gone wiki"
+	userFormField           = "user"
+	passwordFormField       = "password"
 )
 
-// HttpBasicAuthenticator is an HttpAuthenticator that uses HTTP Basic Auth for
-// initial authentication and stores the result in a session cookie for further
-// requests.
+// HttpBasicAuthenticator is an HttpAuthenticator that uses a login form or
+// HTTP Basic Auth for initial authentication and stores the result in a
+// session cookie for further requests.
 type HttpBasicAuthenticator struct {
 	requestAuth         Authenticator // requestAuth stores authentication information during this request.
 	sessionAuth         Authenticator // sessionAuth stores authentication information during the user session.
 	loginRequiresHeader string
-	basicAuth           *auth.BasicAuth
+	backend             Backend
 	bruteBlocker        *bruteblocker.BruteBlocker
+	loginRenderer       *templates.LoginRenderer
+	tokens              *TokenStore
 }
 
 // NewHttpBasicAuthenticator creates a new instance.
 //
 // requestAuth will be provided with the auth information for each request.
-// htpasswdFile is used as source of usernames and passwords.
+// backend verifies usernames and passwords.
 // loginRequiresHeader is the name of an HTTP header required for each login
 // attempt.
 // This may be used to only allow login over secured connections.
 // bruteBlocker is a configured BruteBlocker instance.
+// loader provides the login form's template.
+// sessionKeyPairs are the keys for session cookies, as returned by
+// LoadSessionKeys; when nil, random keys are used.
+// tokens checks API tokens sent as bearer tokens; when nil, no API tokens are
+// accepted.
 func NewHttpBasicAuthenticator(
 	requestAuth Authenticator,
-	htpasswdFile gopath.GoPath,
+	backend Backend,
 	loginRequiresHeader string,
 	bruteBlocker *bruteblocker.BruteBlocker,
+	loader templates.Loader,
+	sessionKeyPairs [][]byte,
+	tokens *TokenStore,
 ) *HttpBasicAuthenticator {
+	var loginRenderer = templates.NewLoginRenderer()
+	if err := loginRenderer.LoadAndWatch(loader); err != nil {
+		panic(fmt.Errorf("couldn't load login template: %s", err))
+	}
+
+	var sessionAuth = NewCookieAuthenticator()
+	if sessionKeyPairs != nil {
+		sessionAuth = NewCookieAuthenticatorWithKeys(sessionKeyPairs)
+	}
+
 	return &HttpBasicAuthenticator{
 		NewContextAuthenticator(),
-		NewCookieAuthenticator(),
+		sessionAuth,
 		loginRequiresHeader,
-		createBasicAuth(authenticationRealmName, htpasswdFile),
-		bruteBlocker}
-}
-
-func createBasicAuth(realmName string, htpasswdFile gopath.GoPath) *auth.BasicAuth {
-	var secretProvider = noSecrets
-	if !htpasswdFile.HasErr() && !htpasswdFile.IsEmpty() {
-		secretProvider = auth.HtpasswdFileProvider(htpasswdFile.Path())
-	}
-	return auth.NewBasicAuthenticator(realmName, secretProvider)
+		backend,
+		bruteBlocker,
+		loginRenderer,
+		tokens}
 }
 
 func (a *HttpBasicAuthenticator) MiddlewareHandler(delegate http.Handler) http.Handler {
 	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if token, ok := bearerToken(request); ok {
+			a.serveWithToken(writer, request, token, delegate)
+			return
+		}
+
 		// copy from session cookie
 		if userID := a.sessionAuth.UserID(request); userID != "" {
 			a.requestAuth.SetUserID(writer, request, userID)
@@ -68,6 +91,32 @@ func (a *HttpBasicAuthenticator) MiddlewareHandler(delegate http.Handler) http.H
 	})
 }
 
+// serveWithToken authenticates the request by the given API token, without
+// creating a session.
+func (a *HttpBasicAuthenticator) serveWithToken(writer http.ResponseWriter, request *http.Request,
+	token string, delegate http.Handler) {
+	var userID, scope, ok = "", "", false
+	if a.tokens != nil {
+		userID, scope, ok = a.tokens.CheckToken(token)
+	}
+	if !ok {
+		log.Printf("%s %s: invalid API token", request.Method, request.URL)
+		writer.Header().Set("WWW-Authenticate", `Bearer realm="`+authenticationRealmName+`"`)
+		failer.ServeUnauthorized(writer, request)
+		return
+	}
+	if scope != TokenScopeWrite && router.IsModifying(request) {
+		log.Printf("%s %s: API token of %s only allows reading", request.Method, request.URL, userID)
+		failer.ServeForbidden(writer, request)
+		return
+	}
+
+	a.requestAuth.SetUserID(writer, request, userID)
+	delegate.ServeHTTP(writer, request)
+}
+
+// LoginHandler serves the login form and accepts credentials either posted
+// from the form or sent via HTTP Basic Auth.
 func (a *HttpBasicAuthenticator) LoginHandler() http.Handler {
 	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
 		if a.loginRequiresHeader != "" && request.Header.Get(a.loginRequiresHeader) == "" {
@@ -93,17 +142,85 @@ func (a *HttpBasicAuthenticator) LoginHandler() http.Handler {
 			}
 		}
 
-		a.basicAuth.RequireAuth(writer, request)
+		if a.isFormLogin(request) || user == "" {
+			a.serveLoginForm(writer, request, user)
+			return
+		}
+		a.requireAuth(writer, request)
+	})
+}
+
+// LogoutHandler ends the user's session.
+func (a *HttpBasicAuthenticator) LogoutHandler() http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if userID := a.sessionAuth.UserID(request); userID != "" {
+			log.Printf("%s %s: logged out %s", request.Method, request.URL, userID)
+		}
+		a.sessionAuth.SetUserID(writer, request, "")
+		a.requestAuth.SetUserID(writer, request, "")
+		router.RedirectToViewMode(writer, request)
 	})
 }
 
+// serveLoginForm shows the login form; after a failed attempt of the given
+// user, including an error message.
+func (a *HttpBasicAuthenticator) serveLoginForm(writer http.ResponseWriter, request *http.Request, user string) {
+	var buf bytes.Buffer
+	if err := a.loginRenderer.Render(&buf, request.URL, user, user != ""); err != nil {
+		log.Printf("%s %s: %s", request.Method, request.URL, err)
+		failer.ServeInternalServerError(writer, request)
+		return
+	}
+
+	if user != "" {
+		writer.WriteHeader(http.StatusUnauthorized)
+	}
+	buf.WriteTo(writer)
+}
+
+// requireAuth asks the browser to send credentials via HTTP Basic Auth.
+func (a *HttpBasicAuthenticator) requireAuth(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("WWW-Authenticate", `Basic realm="`+authenticationRealmName+`"`)
+	writer.WriteHeader(http.StatusUnauthorized)
+	writer.Write([]byte("401 Unauthorized\n"))
+}
+
+// bearerToken returns the token from the request's Authorization header, if
+// any.
+func bearerToken(request *http.Request) (string, bool) {
+	var header = request.Header.Get("Authorization")
+	if len(header) <= len("Bearer ") || !strings.EqualFold(header[:len("Bearer ")], "Bearer ") {
+		return "", false
+	}
+	return strings.TrimSpace(header[len("Bearer "):]), true
+}
+
+func (a *HttpBasicAuthenticator) isFormLogin(request *http.Request) bool {
+	return request.Method == "POST" && request.PostFormValue(userFormField) != ""
+}
+
 func (a *HttpBasicAuthenticator) userAttemptingAuth(request *http.Request) string {
-	if user, _, ok := request.BasicAuth(); ok {
-		return user
+	var user, _ = a.credentials(request)
+	return user
+}
+
+// credentials returns user and password from the login form or, if not
+// given, from the HTTP Basic Auth header.
+func (a *HttpBasicAuthenticator) credentials(request *http.Request) (string, string) {
+	if a.isFormLogin(request) {
+		return request.PostFormValue(userFormField), request.PostFormValue(passwordFormField)
 	}
-	return ""
+	if user, password, ok := request.BasicAuth(); ok {
+		return user, password
+	}
+	return "", ""
 }
 
 func (a *HttpBasicAuthenticator) authenticate(writer http.ResponseWriter, request *http.Request) {
-	a.requestAuth.SetUserID(writer, request, a.basicAuth.CheckAuth(request))
+	var user, password = a.credentials(request)
+	if a.backend.CheckPassword(user, password) {
+		a.requestAuth.SetUserID(writer, request, user)
+		return
+	}
+	a.requestAuth.SetUserID(writer, request, "")
 }
@@ -3,10 +3,12 @@ package authenticator
 import (
 	"net/http"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/abbot/go-http-auth"
 	"github.com/fxnn/gone/authenticator/bruteblocker"
+	"github.com/fxnn/gone/http/templates"
 )
 
 func TestMiddlewareHandler_noAuthentication(t *testing.T) {
@@ -127,9 +129,67 @@ func TestLoginHandler_noLoginGiven(t *testing.T) {
 
 	sut.LoginHandler().ServeHTTP(rsp, req)
 
+	if rsp.status != http.StatusOK {
+		t.Fatalf("Expected status ok, was '%v'", rsp.status)
+	}
+	if !strings.Contains(rsp.body.String(), "<form") {
+		t.Fatalf("Expected login form, but got '%v'", rsp.body.String())
+	}
+}
+
+func TestLoginHandler_rightFormCredentials(t *testing.T) {
+	var requestAuth = newMockAuthenticator()
+	var sessionAuth = newMockAuthenticator()
+	var sut = sutWithBruteblockerRequestAuthSessionAuthAndBasicAuth(requestAuth, sessionAuth, aladdinsSecret)
+	var req = formRequest("Aladdin", "open sesame")
+	var rsp = newMockResponseWriter()
+
+	sut.LoginHandler().ServeHTTP(rsp, req)
+
+	if userID := sessionAuth.UserID(req); userID != "Aladdin" {
+		t.Fatalf("Expected session user to be Aladdin, but was '%v'", userID)
+	}
+	if rsp.status != http.StatusFound {
+		t.Fatalf("Expected status found, was '%v'", rsp.status)
+	}
+}
+
+func TestLoginHandler_wrongFormCredentials(t *testing.T) {
+	var requestAuth = newMockAuthenticator()
+	var sessionAuth = newMockAuthenticator()
+	var sut = sutWithBruteblockerRequestAuthSessionAuthAndBasicAuth(requestAuth, sessionAuth, aladdinsSecret)
+	var req = formRequest("Aladdin", "wrong")
+	var rsp = newMockResponseWriter()
+
+	sut.LoginHandler().ServeHTTP(rsp, req)
+
+	if userID := sessionAuth.UserID(req); userID != "" {
+		t.Fatalf("Expected session user to be empty, but was '%v'", userID)
+	}
 	if rsp.status != http.StatusUnauthorized {
 		t.Fatalf("Expected status unauthorized, was '%v'", rsp.status)
 	}
+	if !strings.Contains(rsp.body.String(), "<form") {
+		t.Fatalf("Expected login form, but got '%v'", rsp.body.String())
+	}
+}
+
+func TestLogoutHandler_clearsSession(t *testing.T) {
+	var requestAuth = newMockAuthenticator()
+	var sessionAuth = newMockAuthenticator()
+	var sut = sutWithRequestAuthAndSessionAuth(requestAuth, sessionAuth)
+	var req = blankRequest()
+	var rsp = newMockResponseWriter()
+
+	sessionAuth.SetUserID(rsp, req, "Aladdin")
+	sut.LogoutHandler().ServeHTTP(rsp, req)
+
+	if userID := sessionAuth.UserID(req); userID != "" {
+		t.Fatalf("Expected session user to be empty, but was '%v'", userID)
+	}
+	if rsp.status != http.StatusFound {
+		t.Fatalf("Expected status found, was '%v'", rsp.status)
+	}
 }
 
 func TestAuthAttemptUser_withoutLogin(t *testing.T) {
@@ -186,12 +246,16 @@ func sutWithBruteblockerRequestAuthAndBasicAuth(
 
 func sutWithBasicAuth(secrets auth.SecretProvider) (result HttpBasicAuthenticator) {
 	result = blankSut()
-	result.basicAuth = auth.NewBasicAuthenticator("Realm", secrets)
+	result.backend = newSecretProviderBackend(secrets)
 	return
 }
 
 func blankSut() HttpBasicAuthenticator {
-	return HttpBasicAuthenticator{}
+	var loginRenderer = templates.NewLoginRenderer()
+	if err := loginRenderer.Load(templates.NewStaticLoader()); err != nil {
+		panic(err)
+	}
+	return HttpBasicAuthenticator{loginRenderer: loginRenderer}
 }
 
 func requestWithAladdinUser() (result *http.Request) {
@@ -203,6 +267,13 @@ func requestWithAladdinUser() (result *http.Request) {
 	return result
 }
 
+func formRequest(user string, password string) *http.Request {
+	var result = blankRequest()
+	result.Method = "POST"
+	result.PostForm = url.Values{userFormField: {user}, passwordFormField: {password}}
+	return result
+}
+
 func blankRequest() *http.Request {
 	return &http.Request{Header: make(http.Header), URL: &url.URL{}}
 }
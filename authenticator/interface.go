@@ -16,12 +16,29 @@ type Authenticator interface {
 	// UserID returns the empty string.
 	UserID(request *http.Request) string
 
+	// Groups returns the names of the groups the user being currently logged
+	// in is a member of.
+	// If no user is logged in, Groups returns nil.
+	Groups(request *http.Request) []string
+
 	// SetUserID sets the unique identifier of the user being currently logged
 	// in.
 	// Set this to the empty string to make no user being logged in.
 	SetUserID(writer http.ResponseWriter, request *http.Request, userID string)
 }
 
+// UnixAuthenticator is an Authenticator that also maps users to users of the
+// operating system, so that their file permissions can be checked.
+type UnixAuthenticator interface {
+	Authenticator
+
+	// UnixUser returns the Unix user the user being currently logged in maps
+	// to.
+	// If no user is logged in, or if there's no such Unix user, it returns
+	// false.
+	UnixUser(request *http.Request) (UnixUser, bool)
+}
+
 // HttpAuthenticator allows the authentification of an user over an HTTP
 // protocol.
 //
@@ -37,4 +54,7 @@ type HttpAuthenticator interface {
 
 	// LoginHandler provides a handler that serves the login UI.
 	LoginHandler() http.Handler
+
+	// LogoutHandler provides a handler that ends the user's session.
+	LogoutHandler() http.Handler
 }
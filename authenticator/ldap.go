@@ -0,0 +1,241 @@
+package authenticator
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fxnn/gone/log"
+)
+
+const (
+	ldapTimeout         = 10 * time.Second
+	ldapProtocolVersion = 3
+	ldapResultSuccess   = 0
+	ldapMaxMessageBytes = 64 * 1024
+)
+
+// ldapBackend checks passwords by binding to an LDAP server as the user.
+// Only simple binds are supported; use an ldaps:// URL to protect the
+// password on its way to the server.
+type ldapBackend struct {
+	address       string
+	useTLS        bool
+	userDNPattern string
+}
+
+// NewLDAPBackend creates a Backend binding to the LDAP server at the given
+// ldap:// or ldaps:// URL.
+// userDNPattern yields a user's distinguished name when "%s" is replaced by
+// the user name, e.g. "uid=%s,ou=people,dc=example,dc=org".
+func NewLDAPBackend(ldapURL string, userDNPattern string) (Backend, error) {
+	var u, err = url.Parse(ldapURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LDAP URL %s: %s", ldapURL, err)
+	}
+	if strings.Count(userDNPattern, "%s") != 1 {
+		return nil, fmt.Errorf("LDAP user DN pattern must contain %%s exactly once: %s", userDNPattern)
+	}
+
+	var b = &ldapBackend{address: u.Host, userDNPattern: userDNPattern}
+	switch u.Scheme {
+	case "ldap":
+		b.address = withDefaultPort(u.Host, "389")
+	case "ldaps":
+		b.address, b.useTLS = withDefaultPort(u.Host, "636"), true
+	default:
+		return nil, fmt.Errorf("unsupported LDAP URL scheme: %s", u.Scheme)
+	}
+	return b, nil
+}
+
+func withDefaultPort(host string, port string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	return net.JoinHostPort(host, port)
+}
+
+func (b *ldapBackend) CheckPassword(user string, password string) bool {
+	if user == "" || password == "" {
+		// HINT: binds without password are anonymous and always succeed
+		return false
+	}
+
+	var err = b.bind(fmt.Sprintf(b.userDNPattern, escapeDN(user)), password)
+	if err != nil {
+		log.Printf("LDAP bind for %s failed: %s", user, err)
+		return false
+	}
+	return true
+}
+
+// ldapBindRequest is the BindRequest of RFC 4511, section 4.2.
+type ldapBindRequest struct {
+	Version  int
+	Name     []byte
+	Password []byte `asn1:"tag:0"`
+}
+
+type ldapBindRequestMessage struct {
+	MessageID   int
+	BindRequest ldapBindRequest `asn1:"application,tag:0"`
+}
+
+// bind connects to the server and performs a simple bind.
+func (b *ldapBackend) bind(dn string, password string) error {
+	var conn, err = b.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(ldapTimeout))
+
+	request, err := asn1.Marshal(ldapBindRequestMessage{1,
+		ldapBindRequest{ldapProtocolVersion, []byte(dn), []byte(password)}})
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+
+	responseBytes, err := readBERElement(bufio.NewReader(conn))
+	if err != nil {
+		return fmt.Errorf("couldn't read response: %s", err)
+	}
+	resultCode, diagnosticMessage, err := parseBindResponse(responseBytes)
+	if err != nil {
+		return fmt.Errorf("invalid response: %s", err)
+	}
+	if resultCode != ldapResultSuccess {
+		return fmt.Errorf("result code %d: %s", resultCode, diagnosticMessage)
+	}
+	return nil
+}
+
+// parseBindResponse extracts result code and diagnostic message from the
+// BindResponse of RFC 4511, section 4.2.2.
+// Unlike encoding/asn1, it accepts the non-minimal length encodings some
+// servers send.
+func parseBindResponse(data []byte) (int, string, error) {
+	var tag, message, _, err = parseBERElement(data)
+	if err != nil || tag != 0x30 {
+		return 0, "", fmt.Errorf("no LDAPMessage: %v", err)
+	}
+	_, _, message, err = parseBERElement(message) // HINT: skip messageID
+	if err != nil {
+		return 0, "", err
+	}
+	tag, response, _, err := parseBERElement(message)
+	if err != nil || tag != 0x61 {
+		return 0, "", fmt.Errorf("no BindResponse: %v", err)
+	}
+
+	tag, resultCode, response, err := parseBERElement(response)
+	if err != nil || tag != 0x0a || len(resultCode) == 0 {
+		return 0, "", fmt.Errorf("no resultCode: %v", err)
+	}
+	var result = 0
+	for _, b := range resultCode {
+		result = result<<8 | int(b)
+	}
+
+	var diagnosticMessage []byte
+	if _, _, response, err = parseBERElement(response); err == nil { // HINT: skip matchedDN
+		_, diagnosticMessage, _, _ = parseBERElement(response)
+	}
+	return result, string(diagnosticMessage), nil
+}
+
+// parseBERElement splits off the first BER encoded element, returning its
+// tag, its content and the remaining data.
+func parseBERElement(data []byte) (byte, []byte, []byte, error) {
+	if len(data) < 2 {
+		return 0, nil, nil, io.ErrUnexpectedEOF
+	}
+	var length, offset = int(data[1]), 2
+	if length&0x80 != 0 {
+		var lengthBytes = length & 0x7f
+		if lengthBytes == 0 || lengthBytes > 4 || len(data) < 2+lengthBytes {
+			return 0, nil, nil, fmt.Errorf("unsupported length encoding")
+		}
+		length = 0
+		for _, b := range data[2 : 2+lengthBytes] {
+			length = length<<8 | int(b)
+		}
+		offset += lengthBytes
+	}
+	if length < 0 || len(data) < offset+length {
+		return 0, nil, nil, io.ErrUnexpectedEOF
+	}
+	return data[0], data[offset : offset+length], data[offset+length:], nil
+}
+
+func (b *ldapBackend) dial() (net.Conn, error) {
+	var dialer = &net.Dialer{Timeout: ldapTimeout}
+	if b.useTLS {
+		return tls.DialWithDialer(dialer, "tcp", b.address, nil)
+	}
+	return dialer.Dial("tcp", b.address)
+}
+
+// readBERElement reads one complete BER encoded element, as LDAP messages
+// are sent without further framing.
+func readBERElement(reader *bufio.Reader) ([]byte, error) {
+	var header = make([]byte, 2)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, err
+	}
+
+	var length = int(header[1])
+	if length&0x80 != 0 {
+		var lengthBytes = make([]byte, length&0x7f)
+		if len(lengthBytes) == 0 || len(lengthBytes) > 4 {
+			return nil, fmt.Errorf("unsupported length encoding")
+		}
+		if _, err := io.ReadFull(reader, lengthBytes); err != nil {
+			return nil, err
+		}
+		header = append(header, lengthBytes...)
+		length = 0
+		for _, lengthByte := range lengthBytes {
+			length = length<<8 | int(lengthByte)
+		}
+	}
+	if length > ldapMaxMessageBytes {
+		return nil, fmt.Errorf("message of %d bytes is too large", length)
+	}
+
+	var content = make([]byte, length)
+	if _, err := io.ReadFull(reader, content); err != nil {
+		return nil, err
+	}
+	return append(header, content...), nil
+}
+
+// escapeDN escapes a value for use in a distinguished name, as specified by
+// RFC 4514, section 2.4.
+func escapeDN(value string) string {
+	var result strings.Builder
+	for i, r := range value {
+		switch {
+		case strings.ContainsRune(`,+"\<>;=`, r),
+			(r == ' ' || r == '#') && i == 0,
+			r == ' ' && i == len(value)-1:
+			result.WriteByte('\\')
+			result.WriteRune(r)
+		case r == 0:
+			result.WriteString(`\00`)
+		default:
+			result.WriteRune(r)
+		}
+	}
+	return result.String()
+}
@@ -0,0 +1,104 @@
+package authenticator
+
+import (
+	"bufio"
+	"encoding/asn1"
+	"net"
+	"testing"
+)
+
+const ldapTestUserDN = `uid=Ala\,ddin,ou=people,dc=example,dc=org`
+
+// ldapBindResponseMessage is the BindResponse of RFC 4511, section 4.2.2.
+type ldapBindResponseMessage struct {
+	MessageID    int
+	BindResponse struct {
+		ResultCode        asn1.Enumerated
+		MatchedDN         []byte
+		DiagnosticMessage []byte
+	} `asn1:"application,tag:1"`
+}
+
+func TestLDAPBackendBindsAsUser(t *testing.T) {
+	var sut, stop = ldapSut(t)
+	defer stop()
+
+	assertPasswordAccepted(t, sut, "Ala,ddin", "open sesame")
+	assertPasswordRejected(t, sut, "Ala,ddin", "wrong")
+	assertPasswordRejected(t, sut, "Thief", "open sesame")
+}
+
+func TestLDAPBackendRejectsEmptyPassword(t *testing.T) {
+	var sut, stop = ldapSut(t)
+	defer stop()
+
+	assertPasswordRejected(t, sut, "Ala,ddin", "")
+}
+
+func TestParseBindResponseAcceptsLongLengthEncoding(t *testing.T) {
+	// HINT: as sent by Active Directory
+	var response = []byte{0x30, 0x84, 0, 0, 0, 0x14, 0x02, 0x01, 0x01,
+		0x61, 0x84, 0, 0, 0, 0x0b, 0x0a, 0x01, 0x31, 0x04, 0x00, 0x04, 0x04, 'n', 'o', 'p', 'e'}
+
+	resultCode, diagnosticMessage, err := parseBindResponse(response)
+	if err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	if resultCode != 49 || diagnosticMessage != "nope" {
+		t.Fatalf("expected result code 49 with 'nope', but got %d with '%s'", resultCode, diagnosticMessage)
+	}
+}
+
+func TestNewLDAPBackendRejectsInvalidConfiguration(t *testing.T) {
+	if _, err := NewLDAPBackend("http://localhost", "uid=%s"); err == nil {
+		t.Fatalf("expected error on unsupported scheme")
+	}
+	if _, err := NewLDAPBackend("ldap://localhost", "uid=someone"); err == nil {
+		t.Fatalf("expected error on pattern without placeholder")
+	}
+}
+
+// ldapSut starts a stand-in LDAP server, which only answers bind requests,
+// and returns a backend using it.
+func ldapSut(t *testing.T) (Backend, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("couldn't listen: %s", err)
+	}
+	go serveLDAPBinds(listener)
+
+	sut, err := NewLDAPBackend("ldap://"+listener.Addr().String(), "uid=%s,ou=people,dc=example,dc=org")
+	if err != nil {
+		listener.Close()
+		t.Fatalf("couldn't create backend: %s", err)
+	}
+	return sut, func() { listener.Close() }
+}
+
+func serveLDAPBinds(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			requestBytes, err := readBERElement(bufio.NewReader(conn))
+			if err != nil {
+				return
+			}
+			var request ldapBindRequestMessage
+			if _, err := asn1.Unmarshal(requestBytes, &request); err != nil {
+				return
+			}
+
+			var response = ldapBindResponseMessage{MessageID: request.MessageID}
+			if string(request.BindRequest.Name) != ldapTestUserDN ||
+				string(request.BindRequest.Password) != "open sesame" {
+				response.BindResponse.ResultCode = 49 // invalidCredentials
+			}
+			responseBytes, _ := asn1.Marshal(response)
+			conn.Write(responseBytes)
+		}()
+	}
+}
@@ -1,12 +1,16 @@
 package authenticator
 
-import "net/http"
+import (
+	"bytes"
+	"net/http"
+)
 
 type mockResponseWriter struct {
 	header        http.Header
 	headerWritten bool
 	status        int
 	bytesWritten  int
+	body          bytes.Buffer
 }
 
 func newMockResponseWriter() *mockResponseWriter {
@@ -21,6 +25,8 @@ func (w *mockResponseWriter) Write(content []byte) (written int, err error) {
 	if w.status == 0 {
 		w.WriteHeader(http.StatusOK)
 	}
+	written, err = w.body.Write(content)
+	w.bytesWritten += written
 	return
 }
 
@@ -0,0 +1,170 @@
+package authenticator
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gorilla/securecookie"
+)
+
+const (
+	sessionHashKeyLengthInBytes  = cookieAuthenticationKeyLengthInBytes
+	sessionBlockKeyLengthInBytes = 32 // HINT: selects AES-256
+	// maxSessionKeyPairs is the number of key pairs kept on rotation,
+	// including the current one.
+	maxSessionKeyPairs = 3
+
+	sessionKeyFileHeader = "# Session keys of the gone wiki. Keep this file secret!\n" +
+		"# Each line holds an authentication and an encryption key.\n" +
+		"# The first line is used for new sessions, all lines are accepted for\n" +
+		"# existing ones.\n"
+)
+
+// LoadSessionKeys reads the key pairs for session cookies from the given
+// file, as to be passed to NewHttpBasicAuthenticator.
+// When the file doesn't exist yet, it's created with a new random key pair,
+// so that sessions survive restarts, and instances sharing the file share
+// their sessions.
+func LoadSessionKeys(path string) ([][]byte, error) {
+	var keyPairs, err = readSessionKeys(path)
+	if os.IsNotExist(err) {
+		keyPairs, err = createSessionKeys(path)
+	}
+	return keyPairs, err
+}
+
+// RotateSessionKeys adds a new key pair to the given file, which will be
+// used for all new sessions.
+// The previous key pairs are still accepted for existing sessions, until
+// they're dropped by further rotations.
+func RotateSessionKeys(path string) error {
+	var keyPairs, err = readSessionKeys(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	newKeyPair, err := generateSessionKeyPair()
+	if err != nil {
+		return err
+	}
+	keyPairs = append(newKeyPair, keyPairs...)
+	if len(keyPairs) > 2*maxSessionKeyPairs {
+		keyPairs = keyPairs[:2*maxSessionKeyPairs]
+	}
+
+	return writeSessionKeys(path, keyPairs)
+}
+
+func readSessionKeys(path string) ([][]byte, error) {
+	var content, err = ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var keyPairs = make([][]byte, 0)
+	var scanner = bufio.NewScanner(bytes.NewReader(content))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		var line = strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var fields = strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s, line %d: expected two keys", path, lineNumber)
+		}
+		for _, field := range fields {
+			key, err := hex.DecodeString(field)
+			if err != nil {
+				return nil, fmt.Errorf("%s, line %d: %s", path, lineNumber, err)
+			}
+			keyPairs = append(keyPairs, key)
+		}
+		if blockKeyLength := len(keyPairs[len(keyPairs)-1]); blockKeyLength != 16 &&
+			blockKeyLength != 24 && blockKeyLength != 32 {
+			return nil, fmt.Errorf("%s, line %d: encryption key must have 16, 24 or 32 bytes",
+				path, lineNumber)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(keyPairs) == 0 {
+		return nil, fmt.Errorf("%s contains no keys", path)
+	}
+	return keyPairs, nil
+}
+
+// createSessionKeys creates the file with a new key pair.
+// If another process creates it at the same time, its keys are used.
+func createSessionKeys(path string) ([][]byte, error) {
+	var keyPairs, err = generateSessionKeyPair()
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		return readSessionKeys(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = file.Write(formatSessionKeys(keyPairs))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return keyPairs, err
+}
+
+// writeSessionKeys replaces the file, making sure that it's never seen
+// partially written.
+func writeSessionKeys(path string, keyPairs [][]byte) error {
+	return writeFileAtomically(path, formatSessionKeys(keyPairs))
+}
+
+// writeFileAtomically replaces the file with the given content, making sure
+// that it's never seen partially written.
+// A new file is only readable by the owner.
+func writeFileAtomically(path string, content []byte) error {
+	var tempFile, err = ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+
+	_, err = tempFile.Write(content)
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tempFile.Name(), path)
+}
+
+func formatSessionKeys(keyPairs [][]byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(sessionKeyFileHeader)
+	for i := 0; i+1 < len(keyPairs); i += 2 {
+		fmt.Fprintf(&buf, "%s %s\n", hex.EncodeToString(keyPairs[i]), hex.EncodeToString(keyPairs[i+1]))
+	}
+	return buf.Bytes()
+}
+
+func generateSessionKeyPair() ([][]byte, error) {
+	var hashKey = securecookie.GenerateRandomKey(sessionHashKeyLengthInBytes)
+	var blockKey = securecookie.GenerateRandomKey(sessionBlockKeyLengthInBytes)
+	if hashKey == nil || blockKey == nil {
+		return nil, fmt.Errorf("failed to generate random session keys")
+	}
+	return [][]byte{hashKey, blockKey}, nil
+}
@@ -0,0 +1,117 @@
+package authenticator
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadSessionKeysCreatesAndReusesFile(t *testing.T) {
+	var path, cleanUp = sessionKeysPath(t)
+	defer cleanUp()
+
+	var created = loadSessionKeys(t, path)
+	var loaded = loadSessionKeys(t, path)
+
+	if len(created) != 2 || len(loaded) != 2 {
+		t.Fatalf("expected one key pair, got %d and %d keys", len(created), len(loaded))
+	}
+	for i := range created {
+		if !bytes.Equal(created[i], loaded[i]) {
+			t.Fatalf("expected key %d to be reused", i)
+		}
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("expected file with mode 0600, got %v, %v", info, err)
+	}
+}
+
+func TestRotateSessionKeysKeepsOldSessionsValid(t *testing.T) {
+	var path, cleanUp = sessionKeysPath(t)
+	defer cleanUp()
+
+	var oldAuth = NewCookieAuthenticatorWithKeys(loadSessionKeys(t, path))
+	var cookies = sessionCookies(oldAuth, "Aladdin")
+
+	if err := RotateSessionKeys(path); err != nil {
+		t.Fatalf("couldn't rotate keys: %s", err)
+	}
+	var newAuth = NewCookieAuthenticatorWithKeys(loadSessionKeys(t, path))
+
+	if userID := newAuth.UserID(requestWithCookies(cookies)); userID != "Aladdin" {
+		t.Fatalf("expected old session to be valid, got user %q", userID)
+	}
+	if userID := newAuth.UserID(requestWithCookies(sessionCookies(newAuth, "Aladdin"))); userID != "Aladdin" {
+		t.Fatalf("expected new session to be valid, got user %q", userID)
+	}
+	if userID := oldAuth.UserID(requestWithCookies(sessionCookies(newAuth, "Aladdin"))); userID != "" {
+		t.Fatalf("expected new session to use the new keys, got user %q", userID)
+	}
+}
+
+func TestRotateSessionKeysDropsOldestKeys(t *testing.T) {
+	var path, cleanUp = sessionKeysPath(t)
+	defer cleanUp()
+
+	var first = NewCookieAuthenticatorWithKeys(loadSessionKeys(t, path))
+	var cookies = sessionCookies(first, "Aladdin")
+	for i := 0; i < maxSessionKeyPairs; i++ {
+		if err := RotateSessionKeys(path); err != nil {
+			t.Fatalf("couldn't rotate keys: %s", err)
+		}
+	}
+	var keyPairs = loadSessionKeys(t, path)
+
+	if len(keyPairs) != 2*maxSessionKeyPairs {
+		t.Fatalf("expected %d key pairs, got %d keys", maxSessionKeyPairs, len(keyPairs))
+	}
+	if userID := NewCookieAuthenticatorWithKeys(keyPairs).UserID(requestWithCookies(cookies)); userID != "" {
+		t.Fatalf("expected session with dropped keys to be invalid, got user %q", userID)
+	}
+}
+
+func TestLoadSessionKeysRejectsInvalidFile(t *testing.T) {
+	var path, cleanUp = sessionKeysPath(t)
+	defer cleanUp()
+	if err := ioutil.WriteFile(path, []byte("# no keys\nabcd\n"), 0600); err != nil {
+		t.Fatalf("couldn't write key file: %s", err)
+	}
+
+	if _, err := LoadSessionKeys(path); err == nil {
+		t.Fatalf("expected error on invalid key file")
+	}
+}
+
+func sessionKeysPath(t *testing.T) (string, func()) {
+	var dir, err = ioutil.TempDir("", "gone_test_")
+	if err != nil {
+		t.Fatalf("couldn't create temp dir: %s", err)
+	}
+	return filepath.Join(dir, ".session-keys"), func() { os.RemoveAll(dir) }
+}
+
+func loadSessionKeys(t *testing.T, path string) [][]byte {
+	var keyPairs, err = LoadSessionKeys(path)
+	if err != nil {
+		t.Fatalf("couldn't load keys: %s", err)
+	}
+	return keyPairs
+}
+
+func sessionCookies(auth *CookieAuthenticator, userID string) []*http.Cookie {
+	var recorder = httptest.NewRecorder()
+	auth.SetUserID(recorder, httptest.NewRequest("GET", "/", nil), userID)
+	return recorder.Result().Cookies()
+}
+
+func requestWithCookies(cookies []*http.Cookie) *http.Request {
+	var request = httptest.NewRequest("GET", "/", nil)
+	for _, cookie := range cookies {
+		request.AddCookie(cookie)
+	}
+	return request
+}
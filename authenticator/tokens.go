@@ -0,0 +1,231 @@
+package authenticator
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fxnn/gone/log"
+	"github.com/gorilla/securecookie"
+)
+
+// Scopes of API tokens.
+const (
+	// TokenScopeRead allows requests that don't change any content.
+	TokenScopeRead = "read"
+	// TokenScopeWrite allows all requests the token's user may send.
+	TokenScopeWrite = "write"
+)
+
+const (
+	tokenIDLengthInBytes     = 8
+	tokenSecretLengthInBytes = 32
+	tokenNeverExpires        = "-"
+
+	tokenFileHeader = "# API tokens of the gone wiki, created by \"gone create-token\".\n" +
+		"# Each line holds id, SHA-256 hash of the secret, user, scope and expiry.\n"
+)
+
+// tokenEntry is an API token as stored in the token file.
+// The token itself is "<id>.<secret>", but only the secret's hash is
+// stored.
+type tokenEntry struct {
+	id      string
+	hash    string
+	user    string
+	scope   string
+	expires time.Time // zero if the token never expires
+}
+
+// TokenStore checks API tokens against the hashes stored in a token file.
+// The file is reloaded when it changes.
+type TokenStore struct {
+	path    string
+	mutex   sync.Mutex
+	modTime time.Time
+	tokens  map[string]tokenEntry
+}
+
+// NewTokenStore creates an instance reading the given token file.
+// Without the file, all tokens are rejected.
+func NewTokenStore(path string) *TokenStore {
+	return &TokenStore{path: path, tokens: make(map[string]tokenEntry)}
+}
+
+// CheckToken returns user and scope of the given token, or false if the token
+// is unknown, revoked or expired.
+func (s *TokenStore) CheckToken(token string) (string, string, bool) {
+	var id, secret, ok = splitToken(token)
+	if !ok {
+		return "", "", false
+	}
+
+	s.mutex.Lock()
+	if err := s.reloadIfNeeded(); err != nil && !os.IsNotExist(err) {
+		log.Warnf("couldn't read token file: %s", err)
+	}
+	entry, ok := s.tokens[id]
+	s.mutex.Unlock()
+
+	if !ok || subtle.ConstantTimeCompare([]byte(hashTokenSecret(secret)), []byte(entry.hash)) != 1 {
+		return "", "", false
+	}
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		log.Printf("rejecting expired token %s of %s", id, entry.user)
+		return "", "", false
+	}
+	return entry.user, entry.scope, true
+}
+
+// reloadIfNeeded reads the file, if it changed since the last read.
+// When the file is missing, all tokens are dropped; on other errors, the
+// previous tokens are kept.
+func (s *TokenStore) reloadIfNeeded() error {
+	var info, err = os.Stat(s.path)
+	if os.IsNotExist(err) {
+		s.tokens, s.modTime = make(map[string]tokenEntry), time.Time{}
+	}
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(s.modTime) {
+		return nil
+	}
+
+	entries, err := readTokenFile(s.path)
+	if err != nil {
+		return err
+	}
+	s.tokens = make(map[string]tokenEntry, len(entries))
+	for _, entry := range entries {
+		s.tokens[entry.id] = entry
+	}
+	s.modTime = info.ModTime()
+	return nil
+}
+
+// CreateToken adds a new token for the given user and scope to the token
+// file and returns it; this is the only time the token is revealed.
+// A validity of zero lets the token never expire.
+func CreateToken(path string, user string, scope string, validity time.Duration) (string, error) {
+	if user == "" || strings.ContainsAny(user, " \t\r\n") {
+		return "", fmt.Errorf("invalid user name: %q", user)
+	}
+	if scope != TokenScopeRead && scope != TokenScopeWrite {
+		return "", fmt.Errorf("invalid scope: %s", scope)
+	}
+
+	var entries, err = readTokenFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	var id = securecookie.GenerateRandomKey(tokenIDLengthInBytes)
+	var secret = securecookie.GenerateRandomKey(tokenSecretLengthInBytes)
+	if id == nil || secret == nil {
+		return "", fmt.Errorf("failed to generate random token")
+	}
+	var entry = tokenEntry{
+		id:    hex.EncodeToString(id),
+		user:  user,
+		scope: scope,
+	}
+	var encodedSecret = base64.RawURLEncoding.EncodeToString(secret)
+	entry.hash = hashTokenSecret(encodedSecret)
+	if validity > 0 {
+		entry.expires = time.Now().Add(validity).UTC().Truncate(time.Second)
+	}
+
+	if err := writeFileAtomically(path, formatTokenFile(append(entries, entry))); err != nil {
+		return "", err
+	}
+	return entry.id + "." + encodedSecret, nil
+}
+
+// RevokeToken removes the token with the given id from the token file.
+// The id is the part of the token before the dot.
+func RevokeToken(path string, id string) error {
+	var entries, err = readTokenFile(path)
+	if err != nil {
+		return err
+	}
+
+	var remaining = make([]tokenEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.id != id {
+			remaining = append(remaining, entry)
+		}
+	}
+	if len(remaining) == len(entries) {
+		return fmt.Errorf("no token with id %s", id)
+	}
+	return writeFileAtomically(path, formatTokenFile(remaining))
+}
+
+// splitToken returns id and secret of the given token.
+func splitToken(token string) (string, string, bool) {
+	var dot = strings.Index(token, ".")
+	if dot <= 0 || dot == len(token)-1 {
+		return "", "", false
+	}
+	return token[:dot], token[dot+1:], true
+}
+
+func hashTokenSecret(secret string) string {
+	var hash = sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+func readTokenFile(path string) ([]tokenEntry, error) {
+	var content, err = ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries = make([]tokenEntry, 0)
+	var scanner = bufio.NewScanner(bytes.NewReader(content))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		var line = strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var fields = strings.Fields(line)
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("%s, line %d: expected id, hash, user, scope and expiry", path, lineNumber)
+		}
+		var entry = tokenEntry{id: fields[0], hash: fields[1], user: fields[2], scope: fields[3]}
+		if entry.scope != TokenScopeRead && entry.scope != TokenScopeWrite {
+			return nil, fmt.Errorf("%s, line %d: invalid scope %s", path, lineNumber, entry.scope)
+		}
+		if fields[4] != tokenNeverExpires {
+			if entry.expires, err = time.Parse(time.RFC3339, fields[4]); err != nil {
+				return nil, fmt.Errorf("%s, line %d: %s", path, lineNumber, err)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+func formatTokenFile(entries []tokenEntry) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(tokenFileHeader)
+	for _, entry := range entries {
+		var expires = tokenNeverExpires
+		if !entry.expires.IsZero() {
+			expires = entry.expires.Format(time.RFC3339)
+		}
+		fmt.Fprintf(&buf, "%s %s %s %s %s\n", entry.id, entry.hash, entry.user, entry.scope, expires)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,174 @@
+package authenticator
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateTokenAndCheckIt(t *testing.T) {
+	var path, cleanUp = tokenFilePath(t)
+	defer cleanUp()
+
+	var token = createToken(t, path, TokenScopeWrite, time.Hour)
+	var sut = NewTokenStore(path)
+
+	if user, scope, ok := sut.CheckToken(token); !ok || user != "Aladdin" || scope != TokenScopeWrite {
+		t.Fatalf("expected write token of Aladdin, got %q, %q, %v", user, scope, ok)
+	}
+	if _, _, ok := sut.CheckToken(token + "x"); ok {
+		t.Fatalf("expected token with wrong secret to be rejected")
+	}
+	if content, _ := ioutil.ReadFile(path); strings.Contains(string(content), token[strings.Index(token, ".")+1:]) {
+		t.Fatalf("expected token secret not to be stored")
+	}
+}
+
+func TestRevokedTokenIsRejected(t *testing.T) {
+	var path, cleanUp = tokenFilePath(t)
+	defer cleanUp()
+
+	var token = createToken(t, path, TokenScopeRead, 0)
+	var other = createToken(t, path, TokenScopeRead, 0)
+	var sut = NewTokenStore(path)
+	sut.CheckToken(token)
+
+	if err := RevokeToken(path, token[:strings.Index(token, ".")]); err != nil {
+		t.Fatalf("couldn't revoke token: %s", err)
+	}
+	// HINT: make sure the change is seen, even with coarse modification times
+	os.Chtimes(path, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+
+	if _, _, ok := sut.CheckToken(token); ok {
+		t.Fatalf("expected revoked token to be rejected")
+	}
+	if _, _, ok := sut.CheckToken(other); !ok {
+		t.Fatalf("expected other token to be accepted")
+	}
+	if err := RevokeToken(path, "unknown"); err == nil {
+		t.Fatalf("expected error on unknown token id")
+	}
+}
+
+func TestTokenIsRejectedWhenFileIsDeleted(t *testing.T) {
+	var path, cleanUp = tokenFilePath(t)
+	defer cleanUp()
+
+	var token = createToken(t, path, TokenScopeRead, 0)
+	var sut = NewTokenStore(path)
+	if _, _, ok := sut.CheckToken(token); !ok {
+		t.Fatalf("expected token to be accepted")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("couldn't remove token file: %s", err)
+	}
+	if _, _, ok := sut.CheckToken(token); ok {
+		t.Fatalf("expected token to be rejected without token file")
+	}
+}
+
+func TestExpiredTokenIsRejected(t *testing.T) {
+	var path, cleanUp = tokenFilePath(t)
+	defer cleanUp()
+
+	var token = createToken(t, path, TokenScopeRead, time.Hour)
+	var content, _ = ioutil.ReadFile(path)
+	var expired = time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	var lines = strings.Split(string(content), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, token[:strings.Index(token, ".")]) {
+			lines[i] = line[:strings.LastIndex(line, " ")+1] + expired
+		}
+	}
+	if err := ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")), 0600); err != nil {
+		t.Fatalf("couldn't write token file: %s", err)
+	}
+
+	if _, _, ok := NewTokenStore(path).CheckToken(token); ok {
+		t.Fatalf("expected expired token to be rejected")
+	}
+}
+
+func TestMiddlewareHandler_authenticatesBearerToken(t *testing.T) {
+	var path, cleanUp = tokenFilePath(t)
+	defer cleanUp()
+	var requestAuth = newMockAuthenticator()
+	var sut = sutWithRequestAuthAndSessionAuth(requestAuth, newMockAuthenticator())
+	sut.tokens = NewTokenStore(path)
+	var req = requestWithBearerToken(createToken(t, path, TokenScopeRead, time.Hour))
+	var rsp = newMockResponseWriter()
+	var delegated = false
+
+	sut.MiddlewareHandler(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		delegated = true
+	})).ServeHTTP(rsp, req)
+
+	if !delegated {
+		t.Fatalf("expected request to be delegated")
+	}
+	if userID := requestAuth.UserID(req); userID != "Aladdin" {
+		t.Fatalf("Expected Aladdin to be authenticated, but was '%v'", userID)
+	}
+}
+
+func TestMiddlewareHandler_rejectsWritesWithReadToken(t *testing.T) {
+	var path, cleanUp = tokenFilePath(t)
+	defer cleanUp()
+	var sut = sutWithRequestAuthAndSessionAuth(newMockAuthenticator(), newMockAuthenticator())
+	sut.tokens = NewTokenStore(path)
+	var req = requestWithBearerToken(createToken(t, path, TokenScopeRead, time.Hour))
+	req.Method = "POST"
+	var rsp = newMockResponseWriter()
+
+	sut.MiddlewareHandler(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		t.Fatalf("expected request not to be delegated")
+	})).ServeHTTP(rsp, req)
+
+	if rsp.status != http.StatusForbidden {
+		t.Fatalf("expected status %d, got %d", http.StatusForbidden, rsp.status)
+	}
+}
+
+func TestMiddlewareHandler_rejectsInvalidToken(t *testing.T) {
+	var path, cleanUp = tokenFilePath(t)
+	defer cleanUp()
+	var sut = sutWithRequestAuthAndSessionAuth(newMockAuthenticator(), newMockAuthenticator())
+	sut.tokens = NewTokenStore(path)
+	var rsp = newMockResponseWriter()
+
+	sut.MiddlewareHandler(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		t.Fatalf("expected request not to be delegated")
+	})).ServeHTTP(rsp, requestWithBearerToken("0123.invalid"))
+
+	if rsp.status != http.StatusUnauthorized {
+		t.Fatalf("expected status %d, got %d", http.StatusUnauthorized, rsp.status)
+	}
+}
+
+func tokenFilePath(t *testing.T) (string, func()) {
+	var dir, err = ioutil.TempDir("", "gone_test_")
+	if err != nil {
+		t.Fatalf("couldn't create temp dir: %s", err)
+	}
+	return filepath.Join(dir, ".api-tokens"), func() { os.RemoveAll(dir) }
+}
+
+func createToken(t *testing.T, path string, scope string, validity time.Duration) string {
+	var token, err = CreateToken(path, "Aladdin", scope, validity)
+	if err != nil {
+		t.Fatalf("couldn't create token: %s", err)
+	}
+	return token
+}
+
+func requestWithBearerToken(token string) *http.Request {
+	var result = blankRequest()
+	result.Method = "GET"
+	result.Header.Set("Authorization", "Bearer "+token)
+	return result
+}
@@ -0,0 +1,167 @@
+package authenticator
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fxnn/gone/log"
+)
+
+// unixUserCacheDuration is how long Unix users and their groups are cached,
+// as looking them up means reading /etc/passwd and /etc/group.
+const unixUserCacheDuration = 1 * time.Minute
+
+// A UnixUser is the identity of a user of the operating system, as used when
+// checking file permissions.
+type UnixUser struct {
+	UID  uint32
+	GIDs []uint32
+}
+
+// HasGroup returns true iff the user is a member of the group with the given
+// id.
+func (u UnixUser) HasGroup(gid uint32) bool {
+	for _, g := range u.GIDs {
+		if g == gid {
+			return true
+		}
+	}
+	return false
+}
+
+// A UnixUserSource maps users to Unix users.
+type UnixUserSource interface {
+	// UnixUserOf returns the Unix user the given user maps to, or false if
+	// there's none.
+	UnixUserOf(user string) (UnixUser, bool)
+}
+
+// UnixUserAuthenticator is an Authenticator that also maps the user being
+// logged in to a Unix user.
+type UnixUserAuthenticator struct {
+	Authenticator
+	unixUsers UnixUserSource
+}
+
+// Ensure that UnixAuthenticator interface is implemented
+var _ UnixAuthenticator = (*UnixUserAuthenticator)(nil)
+
+// NewUnixUserAuthenticator creates an instance delegating to the given
+// Authenticator and mapping its users by the given UnixUserSource.
+func NewUnixUserAuthenticator(delegate Authenticator, unixUsers UnixUserSource) *UnixUserAuthenticator {
+	return &UnixUserAuthenticator{delegate, unixUsers}
+}
+
+func (a *UnixUserAuthenticator) UnixUser(request *http.Request) (UnixUser, bool) {
+	if !a.IsAuthenticated(request) {
+		return UnixUser{}, false
+	}
+	return a.unixUsers.UnixUserOf(a.UserID(request))
+}
+
+// systemUnixUsers looks up Unix users and their groups using the operating
+// system, i.e. /etc/passwd and /etc/group.
+type systemUnixUsers struct {
+	nameMapping func(user string) string
+	mutex       sync.Mutex
+	cache       map[string]unixUserCacheEntry
+}
+
+type unixUserCacheEntry struct {
+	unixUser UnixUser
+	ok       bool
+	expires  time.Time
+}
+
+// NewSystemUnixUsers creates a UnixUserSource mapping each user to the Unix
+// user of the same name.
+func NewSystemUnixUsers() UnixUserSource {
+	return newSystemUnixUsers(func(user string) string { return user })
+}
+
+// NewUnixUsersFile creates a UnixUserSource mapping users to Unix users as
+// given by a file with lines like "user: unixuser".
+// Users not contained in the file map to no Unix user.
+// The file is read once.
+func NewUnixUsersFile(path string) (UnixUserSource, error) {
+	var content, err = ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	mapping, err := parseUnixUsersFile(path, content)
+	if err != nil {
+		return nil, err
+	}
+	return newSystemUnixUsers(func(user string) string { return mapping[user] }), nil
+}
+
+func newSystemUnixUsers(nameMapping func(user string) string) *systemUnixUsers {
+	return &systemUnixUsers{nameMapping: nameMapping, cache: make(map[string]unixUserCacheEntry)}
+}
+
+func (s *systemUnixUsers) UnixUserOf(user string) (UnixUser, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if entry, ok := s.cache[user]; ok && time.Now().Before(entry.expires) {
+		return entry.unixUser, entry.ok
+	}
+
+	var unixUser, err = lookupUnixUser(s.nameMapping(user))
+	if err != nil {
+		log.Warnf("no Unix user for %s: %s", user, err)
+	}
+	s.cache[user] = unixUserCacheEntry{unixUser, err == nil, time.Now().Add(unixUserCacheDuration)}
+	return unixUser, err == nil
+}
+
+func lookupUnixUser(name string) (UnixUser, error) {
+	if name == "" {
+		return UnixUser{}, fmt.Errorf("not mapped")
+	}
+	var u, err = user.Lookup(name)
+	if err != nil {
+		return UnixUser{}, err
+	}
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return UnixUser{}, fmt.Errorf("unsupported uid %s", u.Uid)
+	}
+
+	groupIDs, err := u.GroupIds()
+	if err != nil {
+		// HINT: at least, the primary group is known
+		groupIDs = []string{u.Gid}
+	}
+	var result = UnixUser{UID: uint32(uid)}
+	for _, groupID := range groupIDs {
+		if gid, err := strconv.ParseUint(groupID, 10, 32); err == nil {
+			result.GIDs = append(result.GIDs, uint32(gid))
+		}
+	}
+	return result, nil
+}
+
+// parseUnixUsersFile returns the Unix user name of each user.
+func parseUnixUsersFile(path string, content []byte) (map[string]string, error) {
+	var mapping = make(map[string]string)
+	for lineNumber, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var fields = strings.SplitN(line, ":", 2)
+		if len(fields) != 2 || strings.TrimSpace(fields[0]) == "" || strings.TrimSpace(fields[1]) == "" {
+			return nil, fmt.Errorf("%s, line %d: expected user, colon and Unix user", path, lineNumber+1)
+		}
+		mapping[strings.TrimSpace(fields[0])] = strings.TrimSpace(fields[1])
+	}
+	return mapping, nil
+}
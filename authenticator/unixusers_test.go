@@ -0,0 +1,49 @@
+package authenticator
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestParseUnixUsersFile(t *testing.T) {
+	var mapping, err = parseUnixUsersFile("unix-users", []byte("# mapping\nAladdin: aladdin\n Jasmine : jas\n"))
+	if err != nil {
+		t.Fatalf("couldn't parse file: %s", err)
+	}
+	if mapping["Aladdin"] != "aladdin" || mapping["Jasmine"] != "jas" {
+		t.Fatalf("unexpected mapping: %v", mapping)
+	}
+
+	if _, err := parseUnixUsersFile("unix-users", []byte("Aladdin\n")); err == nil {
+		t.Fatalf("expected error on missing Unix user")
+	}
+}
+
+func TestUnixUserAuthenticatorIgnoresAnonymousUsers(t *testing.T) {
+	var sut = NewUnixUserAuthenticator(newMockAuthenticator(), &fixedUnixUsers{UnixUser{UID: 1000}})
+
+	if _, ok := sut.UnixUser(&http.Request{}); ok {
+		t.Fatalf("expected no Unix user for anonymous user")
+	}
+
+	sut.SetUserID(nil, &http.Request{}, "Aladdin")
+	if unixUser, ok := sut.UnixUser(&http.Request{}); !ok || unixUser.UID != 1000 {
+		t.Fatalf("expected Unix user 1000, got %v", unixUser)
+	}
+}
+
+func TestUnmappedUserHasNoUnixUser(t *testing.T) {
+	var sut = newSystemUnixUsers(func(user string) string { return "" })
+
+	if _, ok := sut.UnixUserOf("Aladdin"); ok {
+		t.Fatalf("expected no Unix user")
+	}
+}
+
+type fixedUnixUsers struct {
+	unixUser UnixUser
+}
+
+func (u *fixedUnixUsers) UnixUserOf(user string) (UnixUser, bool) {
+	return u.unixUser, true
+}
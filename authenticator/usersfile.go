@@ -0,0 +1,138 @@
+package authenticator
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fxnn/gone/log"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// usersFileBackend checks passwords against bcrypt hashes read from a users
+// file.
+// The file maps user names to hashes, either as JSON object or as YAML
+// mapping, depending on its extension:
+//
+//	{"Aladdin": "$2y$10$..."}
+//
+//	# YAML allows comments
+//	Aladdin: "$2y$10$..."
+//
+// Only such flat mappings are supported; nested YAML is not.
+// The file is reloaded when it changes.
+type usersFileBackend struct {
+	path    string
+	mutex   sync.Mutex
+	modTime time.Time
+	hashes  map[string]string
+}
+
+// NewUsersFileBackend creates a Backend reading users and bcrypt hashes from
+// the given JSON or YAML file.
+func NewUsersFileBackend(path string) Backend {
+	return &usersFileBackend{path: path, hashes: make(map[string]string)}
+}
+
+func (b *usersFileBackend) CheckPassword(user string, password string) bool {
+	var hash, ok = b.hashForUser(user)
+	if !ok {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+func (b *usersFileBackend) hashForUser(user string) (string, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if err := b.reloadIfNeeded(); err != nil {
+		log.Warnf("couldn't read users file: %s", err)
+	}
+	var hash, ok = b.hashes[user]
+	return hash, ok && user != ""
+}
+
+// reloadIfNeeded reads the file, if it changed since the last read.
+// When the file is missing, all users are dropped; on other errors, like an
+// invalid file, the previous users are kept.
+func (b *usersFileBackend) reloadIfNeeded() error {
+	var info, err = os.Stat(b.path)
+	if os.IsNotExist(err) {
+		b.hashes, b.modTime = make(map[string]string), time.Time{}
+	}
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(b.modTime) {
+		return nil
+	}
+
+	content, err := ioutil.ReadFile(b.path)
+	if err != nil {
+		return err
+	}
+	hashes, err := parseUsersFile(b.path, content)
+	if err != nil {
+		return err
+	}
+
+	b.hashes, b.modTime = hashes, info.ModTime()
+	return nil
+}
+
+func parseUsersFile(path string, content []byte) (map[string]string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		var hashes = make(map[string]string)
+		if err := json.Unmarshal(content, &hashes); err != nil {
+			return nil, fmt.Errorf("invalid JSON in %s: %s", path, err)
+		}
+		return hashes, nil
+	case ".yaml", ".yml":
+		var hashes, err = parseYAMLMapping(content)
+		if err != nil {
+			return nil, fmt.Errorf("invalid YAML in %s: %s", path, err)
+		}
+		return hashes, nil
+	}
+	return nil, fmt.Errorf("unknown users file format: %s", path)
+}
+
+// parseYAMLMapping parses a flat YAML mapping of strings to strings.
+func parseYAMLMapping(content []byte) (map[string]string, error) {
+	var result = make(map[string]string)
+	var scanner = bufio.NewScanner(bytes.NewReader(content))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		var line = scanner.Text()
+		var trimmed = strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			return nil, fmt.Errorf("line %d: nested values are not supported", lineNumber)
+		}
+
+		var parts = strings.SplitN(trimmed, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("line %d: expected 'key: value'", lineNumber)
+		}
+		result[unquoteYAML(parts[0])] = unquoteYAML(parts[1])
+	}
+	return result, scanner.Err()
+}
+
+func unquoteYAML(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,100 @@
+package authenticator
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestUsersFileBackendReadsJSON(t *testing.T) {
+	var sut, cleanUp = usersFileSut(t, "users.json", `{"Aladdin": "%s"}`)
+	defer cleanUp()
+
+	assertPasswordAccepted(t, sut, "Aladdin", "open sesame")
+	assertPasswordRejected(t, sut, "Aladdin", "wrong")
+	assertPasswordRejected(t, sut, "Thief", "open sesame")
+}
+
+func TestUsersFileBackendReadsYAML(t *testing.T) {
+	var sut, cleanUp = usersFileSut(t, "users.yaml", "# users\nAladdin: '%s'\n")
+	defer cleanUp()
+
+	assertPasswordAccepted(t, sut, "Aladdin", "open sesame")
+	assertPasswordRejected(t, sut, "Aladdin", "wrong")
+}
+
+func TestUsersFileBackendRejectsUsersWhenFileIsDeleted(t *testing.T) {
+	var path, cleanUp = usersFilePath(t, "users.json", `{"Aladdin": "%s"}`)
+	defer cleanUp()
+	var sut = NewUsersFileBackend(path)
+	assertPasswordAccepted(t, sut, "Aladdin", "open sesame")
+
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("couldn't remove users file: %s", err)
+	}
+	assertPasswordRejected(t, sut, "Aladdin", "open sesame")
+}
+
+func TestUsersFileBackendKeepsUsersWhenFileIsInvalid(t *testing.T) {
+	var path, cleanUp = usersFilePath(t, "users.json", `{"Aladdin": "%s"}`)
+	defer cleanUp()
+	var sut = NewUsersFileBackend(path)
+	assertPasswordAccepted(t, sut, "Aladdin", "open sesame")
+
+	if err := ioutil.WriteFile(path, []byte("{invalid"), 0600); err != nil {
+		t.Fatalf("couldn't write users file: %s", err)
+	}
+	// HINT: make sure the change is seen, even with coarse modification times
+	os.Chtimes(path, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	assertPasswordAccepted(t, sut, "Aladdin", "open sesame")
+}
+
+func TestUsersFileBackendRejectsNestedYAML(t *testing.T) {
+	if _, err := parseYAMLMapping([]byte("users:\n  Aladdin: secret\n")); err == nil {
+		t.Fatalf("expected error on nested YAML")
+	}
+}
+
+func usersFileSut(t *testing.T, name string, format string) (Backend, func()) {
+	var path, cleanUp = usersFilePath(t, name, format)
+	return NewUsersFileBackend(path), cleanUp
+}
+
+// usersFilePath writes a users file with the hash of "open sesame" filled into
+// the given format, and returns its path.
+func usersFilePath(t *testing.T, name string, format string) (string, func()) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("open sesame"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("couldn't hash password: %s", err)
+	}
+	dir, err := ioutil.TempDir("", "gone_test_")
+	if err != nil {
+		t.Fatalf("couldn't create temp dir: %s", err)
+	}
+
+	var path = filepath.Join(dir, name)
+	var content = []byte(fmt.Sprintf(format, hash))
+	if err := ioutil.WriteFile(path, content, 0600); err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("couldn't write users file: %s", err)
+	}
+
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func assertPasswordAccepted(t *testing.T, sut Backend, user string, password string) {
+	if !sut.CheckPassword(user, password) {
+		t.Fatalf("expected password of %s to be accepted", user)
+	}
+}
+
+func assertPasswordRejected(t *testing.T, sut Backend, user string, password string) {
+	if sut.CheckPassword(user, password) {
+		t.Fatalf("expected password of %s to be rejected", user)
+	}
+}
@@ -9,6 +9,9 @@ const (
 	CommandHelp Command = iota
 	CommandListen
 	CommandExportTemplates
+	CommandRotateSessionKeys
+	CommandCreateToken
+	CommandRevokeToken
 )
 
 // String returns the string representation of the command, as it's to be used
@@ -21,13 +24,20 @@ func (c Command) String() string {
 		return "listen"
 	case CommandExportTemplates:
 		return "export-templates"
+	case CommandRotateSessionKeys:
+		return "rotate-session-keys"
+	case CommandCreateToken:
+		return "create-token"
+	case CommandRevokeToken:
+		return "revoke-token"
 	}
 	return ""
 }
 
 // Commands returns all valid command values.
 func Commands() []Command {
-	return []Command{CommandHelp, CommandListen, CommandExportTemplates}
+	return []Command{CommandHelp, CommandListen, CommandExportTemplates, CommandRotateSessionKeys,
+		CommandCreateToken, CommandRevokeToken}
 }
 
 // StringToCommand interprets the given string as a Command.
@@ -13,6 +13,23 @@ var (
 	bindAddress                     string
 	requireSSLHeader                string
 	templatePath                    string
+	storeEngine                     string
+	authBackend                     string
+	usersFile                       string
+	groupsFile                      string
+	unixUsers                       string
+	ldapURL                         string
+	ldapUserDN                      string
+	sessionKeyFile                  string
+	tokenFile                       string
+	tokenUser                       string
+	tokenScope                      string
+	tokenValidityDays               int
+	tokenID                         string
+	requestTimeoutSeconds           int
+	lockTimeoutSeconds              int
+	trashRetentionDays              int
+	uploadMaxMegabytes              int
 	bruteforceMaxDelayMillis        int
 	bruteforceDelayStepMillis       int
 	bruteforceDropDelayAfterMinutes int
@@ -29,6 +46,48 @@ func init() {
 		"The `name` of a header to be required when logging in")
 	flag.StringVar(&templatePath, "template", DefaultTemplatePath,
 		"The `path` to a directory containing custom templates")
+	flag.StringVar(&storeEngine, "store", DefaultStore,
+		"The storage `engine`, either \""+StoreFile+"\" or \""+StoreGit+"\"")
+	flag.StringVar(&authBackend, "auth", DefaultAuthBackend,
+		"The authentication `backend`, one of \""+AuthHtpasswd+"\", \""+AuthUsersFile+
+			"\" or \""+AuthLDAP+"\"")
+	flag.StringVar(&usersFile, "users-file", DefaultUsersFile,
+		"The `path` to a JSON or YAML file mapping users to bcrypt hashes")
+	flag.StringVar(&groupsFile, "groups-file", DefaultGroupsFile,
+		"The `path` to a file with lines like \"group: user1 user2\", used by .acl files")
+	flag.StringVar(&unixUsers, "unix-users", DefaultUnixUsers,
+		"Check file owner and group permissions by mapping users to Unix users, either \""+
+			UnixUsersSystem+"\" or the `path` to a file with lines like \"user: unixuser\"")
+	flag.StringVar(&ldapURL, "ldap-url", "",
+		"The ldap:// or ldaps:// `URL` of the LDAP server")
+	flag.StringVar(&ldapUserDN, "ldap-user-dn", "",
+		"The `pattern` of the users' DNs, like uid=%s,ou=people,dc=example,dc=org")
+
+	flag.StringVar(&sessionKeyFile, "session-key-file", DefaultSessionKeyFile,
+		"The `path` to the file holding the session keys, shared by all instances")
+
+	flag.StringVar(&tokenFile, "token-file", DefaultTokenFile,
+		"The `path` to the file holding the hashes of API tokens")
+	flag.StringVar(&tokenUser, "token-user", "",
+		"The `user` to create an API token for, with create-token")
+	flag.StringVar(&tokenScope, "token-scope", DefaultTokenScope,
+		"The `scope` of the API token to create, either \""+TokenScopeRead+"\" or \""+TokenScopeWrite+"\"")
+	flag.IntVar(&tokenValidityDays, "token-valid-days", int(DefaultTokenValidity/(24*time.Hour)),
+		"The number of `days` the API token to create is valid, or 0 for ever")
+	flag.StringVar(&tokenID, "token-id", "",
+		"The `id` of the API token to revoke, with revoke-token")
+
+	flag.IntVar(&requestTimeoutSeconds, "request-timeout",
+		int(DefaultRequestTimeout/time.Second),
+		"The number of `seconds` after which serving a request is stopped, or 0 for no limit")
+	flag.IntVar(&lockTimeoutSeconds, "lock-timeout",
+		int(DefaultLockTimeout/time.Second),
+		"The number of `seconds` a write waits for other writers of the same file")
+	flag.IntVar(&trashRetentionDays, "trash-retention", int(DefaultTrashRetention/(24*time.Hour)),
+		"The number of `days` deleted files are kept in the trash, or 0 for ever")
+	flag.IntVar(&uploadMaxMegabytes, "upload-max-size",
+		int(DefaultUploadMaxBytes/(1024*1024)),
+		"The max number of `MiB` to be uploaded at once")
 
 	flag.IntVar(&bruteforceMaxDelayMillis, "bruteforce-max-delay",
 		int(DefaultBruteforceMaxDelay/time.Millisecond),
@@ -54,6 +113,23 @@ func FromCommandline() Config {
 	c.BindAddress = bindAddress
 	c.RequireSSLHeader = requireSSLHeader
 	c.TemplatePath = templatePath
+	c.Store = storeEngine
+	c.AuthBackend = authBackend
+	c.UsersFile = usersFile
+	c.GroupsFile = groupsFile
+	c.UnixUsers = unixUsers
+	c.LDAPURL = ldapURL
+	c.LDAPUserDN = ldapUserDN
+	c.SessionKeyFile = sessionKeyFile
+	c.TokenFile = tokenFile
+	c.TokenUser = tokenUser
+	c.TokenScope = tokenScope
+	c.TokenValidity = time.Duration(tokenValidityDays) * 24 * time.Hour
+	c.TokenID = tokenID
+	c.RequestTimeout = time.Duration(requestTimeoutSeconds) * time.Second
+	c.LockTimeout = time.Duration(lockTimeoutSeconds) * time.Second
+	c.TrashRetention = time.Duration(trashRetentionDays) * 24 * time.Hour
+	c.UploadMaxBytes = int64(uploadMaxMegabytes) * 1024 * 1024
 	c.BruteforceMaxDelay = time.Duration(bruteforceMaxDelayMillis) * time.Millisecond
 	c.BruteforceDelayStep = time.Duration(bruteforceDelayStepMillis) * time.Millisecond
 	c.BruteforceDropDelayAfter = time.Duration(bruteforceDropDelayAfterMinutes) * time.Minute
@@ -64,6 +140,26 @@ func FromCommandline() Config {
 func parseCommandline() Command {
 	flag.Parse()
 
+	if storeEngine != StoreFile && storeEngine != StoreGit {
+		fmt.Fprintf(out, "Invalid store: %s", storeEngine)
+		fmt.Fprintln(out)
+		PrintUsage()
+		os.Exit(2)
+	}
+
+	if authBackend != AuthHtpasswd && authBackend != AuthUsersFile && authBackend != AuthLDAP {
+		fmt.Fprintf(out, "Invalid auth backend: %s", authBackend)
+		fmt.Fprintln(out)
+		PrintUsage()
+		os.Exit(2)
+	}
+
+	if authBackend == AuthLDAP && (ldapURL == "" || ldapUserDN == "") {
+		fmt.Fprintln(out, "The ldap auth backend requires -ldap-url and -ldap-user-dn")
+		PrintUsage()
+		os.Exit(2)
+	}
+
 	if flag.NArg() > 1 {
 		fmt.Fprintln(out, "No more than one command allowed")
 		PrintUsage()
@@ -76,6 +172,7 @@ func parseCommandline() Command {
 			PrintUsage()
 			os.Exit(2)
 		} else {
+			validateTokenFlags(cmd)
 			return cmd
 		}
 	}
@@ -87,6 +184,30 @@ func parseCommandline() Command {
 	return DefaultCommand
 }
 
+func validateTokenFlags(cmd Command) {
+	if cmd == CommandCreateToken && tokenUser == "" {
+		fmt.Fprintln(out, "The create-token command requires -token-user")
+		PrintUsage()
+		os.Exit(2)
+	}
+	if cmd == CommandCreateToken && tokenScope != TokenScopeRead && tokenScope != TokenScopeWrite {
+		fmt.Fprintf(out, "Invalid token scope: %s", tokenScope)
+		fmt.Fprintln(out)
+		PrintUsage()
+		os.Exit(2)
+	}
+	if cmd == CommandCreateToken && tokenValidityDays < 0 {
+		fmt.Fprintln(out, "The token validity must not be negative")
+		PrintUsage()
+		os.Exit(2)
+	}
+	if cmd == CommandRevokeToken && tokenID == "" {
+		fmt.Fprintln(out, "The revoke-token command requires -token-id")
+		PrintUsage()
+		os.Exit(2)
+	}
+}
+
 func PrintUsage() {
 	fmt.Fprintf(out, "Usage: %s [-flags ...] [command]", os.Args[0])
 	fmt.Fprintln(out)
@@ -19,11 +19,93 @@ type Config struct {
 	// is set.
 	RequireSSLHeader string
 
+	// Store selects the storage engine, one of the Store* constants.
+	// This defaults to the DefaultStore constant.
+	Store string
+
+	// AuthBackend selects where users and passwords come from, one of the
+	// Auth* constants.
+	// This defaults to the DefaultAuthBackend constant.
+	AuthBackend string
+
+	// UsersFile is the path to the JSON or YAML file used by the AuthUsersFile
+	// backend.
+	// This defaults to the DefaultUsersFile constant.
+	UsersFile string
+
+	// GroupsFile is the path to the file assigning users to the groups used
+	// in access control lists.
+	// This defaults to the DefaultGroupsFile constant.
+	GroupsFile string
+
+	// UnixUsers enables checking the owner and group permissions of files for
+	// authenticated users, by mapping them to Unix users.
+	// It's either UnixUsersSystem, or the path to a file with lines like
+	// "user: unixuser".
+	// This defaults to the empty string, meaning that authenticated users may
+	// access all files the application may access.
+	UnixUsers string
+
+	// LDAPURL is the ldap:// or ldaps:// URL of the server used by the
+	// AuthLDAP backend.
+	LDAPURL string
+
+	// LDAPUserDN is the pattern of the users' distinguished names, with %s
+	// being replaced by the user name, as used by the AuthLDAP backend.
+	LDAPUserDN string
+
+	// SessionKeyFile is the path to the file holding the keys of session
+	// cookies, which is created when missing.
+	// This defaults to the empty string, meaning a hidden file in the content
+	// root is used.
+	SessionKeyFile string
+
+	// TokenFile is the path to the file holding the hashes of API tokens.
+	// This defaults to the DefaultTokenFile constant.
+	TokenFile string
+
+	// TokenUser is the user a token is created for by CommandCreateToken.
+	TokenUser string
+
+	// TokenScope is the scope of a token created by CommandCreateToken, one of
+	// the TokenScope* constants.
+	// This defaults to the DefaultTokenScope constant.
+	TokenScope string
+
+	// TokenValidity is how long a token created by CommandCreateToken is
+	// valid; zero means forever.
+	// This defaults to the DefaultTokenValidity constant.
+	TokenValidity time.Duration
+
+	// TokenID identifies the token to be revoked by CommandRevokeToken.
+	TokenID string
+
 	// TemplatePath is the path to the directory containing custom templates.
 	// This defaults to the empty string, meaning the static templates
 	// delivered with the application are used.
 	TemplatePath string
 
+	// RequestTimeout is the time after which serving a request is stopped,
+	// including all work in the store.
+	// This defaults to the DefaultRequestTimeout constant, meaning there's no
+	// such deadline.
+	RequestTimeout time.Duration
+
+	// LockTimeout is the time a write waits for other writers of the same
+	// file, including other processes, before it fails.
+	// This defaults to the DefaultLockTimeout constant.
+	LockTimeout time.Duration
+
+	// TrashRetention is the time deleted files are kept in the trash, from
+	// which they may be restored.
+	// This defaults to the DefaultTrashRetention constant; zero keeps them
+	// for ever.
+	TrashRetention time.Duration
+
+	// UploadMaxBytes is the maximum size of a request uploading files.
+	// This defaults to the DefaultUploadMaxBytes constant.
+	UploadMaxBytes int64
+
 	// BruteforceMaxDelay is the maximum amount of time a login request is
 	// delayed in order to prevent bruteforce attacks.
 	BruteforceMaxDelay time.Duration
@@ -41,11 +123,58 @@ type Config struct {
 	BruteforceDropDelayAfter time.Duration
 }
 
+// Storage engines that can be selected via the Store configuration.
+const (
+	// StoreFile keeps content as plain files, with revisions in a hidden
+	// directory.
+	StoreFile = "file"
+	// StoreGit keeps content as plain files and commits each change to a
+	// local git repository.
+	StoreGit = "git"
+)
+
+// Authentication backends that can be selected via the AuthBackend
+// configuration.
+const (
+	// AuthHtpasswd reads users from the .htpasswd file in the content root.
+	AuthHtpasswd = "htpasswd"
+	// AuthUsersFile reads users and bcrypt hashes from a JSON or YAML file.
+	AuthUsersFile = "users-file"
+	// AuthLDAP checks passwords by binding to an LDAP server.
+	AuthLDAP = "ldap"
+)
+
+// Scopes of API tokens that can be selected via the TokenScope
+// configuration.
+const (
+	// TokenScopeRead only allows requests that don't change content.
+	TokenScopeRead = "read"
+	// TokenScopeWrite allows all requests.
+	TokenScopeWrite = "write"
+)
+
+// UnixUsersSystem is the UnixUsers configuration mapping each user to the
+// Unix user of the same name.
+const UnixUsersSystem = "system"
+
 const (
 	DefaultCommand                  = CommandListen
 	DefaultBindAddress              = ":8080"
 	DefaultRequireSSLHeader         = ""
+	DefaultStore                    = StoreFile
+	DefaultAuthBackend              = AuthHtpasswd
+	DefaultUsersFile                = ".users.json"
+	DefaultGroupsFile               = ".htgroups"
+	DefaultUnixUsers                = ""
+	DefaultSessionKeyFile           = ""
+	DefaultTokenFile                = ".api-tokens"
+	DefaultTokenScope               = TokenScopeRead
+	DefaultTokenValidity            = 90 * 24 * time.Hour
 	DefaultTemplatePath             = ""
+	DefaultRequestTimeout           = 0
+	DefaultLockTimeout              = 10 * time.Second
+	DefaultTrashRetention           = 30 * 24 * time.Hour
+	DefaultUploadMaxBytes           = 32 * 1024 * 1024
 	DefaultBruteforceMaxDelay       = 20 * time.Second
 	DefaultBruteforceDelayStep      = 1 * time.Second
 	DefaultBruteforceDropDelayAfter = 4 * time.Hour
@@ -0,0 +1,221 @@
+package diff
+
+import (
+	"strings"
+)
+
+// maxEditDistance limits the effort spent on finding a minimal diff.
+// Texts differing in more lines are simply treated as being replaced
+// completely.
+const maxEditDistance = 2000
+
+// LineType tells whether a line is part of both texts, or only of one of
+// them.
+type LineType int
+
+const (
+	Unchanged LineType = iota
+	Added
+	Removed
+)
+
+// Line is one line of a diff.
+type Line struct {
+	Type LineType
+	Text string
+
+	// OldNumber is the 1-based line number inside the old text, or 0 for
+	// added lines.
+	OldNumber int
+
+	// NewNumber is the 1-based line number inside the new text, or 0 for
+	// removed lines.
+	NewNumber int
+}
+
+// IsAdded returns true iff the line is only part of the new text.
+func (l Line) IsAdded() bool {
+	return l.Type == Added
+}
+
+// IsRemoved returns true iff the line is only part of the old text.
+func (l Line) IsRemoved() bool {
+	return l.Type == Removed
+}
+
+// Prefix returns the character used for this line in unified diffs.
+func (l Line) Prefix() string {
+	switch l.Type {
+	case Added:
+		return "+"
+	case Removed:
+		return "-"
+	}
+	return " "
+}
+
+// Strings computes the diff between the lines of both texts.
+func Strings(oldText, newText string) []Line {
+	return Lines(splitLines(oldText), splitLines(newText))
+}
+
+// IsEqual returns true iff the diff contains no changes.
+func IsEqual(lines []Line) bool {
+	for _, l := range lines {
+		if l.Type != Unchanged {
+			return false
+		}
+	}
+	return true
+}
+
+// Lines computes the diff between both lists of lines, using Myers'
+// algorithm.
+// Each line of both lists is contained in the result exactly once.
+func Lines(oldLines, newLines []string) []Line {
+	// HINT: common prefix and suffix are cheap to find and reduce the effort
+	var prefix = 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	var suffix = 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	var result = make([]Line, 0, len(oldLines)+len(newLines))
+	for i := 0; i < prefix; i++ {
+		result = append(result, Line{Unchanged, oldLines[i], i + 1, i + 1})
+	}
+
+	var a, b = oldLines[prefix : len(oldLines)-suffix], newLines[prefix : len(newLines)-suffix]
+	for _, l := range myers(a, b) {
+		if l.OldNumber != 0 {
+			l.OldNumber += prefix
+		}
+		if l.NewNumber != 0 {
+			l.NewNumber += prefix
+		}
+		result = append(result, l)
+	}
+
+	for i := suffix; i > 0; i-- {
+		var oldIndex, newIndex = len(oldLines) - i, len(newLines) - i
+		result = append(result, Line{Unchanged, oldLines[oldIndex], oldIndex + 1, newIndex + 1})
+	}
+
+	return result
+}
+
+// myers implements the algorithm described in "An O(ND) Difference Algorithm
+// and Its Variations" by Eugene W. Myers.
+func myers(a, b []string) []Line {
+	var n, m = len(a), len(b)
+	var max = n + m
+	if max == 0 {
+		return nil
+	}
+	if n-m > maxEditDistance || m-n > maxEditDistance {
+		// HINT: the edit distance is at least the difference in line counts
+		return replaceAll(a, b)
+	}
+	if max > maxEditDistance {
+		max = maxEditDistance
+	}
+
+	// HINT: v[k+offset] is the furthest x reached on diagonal k
+	var offset = max + 1
+	var v = make([]int, 2*max+3)
+	// HINT: trace[d][k+d] is v[k+offset] before step d, for -d <= k <= d
+	var trace [][]int
+
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[k-1+offset] < v[k+1+offset]) {
+				x = v[k+1+offset]
+			} else {
+				x = v[k-1+offset] + 1
+			}
+			var y = x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[k+offset] = x
+
+			if x >= n && y >= m {
+				return backtrack(a, b, trace)
+			}
+		}
+	}
+
+	return replaceAll(a, b)
+}
+
+// backtrack walks back through the trace of the myers algorithm, collecting
+// the lines of the diff.
+func backtrack(a, b []string, trace [][]int) []Line {
+	var x, y = len(a), len(b)
+	var reversed = make([]Line, 0, len(a)+len(b))
+
+	for d := len(trace) - 1; d > 0; d-- {
+		var v = trace[d]
+		var k = x - y
+
+		var prevK int
+		if k == -d || (k != d && v[k-1+d] < v[k+1+d]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		var prevX = v[prevK+d]
+		var prevY = prevX - prevK
+
+		for x > prevX && y > prevY {
+			reversed = append(reversed, Line{Unchanged, a[x-1], x, y})
+			x--
+			y--
+		}
+		if x == prevX {
+			reversed = append(reversed, Line{Added, b[y-1], 0, y})
+		} else {
+			reversed = append(reversed, Line{Removed, a[x-1], x, 0})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		reversed = append(reversed, Line{Unchanged, a[x-1], x, y})
+		x--
+		y--
+	}
+
+	var result = make([]Line, len(reversed))
+	for i, l := range reversed {
+		result[len(reversed)-1-i] = l
+	}
+	return result
+}
+
+func replaceAll(a, b []string) []Line {
+	var result = make([]Line, 0, len(a)+len(b))
+	for i, text := range a {
+		result = append(result, Line{Removed, text, i + 1, 0})
+	}
+	for i, text := range b {
+		result = append(result, Line{Added, text, 0, i + 1})
+	}
+	return result
+}
+
+// splitLines splits the text into lines, ignoring a trailing line break.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	text = strings.Replace(text, "\r\n", "\n", -1)
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,83 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestStringsOfEqualTexts(t *testing.T) {
+	var lines = Strings("a\nb\nc\n", "a\nb\nc\n")
+
+	if !IsEqual(lines) {
+		t.Fatalf("expected no changes, but got %v", lines)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, but got %d", len(lines))
+	}
+}
+
+func TestStringsWithChangedLine(t *testing.T) {
+	var lines = Strings("a\nb\nc", "a\nx\nc")
+
+	assertUnified(t, lines, " a\n-b\n+x\n c\n")
+}
+
+func TestStringsWithAddedAndRemovedLines(t *testing.T) {
+	var lines = Strings("a\nb\nc\nd\ne", "x\na\nc\nd\ny\ne")
+
+	assertUnified(t, lines, "+x\n a\n-b\n c\n d\n+y\n e\n")
+}
+
+func TestStringsFromEmptyText(t *testing.T) {
+	var lines = Strings("", "a\nb")
+
+	assertUnified(t, lines, "+a\n+b\n")
+	if lines[1].NewNumber != 2 {
+		t.Fatalf("expected new line number 2, but got %d", lines[1].NewNumber)
+	}
+}
+
+func TestStringsReplacesTextsDifferingTooMuch(t *testing.T) {
+	var oldLines, newLines []string
+	for i := 0; i < 3*maxEditDistance; i++ {
+		oldLines = append(oldLines, fmt.Sprintf("old %d", i))
+		newLines = append(newLines, fmt.Sprintf("new %d", i))
+	}
+
+	var lines = Lines(oldLines, newLines)
+
+	if len(lines) != len(oldLines)+len(newLines) {
+		t.Fatalf("expected %d lines, but got %d", len(oldLines)+len(newLines), len(lines))
+	}
+	if !lines[0].IsRemoved() || !lines[len(lines)-1].IsAdded() {
+		t.Fatalf("expected all old lines removed and all new lines added")
+	}
+}
+
+func TestHunksGroupsNearbyChanges(t *testing.T) {
+	var oldText = "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16\n17\n18\n19\n20"
+	var newText = strings.Replace(strings.Replace(oldText, "\n3\n", "\nx\n", 1), "\n18\n", "\ny\n", 1)
+
+	var hunks = Hunks(Strings(oldText, newText), DefaultContext)
+
+	if len(hunks) != 2 {
+		t.Fatalf("expected 2 hunks, but got %d", len(hunks))
+	}
+	if hunks[0].Header() != "@@ -1,6 +1,6 @@" {
+		t.Fatalf("unexpected header of first hunk: %s", hunks[0].Header())
+	}
+	if hunks[1].Header() != "@@ -15,6 +15,6 @@" {
+		t.Fatalf("unexpected header of second hunk: %s", hunks[1].Header())
+	}
+}
+
+func assertUnified(t *testing.T, lines []Line, expected string) {
+	var actual string
+	for _, l := range lines {
+		actual += l.Prefix() + l.Text + "\n"
+	}
+	if actual != expected {
+		t.Fatalf("expected diff\n%s\nbut got\n%s", expected, actual)
+	}
+}
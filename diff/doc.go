@@ -0,0 +1,3 @@
+// Package diff computes line-based differences between two texts and groups
+// them into hunks, as known from the unified diff format.
+package diff
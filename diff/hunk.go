@@ -0,0 +1,81 @@
+package diff
+
+import "fmt"
+
+// DefaultContext is the number of unchanged lines shown around each change,
+// as used by the diff and patch tools.
+const DefaultContext = 3
+
+// Hunk is a group of changed lines, surrounded by some unchanged lines.
+type Hunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	Lines              []Line
+}
+
+// Header returns the hunk's header line as used in unified diffs, e.g.
+// "@@ -1,4 +1,5 @@".
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+}
+
+// Hunks groups the changes in the given diff into hunks, each with up to
+// context unchanged lines before and after the changes.
+// Changes being less than 2*context lines apart are grouped into one hunk.
+func Hunks(lines []Line, context int) []Hunk {
+	var result []Hunk
+	var start, end = -1, -1
+
+	for i, l := range lines {
+		if l.Type == Unchanged {
+			continue
+		}
+		var from, to = max(0, i-context), min(len(lines), i+context+1)
+		if start >= 0 && from <= end {
+			end = to
+			continue
+		}
+		if start >= 0 {
+			result = append(result, newHunk(lines[start:end]))
+		}
+		start, end = from, to
+	}
+	if start >= 0 {
+		result = append(result, newHunk(lines[start:end]))
+	}
+
+	return result
+}
+
+func newHunk(lines []Line) Hunk {
+	var h = Hunk{Lines: lines}
+	for _, l := range lines {
+		if l.Type != Added {
+			if h.OldStart == 0 {
+				h.OldStart = l.OldNumber
+			}
+			h.OldLines++
+		}
+		if l.Type != Removed {
+			if h.NewStart == 0 {
+				h.NewStart = l.NewNumber
+			}
+			h.NewLines++
+		}
+	}
+	return h
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -7,6 +7,7 @@
 package main
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/fxnn/gone/authenticator"
@@ -15,11 +16,18 @@ import (
 	"github.com/fxnn/gone/http"
 	"github.com/fxnn/gone/http/templates"
 	"github.com/fxnn/gone/log"
+	"github.com/fxnn/gone/store"
 	"github.com/fxnn/gone/store/filestore"
+	"github.com/fxnn/gone/store/gitstore"
+	"github.com/fxnn/gone/thumbnail"
 	"github.com/fxnn/gopath"
 )
 
-const defaultTemplateDirectoryName = ".templates"
+const (
+	defaultTemplateDirectoryName = ".templates"
+	thumbnailDirectoryName       = ".thumbnails"
+	defaultSessionKeyFileName    = ".session-keys"
+)
 
 func main() {
 	log.Printf("--- gone startup ---")
@@ -29,6 +37,12 @@ func main() {
 	switch cfg.Command {
 	case config.CommandExportTemplates:
 		exportTemplates(cfg)
+	case config.CommandRotateSessionKeys:
+		rotateSessionKeys(cfg)
+	case config.CommandCreateToken:
+		createToken(cfg)
+	case config.CommandRevokeToken:
+		revokeToken(cfg)
 	case config.CommandListen:
 		listen(cfg)
 	case config.CommandHelp:
@@ -47,21 +61,96 @@ func exportTemplates(cfg config.Config) {
 	}
 }
 
+func rotateSessionKeys(cfg config.Config) {
+	var path = sessionKeyFilePath(contentRoot(), cfg)
+	if err := authenticator.RotateSessionKeys(path.Path()); err != nil {
+		log.Fatalf("error rotating session keys: %s", err)
+	}
+	log.Printf("rotated session keys in %s; restart all instances to use them", path.Path())
+}
+
+func createToken(cfg config.Config) {
+	var token, err = authenticator.CreateToken(cfg.TokenFile, cfg.TokenUser, cfg.TokenScope, cfg.TokenValidity)
+	if err != nil {
+		log.Fatalf("error creating token: %s", err)
+	}
+	log.Printf("created %s token for %s in %s", cfg.TokenScope, cfg.TokenUser, cfg.TokenFile)
+	// HINT: the token is the last line of output, without log prefix
+	fmt.Println(token)
+}
+
+func revokeToken(cfg config.Config) {
+	if err := authenticator.RevokeToken(cfg.TokenFile, cfg.TokenID); err != nil {
+		log.Fatalf("error revoking token: %s", err)
+	}
+	log.Printf("revoked token %s in %s", cfg.TokenID, cfg.TokenFile)
+}
+
 func listen(cfg config.Config) {
 	var cr = contentRoot()
 
-	var auth = authenticator.NewContextAuthenticator()
-	var httpAuth = createHttpAuthenticator(auth, cr, cfg)
-	var store = filestore.New(cr, auth)
+	var auth = createAuthenticator(cfg)
 	var loader = createLoader(cr, cfg)
+	var httpAuth = createHttpAuthenticator(auth, cr, cfg, loader)
+	var store = createStore(auth, cr, cfg)
+	var thumbnails = thumbnail.NewCache(cr.JoinPath(thumbnailDirectoryName).Path())
 
-	http.ListenAndServe(cfg.BindAddress, httpAuth, store, loader)
+	http.ListenAndServe(cfg.BindAddress, cfg.RequestTimeout, cfg.UploadMaxBytes, httpAuth, store, thumbnails, loader)
+}
+
+func createAuthenticator(cfg config.Config) authenticator.Authenticator {
+	var auth = authenticator.NewContextAuthenticatorWithGroups(authenticator.NewGroupsFile(cfg.GroupsFile))
+
+	switch cfg.UnixUsers {
+	case "":
+		return auth
+	case config.UnixUsersSystem:
+		log.Printf("checking file permissions of users as Unix users of the same name (by configuration)")
+		return authenticator.NewUnixUserAuthenticator(auth, authenticator.NewSystemUnixUsers())
+	}
+
+	log.Printf("checking file permissions of users as Unix users mapped by %s (by configuration)", cfg.UnixUsers)
+	var unixUsers, err = authenticator.NewUnixUsersFile(cfg.UnixUsers)
+	if err != nil {
+		log.Fatalf("error reading Unix users: %s", err)
+	}
+	return authenticator.NewUnixUserAuthenticator(auth, unixUsers)
+}
+
+func createStore(
+	auth authenticator.Authenticator,
+	contentRoot gopath.GoPath,
+	cfg config.Config,
+) store.Store {
+	var s = createFileStore(auth, contentRoot, cfg)
+	if err := s.StartIndex(); err != nil {
+		log.Warnf("searching without index: %s", err)
+	}
+	return s
+}
+
+func createFileStore(
+	auth authenticator.Authenticator,
+	contentRoot gopath.GoPath,
+	cfg config.Config,
+) filestore.Store {
+	if cfg.Store == config.StoreGit {
+		log.Printf("committing changes to git repository in %s (by configuration)", contentRoot.Path())
+		var s, err = gitstore.New(contentRoot, auth, cfg.LockTimeout, cfg.TrashRetention)
+		if err != nil {
+			log.Fatalf("error opening git store: %s", err)
+		}
+		return s
+	}
+
+	return filestore.New(contentRoot, auth, cfg.LockTimeout, cfg.TrashRetention)
 }
 
 func createHttpAuthenticator(
 	auth authenticator.Authenticator,
 	contentRoot gopath.GoPath,
 	cfg config.Config,
+	loader templates.Loader,
 ) authenticator.HttpAuthenticator {
 	var bruteBlocker = bruteblocker.New(
 		cfg.BruteforceMaxDelay,
@@ -75,12 +164,49 @@ func createHttpAuthenticator(
 	}
 	return authenticator.NewHttpBasicAuthenticator(
 		auth,
-		htpasswdFilePath(contentRoot),
+		createAuthBackend(contentRoot, cfg),
 		cfg.RequireSSLHeader,
 		bruteBlocker,
+		loader,
+		loadSessionKeys(contentRoot, cfg),
+		authenticator.NewTokenStore(cfg.TokenFile),
 	)
 }
 
+func loadSessionKeys(contentRoot gopath.GoPath, cfg config.Config) [][]byte {
+	var path = sessionKeyFilePath(contentRoot, cfg)
+	var keyPairs, err = authenticator.LoadSessionKeys(path.Path())
+	if err != nil {
+		log.Fatalf("error loading session keys: %s", err)
+	}
+	log.Printf("using session keys from %s", path.Path())
+	return keyPairs
+}
+
+func sessionKeyFilePath(contentRoot gopath.GoPath, cfg config.Config) gopath.GoPath {
+	if cfg.SessionKeyFile != "" {
+		return gopath.FromPath(cfg.SessionKeyFile)
+	}
+	return contentRoot.JoinPath(defaultSessionKeyFileName)
+}
+
+func createAuthBackend(contentRoot gopath.GoPath, cfg config.Config) authenticator.Backend {
+	switch cfg.AuthBackend {
+	case config.AuthUsersFile:
+		log.Printf("using authentication data from %s (by configuration)", cfg.UsersFile)
+		return authenticator.NewUsersFileBackend(cfg.UsersFile)
+	case config.AuthLDAP:
+		log.Printf("authenticating against LDAP server %s (by configuration)", cfg.LDAPURL)
+		var backend, err = authenticator.NewLDAPBackend(cfg.LDAPURL, cfg.LDAPUserDN)
+		if err != nil {
+			log.Fatalf("error configuring LDAP: %s", err)
+		}
+		return backend
+	}
+
+	return authenticator.NewHtpasswdBackend(htpasswdFilePath(contentRoot))
+}
+
 func htpasswdFilePath(contentRoot gopath.GoPath) gopath.GoPath {
 	htpasswdFile := contentRoot.JoinPath(".htpasswd")
 	if !htpasswdFile.IsExists() {
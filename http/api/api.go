@@ -0,0 +1,365 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fxnn/gone/log"
+	"github.com/fxnn/gone/store"
+)
+
+// JSONMimeType is the MIME type of all the API's responses.
+const JSONMimeType = "application/json"
+
+// The API is a HTTP handler serving files and directory listings as JSON.
+//
+//	GET /path/file.md     returns a File, including its content for text files
+//	GET /path/dir/        returns a Directory, listing its entries
+//	PUT /path/file.md     stores the request body as the file's content
+//	DELETE /path/file.md  deletes the file
+//
+// A PUT request's body is either the raw content or, with Content-Type
+// application/json, an object like {"content": "..."}.
+// Errors are reported as object like {"error": "..."}.
+//
+// Text files are served with an ETag, which is the hex encoded SHA-256 sum
+// of their content.
+// A PUT request with If-Match only replaces the file when its current
+// content still has that ETag, and fails with 412 Precondition Failed
+// otherwise; "If-Match: *" only replaces existing files.
+type API struct {
+	store           store.Store
+	maxContentBytes int64
+}
+
+// File is the JSON representation of a file.
+type File struct {
+	Path     string    `json:"path"`
+	MimeType string    `json:"mimeType"`
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"modTime"`
+	// Content is only given for text files; other files are fetched
+	// without accepting application/json.
+	Content *string `json:"content,omitempty"`
+}
+
+// Directory is the JSON representation of a directory listing.
+type Directory struct {
+	Path    string  `json:"path"`
+	Entries []Entry `json:"entries"`
+}
+
+// Entry is the JSON representation of a store.Entry.
+type Entry struct {
+	Name        string    `json:"name"`
+	Path        string    `json:"path"`
+	IsDirectory bool      `json:"isDirectory"`
+	Size        int64     `json:"size"`
+	ModTime     time.Time `json:"modTime"`
+	MimeType    string    `json:"mimeType"`
+}
+
+type contentRequest struct {
+	Content *string `json:"content"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// New initializes a new instance ready to use.
+// Requests changing content are limited to maxContentBytes.
+func New(s store.Store, maxContentBytes int64) *API {
+	return &API{s, maxContentBytes}
+}
+
+func (a *API) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	a.forRequest().serveHTTP(writer, request)
+}
+
+// forRequest returns a copy of the API using its own store handle, as store
+// handles may not be shared between requests.
+func (a *API) forRequest() *API {
+	var result = *a
+	result.store = a.store.Handle()
+	return &result
+}
+
+func (a *API) serveHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Vary", "Accept")
+
+	switch request.Method {
+	case "GET", "HEAD":
+		if strings.HasSuffix(request.URL.Path, "/") {
+			a.serveDirectory(writer, request)
+			return
+		}
+		a.serveFile(writer, request)
+	case "PUT":
+		a.servePut(writer, request)
+	case "DELETE":
+		a.serveDelete(writer, request)
+	default:
+		log.Printf("%s %s: method not allowed", request.Method, request.URL)
+		writer.Header().Set("Allow", "GET, HEAD, PUT, DELETE")
+		serveError(writer, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (a *API) serveFile(writer http.ResponseWriter, request *http.Request) {
+	if !a.store.HasReadAccessForRequest(request) {
+		a.store.Err()
+		log.Printf("%s %s: no read permissions", request.Method, request.URL)
+		serveError(writer, http.StatusUnauthorized, "no read permissions")
+		return
+	}
+
+	var file = a.fileForRequest(request)
+	if err := a.store.Err(); err != nil {
+		a.serveStoreError(writer, request, err)
+		return
+	}
+	if file.MimeType == store.DirectoryMimeType {
+		a.serveDirectory(writer, request)
+		return
+	}
+
+	if isText(file.MimeType) {
+		var content = a.store.ReadString(request)
+		if err := a.store.Err(); err != nil {
+			a.serveStoreError(writer, request, err)
+			return
+		}
+		file.Content = &content
+		writer.Header().Set("ETag", eTagFor(content))
+	}
+
+	serveJSON(writer, http.StatusOK, file)
+}
+
+func (a *API) serveDirectory(writer http.ResponseWriter, request *http.Request) {
+	var entries = a.store.List(request)
+	if err := a.store.Err(); err != nil {
+		a.serveStoreError(writer, request, err)
+		return
+	}
+
+	var directory = Directory{Path: request.URL.Path, Entries: make([]Entry, 0, len(entries))}
+	for _, entry := range entries {
+		directory.Entries = append(directory.Entries, Entry(entry))
+	}
+	serveJSON(writer, http.StatusOK, directory)
+}
+
+func (a *API) servePut(writer http.ResponseWriter, request *http.Request) {
+	if strings.HasSuffix(request.URL.Path, "/") {
+		log.Printf("%s %s: can't write a directory", request.Method, request.URL)
+		serveError(writer, http.StatusBadRequest, "can't write a directory")
+		return
+	}
+	if !a.store.HasWriteAccessForRequest(request) {
+		a.store.Err()
+		log.Printf("%s %s: no write permissions", request.Method, request.URL)
+		serveError(writer, http.StatusUnauthorized, "no write permissions")
+		return
+	}
+
+	var exists, err = a.exists(request)
+	if err != nil {
+		a.serveStoreError(writer, request, err)
+		return
+	}
+	var expectedHash, anyVersion, ok = parseIfMatch(request.Header.Get("If-Match"))
+	if !ok {
+		log.Printf("%s %s: unsupported If-Match header", request.Method, request.URL)
+		serveError(writer, http.StatusBadRequest, "If-Match must be * or a single strong entity tag")
+		return
+	}
+	if anyVersion && !exists {
+		log.Printf("%s %s: If-Match: * on a missing file", request.Method, request.URL)
+		serveError(writer, http.StatusPreconditionFailed, "file doesn't exist")
+		return
+	}
+
+	content, err := a.readContent(request)
+	if err != nil {
+		log.Printf("%s %s: %s", request.Method, request.URL, err)
+		if err == errContentTooLarge {
+			serveError(writer, http.StatusRequestEntityTooLarge,
+				fmt.Sprintf("content exceeds %d bytes", a.maxContentBytes))
+			return
+		}
+		serveError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if expectedHash != "" {
+		a.store.WriteStringIfUnchanged(request, content, expectedHash)
+	} else {
+		a.store.WriteString(request, content)
+	}
+	if err := a.store.Err(); err != nil {
+		a.serveStoreError(writer, request, err)
+		return
+	}
+	log.Printf("%s %s: wrote %d bytes", request.Method, request.URL, len(content))
+	writer.Header().Set("ETag", eTagFor(content))
+
+	var file = a.fileForRequest(request)
+	if err := a.store.Err(); err != nil {
+		a.serveStoreError(writer, request, err)
+		return
+	}
+	if exists {
+		serveJSON(writer, http.StatusOK, file)
+		return
+	}
+	writer.Header().Set("Location", request.URL.Path)
+	serveJSON(writer, http.StatusCreated, file)
+}
+
+func (a *API) serveDelete(writer http.ResponseWriter, request *http.Request) {
+	if !a.store.HasDeleteAccessForRequest(request) {
+		a.store.Err()
+		log.Printf("%s %s: no delete permissions", request.Method, request.URL)
+		serveError(writer, http.StatusUnauthorized, "no delete permissions")
+		return
+	}
+
+	var mimeType = a.store.MimeTypeForRequest(request)
+	if err := a.store.Err(); err != nil {
+		a.serveStoreError(writer, request, err)
+		return
+	}
+	if mimeType == store.DirectoryMimeType {
+		log.Printf("%s %s: can't delete a directory", request.Method, request.URL)
+		serveError(writer, http.StatusConflict, "can't delete a directory")
+		return
+	}
+
+	a.store.Delete(request)
+	if err := a.store.Err(); err != nil {
+		a.serveStoreError(writer, request, err)
+		return
+	}
+	log.Printf("%s %s: deleted", request.Method, request.URL)
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// fileForRequest collects the metadata of the file the request points to.
+// A caller must always check the store's Err() method.
+func (a *API) fileForRequest(request *http.Request) File {
+	return File{
+		Path:     request.URL.Path,
+		MimeType: a.store.MimeTypeForRequest(request),
+		Size:     a.store.FileSizeForRequest(request),
+		ModTime:  a.store.ModTimeForRequest(request),
+	}
+}
+
+func (a *API) exists(request *http.Request) (bool, error) {
+	a.store.ModTimeForRequest(request)
+	var err = a.store.Err()
+	if store.IsPathNotFoundError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// errContentTooLarge is returned when the content to be written exceeds
+// maxContentBytes.
+var errContentTooLarge = errors.New("content too large")
+
+// readContent reads the content to be written from the request body.
+func (a *API) readContent(request *http.Request) (string, error) {
+	// HINT: reading one more byte tells whether the limit is exceeded
+	var body, err = ioutil.ReadAll(io.LimitReader(request.Body, a.maxContentBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(body)) > a.maxContentBytes {
+		return "", errContentTooLarge
+	}
+
+	var contentType, _, _ = mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if contentType != JSONMimeType {
+		return string(body), nil
+	}
+	var parsed contentRequest
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return "", fmt.Errorf("invalid JSON: %s", err)
+	}
+	if parsed.Content == nil {
+		return "", fmt.Errorf("no content given")
+	}
+	return *parsed.Content, nil
+}
+
+func (a *API) serveStoreError(writer http.ResponseWriter, request *http.Request, err error) {
+	log.Printf("%s %s: %s", request.Method, request.URL, err)
+	switch {
+	case store.IsPathNotFoundError(err):
+		serveError(writer, http.StatusNotFound, "not found")
+	case store.IsAccessDeniedError(err):
+		serveError(writer, http.StatusUnauthorized, "access denied")
+	case store.IsConflictError(err):
+		serveError(writer, http.StatusPreconditionFailed, "file was changed by someone else")
+	case store.IsLockTimeoutError(err):
+		serveError(writer, http.StatusServiceUnavailable, "file is locked by another writer")
+	case request.Context().Err() != nil:
+		serveError(writer, http.StatusServiceUnavailable, "request took too long")
+	default:
+		serveError(writer, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+// eTagFor returns the entity tag of the given content.
+func eTagFor(content string) string {
+	return `"` + store.ContentHash(content) + `"`
+}
+
+// parseIfMatch returns the content hash given by an If-Match header value,
+// or whether it matches any version.
+// Both are zero values without header; ok is false when the header can't be
+// handled.
+func parseIfMatch(header string) (expectedHash string, anyVersion bool, ok bool) {
+	header = strings.TrimSpace(header)
+	switch {
+	case header == "":
+		return "", false, true
+	case header == "*":
+		return "", true, true
+	case len(header) > 2 && strings.HasPrefix(header, `"`) && strings.HasSuffix(header, `"`) &&
+		!strings.ContainsAny(header[1:len(header)-1], `",`):
+		return header[1 : len(header)-1], false, true
+	}
+	return "", false, false
+}
+
+func serveError(writer http.ResponseWriter, code int, message string) {
+	serveJSON(writer, code, errorResponse{message})
+}
+
+func serveJSON(writer http.ResponseWriter, code int, value interface{}) {
+	var content, err = json.Marshal(value)
+	if err != nil {
+		log.Printf("couldn't encode JSON response: %s", err)
+		writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	writer.Header().Set("Content-Type", JSONMimeType+"; charset=utf-8")
+	writer.WriteHeader(code)
+	writer.Write(append(content, '\n'))
+}
+
+func isText(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "text/")
+}
@@ -0,0 +1,265 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fxnn/gone/store"
+	"github.com/fxnn/gone/store/mockstore"
+)
+
+func TestGetFileWithContent(t *testing.T) {
+	var response = httptest.NewRecorder()
+	var store = mockstore.New()
+	store.GivenReadAccess()
+	store.GivenMimeType("text/markdown")
+	store.GivenContent("# Hello")
+
+	New(store, 1024).ServeHTTP(response, apiRequest(t, "GET", "/page.md", ""))
+
+	assertResponseCode(t, response, http.StatusOK)
+	var file File
+	decodeResponse(t, response, &file)
+	if file.Path != "/page.md" || file.MimeType != "text/markdown" || file.Content == nil ||
+		*file.Content != "# Hello" {
+		t.Fatalf("unexpected file: %+v", file)
+	}
+}
+
+func TestGetFileWithETag(t *testing.T) {
+	var response = httptest.NewRecorder()
+	var s = mockstore.New()
+	s.GivenReadAccess()
+	s.GivenMimeType("text/markdown")
+	s.GivenContent("# Hello")
+
+	New(s, 1024).ServeHTTP(response, apiRequest(t, "GET", "/page.md", ""))
+
+	if eTag := response.Header().Get("ETag"); eTag != `"`+store.ContentHash("# Hello")+`"` {
+		t.Fatalf("expected ETag of content, got %s", eTag)
+	}
+}
+
+func TestGetBinaryFileWithoutContent(t *testing.T) {
+	var response = httptest.NewRecorder()
+	var store = mockstore.New()
+	store.GivenReadAccess()
+	store.GivenMimeType("image/png")
+
+	New(store, 1024).ServeHTTP(response, apiRequest(t, "GET", "/image.png", ""))
+
+	assertResponseCode(t, response, http.StatusOK)
+	var file File
+	decodeResponse(t, response, &file)
+	if file.Content != nil {
+		t.Fatalf("expected no content for binary file, got %q", *file.Content)
+	}
+}
+
+func TestGetWithoutReadAccess(t *testing.T) {
+	var response = httptest.NewRecorder()
+
+	New(mockstore.New(), 1024).ServeHTTP(response, apiRequest(t, "GET", "/page.md", ""))
+
+	assertResponseCode(t, response, http.StatusUnauthorized)
+	var errResponse errorResponse
+	decodeResponse(t, response, &errResponse)
+	if errResponse.Error == "" {
+		t.Fatalf("expected error message")
+	}
+}
+
+func TestGetNotExisting(t *testing.T) {
+	var response = httptest.NewRecorder()
+	var store = mockstore.New()
+	store.GivenReadAccess()
+	store.GivenNotExists()
+
+	New(store, 1024).ServeHTTP(response, apiRequest(t, "GET", "/page.md", ""))
+
+	assertResponseCode(t, response, http.StatusNotFound)
+}
+
+func TestListDirectory(t *testing.T) {
+	var response = httptest.NewRecorder()
+	var s = mockstore.New()
+	s.GivenEntries(
+		store.Entry{Name: "a.md", Path: "/dir/a.md", MimeType: "text/markdown"},
+		store.Entry{Name: "b", Path: "/dir/b", IsDirectory: true},
+		store.Entry{Name: "c.md", Path: "/other/c.md"})
+
+	New(s, 1024).ServeHTTP(response, apiRequest(t, "GET", "/dir/", ""))
+
+	assertResponseCode(t, response, http.StatusOK)
+	var directory Directory
+	decodeResponse(t, response, &directory)
+	if len(directory.Entries) != 2 || directory.Entries[0].Name != "a.md" || !directory.Entries[1].IsDirectory {
+		t.Fatalf("unexpected entries: %+v", directory.Entries)
+	}
+}
+
+func TestPutCreatesFile(t *testing.T) {
+	var response = httptest.NewRecorder()
+	var store = mockstore.New()
+	store.GivenWriteAccess()
+	store.GivenNotExists()
+
+	New(store, 1024).ServeHTTP(response, apiRequest(t, "PUT", "/page.md", "# Hello"))
+
+	assertResponseCode(t, response, http.StatusCreated)
+	if content := store.ReadString(nil); content != "# Hello" {
+		t.Fatalf("expected content to be written, got %q", content)
+	}
+}
+
+func TestPutReplacesFileFromJSON(t *testing.T) {
+	var response = httptest.NewRecorder()
+	var store = mockstore.New()
+	store.GivenWriteAccess()
+	store.GivenContent("old")
+	var request = apiRequest(t, "PUT", "/page.md", `{"content": "new"}`)
+	request.Header.Set("Content-Type", "application/json")
+
+	New(store, 1024).ServeHTTP(response, request)
+
+	assertResponseCode(t, response, http.StatusOK)
+	if content := store.ReadString(nil); content != "new" {
+		t.Fatalf("expected content to be written, got %q", content)
+	}
+}
+
+func TestPutIfMatchReplacesUnchangedFile(t *testing.T) {
+	var response = httptest.NewRecorder()
+	var s = mockstore.New()
+	s.GivenWriteAccess()
+	s.GivenContent("old")
+	var request = apiRequest(t, "PUT", "/page.md", "new")
+	request.Header.Set("If-Match", `"`+store.ContentHash("old")+`"`)
+
+	New(s, 1024).ServeHTTP(response, request)
+
+	assertResponseCode(t, response, http.StatusOK)
+	if eTag := response.Header().Get("ETag"); eTag != `"`+store.ContentHash("new")+`"` {
+		t.Fatalf("expected ETag of new content, got %s", eTag)
+	}
+}
+
+func TestPutIfMatchFailsWhenFileWasChanged(t *testing.T) {
+	var response = httptest.NewRecorder()
+	var s = mockstore.New()
+	s.GivenWriteAccess()
+	s.GivenContent("theirs")
+	var request = apiRequest(t, "PUT", "/page.md", "mine")
+	request.Header.Set("If-Match", `"`+store.ContentHash("old")+`"`)
+
+	New(s, 1024).ServeHTTP(response, request)
+
+	assertResponseCode(t, response, http.StatusPreconditionFailed)
+	if content := s.ReadString(nil); content != "theirs" {
+		t.Fatalf("expected content to be unchanged, got %q", content)
+	}
+}
+
+func TestPutIfMatchAnyFailsWhenFileIsMissing(t *testing.T) {
+	var response = httptest.NewRecorder()
+	var s = mockstore.New()
+	s.GivenWriteAccess()
+	s.GivenNotExists()
+	var request = apiRequest(t, "PUT", "/page.md", "new")
+	request.Header.Set("If-Match", "*")
+
+	New(s, 1024).ServeHTTP(response, request)
+
+	assertResponseCode(t, response, http.StatusPreconditionFailed)
+}
+
+func TestPutWithoutWriteAccess(t *testing.T) {
+	var response = httptest.NewRecorder()
+	var store = mockstore.New()
+	store.GivenContent("old")
+
+	New(store, 1024).ServeHTTP(response, apiRequest(t, "PUT", "/page.md", "new"))
+
+	assertResponseCode(t, response, http.StatusUnauthorized)
+	if content := store.ReadString(nil); content != "old" {
+		t.Fatalf("expected content to be unchanged, got %q", content)
+	}
+}
+
+func TestPutTooLarge(t *testing.T) {
+	var response = httptest.NewRecorder()
+	var store = mockstore.New()
+	store.GivenWriteAccess()
+	store.GivenContent("old")
+
+	New(store, 4).ServeHTTP(response, apiRequest(t, "PUT", "/page.md", "too large"))
+
+	assertResponseCode(t, response, http.StatusRequestEntityTooLarge)
+	if content := store.ReadString(nil); content != "old" {
+		t.Fatalf("expected content to be unchanged, got %q", content)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	var response = httptest.NewRecorder()
+	var store = mockstore.New()
+	store.GivenDeleteAccess()
+
+	New(store, 1024).ServeHTTP(response, apiRequest(t, "DELETE", "/page.md", ""))
+
+	assertResponseCode(t, response, http.StatusNoContent)
+}
+
+func TestDeleteWhileLocked(t *testing.T) {
+	var response = httptest.NewRecorder()
+	var s = mockstore.New()
+	s.GivenDeleteAccess()
+	s.GivenErr(store.NewLockTimeoutError("mocked LockTimeoutError"))
+
+	New(s, 1024).ServeHTTP(response, apiRequest(t, "DELETE", "/page.md", ""))
+
+	assertResponseCode(t, response, http.StatusServiceUnavailable)
+}
+
+func TestDeleteWithoutDeleteAccess(t *testing.T) {
+	var response = httptest.NewRecorder()
+
+	New(mockstore.New(), 1024).ServeHTTP(response, apiRequest(t, "DELETE", "/page.md", ""))
+
+	assertResponseCode(t, response, http.StatusUnauthorized)
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	var response = httptest.NewRecorder()
+
+	New(mockstore.New(), 1024).ServeHTTP(response, apiRequest(t, "PATCH", "/page.md", ""))
+
+	assertResponseCode(t, response, http.StatusMethodNotAllowed)
+}
+
+func apiRequest(t *testing.T, method string, requestURL string, body string) *http.Request {
+	var request, err = http.NewRequest(method, requestURL, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("couldn't create http.Request: %v", err)
+	}
+	request.Header.Set("Accept", JSONMimeType)
+	return request
+}
+
+func decodeResponse(t *testing.T, response *httptest.ResponseRecorder, value interface{}) {
+	if contentType := response.Header().Get("Content-Type"); !strings.HasPrefix(contentType, JSONMimeType) {
+		t.Fatalf("expected JSON response, got %s", contentType)
+	}
+	if err := json.Unmarshal(response.Body.Bytes(), value); err != nil {
+		t.Fatalf("couldn't decode response %q: %s", response.Body.String(), err)
+	}
+}
+
+func assertResponseCode(t *testing.T, response *httptest.ResponseRecorder, expected int) {
+	if response.Code != expected {
+		t.Fatalf("code expected to be %d, but is %d: %s", expected, response.Code, response.Body.String())
+	}
+}
@@ -0,0 +1,8 @@
+// Package api serves the wiki's contents as JSON, so that tools can
+// integrate with the wiki without scraping HTML.
+//
+// Requests accepting application/json, as well as all PUT and DELETE
+// requests, are routed to the API; see router.IsAPIRequest.
+// It uses the same store and therefore the same access control as the HTML
+// user interface.
+package api
@@ -0,0 +1,24 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// RequestDeadline lets the context of each request expire after the given
+// timeout, so that all work on a request stops by then.
+// A timeout of zero or less sets no deadline.
+//
+// As other handlers keep data per request instance, this must wrap them.
+func RequestDeadline(timeout time.Duration, next http.Handler) http.Handler {
+	if timeout <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ctx, cancel = context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -1,10 +1,13 @@
 package editor
 
 import (
+	"bytes"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 
+	"github.com/fxnn/gone/diff"
 	"github.com/fxnn/gone/http/failer"
 	"github.com/fxnn/gone/http/router"
 	"github.com/fxnn/gone/http/templates"
@@ -19,20 +22,49 @@ const (
 // The Editor is a HTTP Handler that serves the editor UI.
 // While the UI itself is implemented in a HTML template, this type
 // implements the logic behind the UI.
+//
+// Saving is subject to optimistic concurrency control: the edit UI carries
+// a hash of the content being edited, and a save is rejected when the file
+// was changed in the meantime.
 type Editor struct {
-	store    store.Store
-	renderer *templates.EditorRenderer
+	store            store.Store
+	renderer         *templates.EditorRenderer
+	conflictRenderer *templates.ConflictRenderer
+	moveRenderer     *templates.MoveRenderer
+	maxUploadBytes   int64
 }
 
 // New initializes a new instance ready to use.
 // The instance includes a loaded and parsed template.
-func New(l templates.Loader, s store.Store) *Editor {
+// Uploads are limited to maxUploadBytes per request.
+func New(l templates.Loader, s store.Store, maxUploadBytes int64) *Editor {
 	var renderer = templates.NewEditorRenderer()
 	if err := renderer.LoadAndWatch(l); err != nil {
 		panic(fmt.Errorf("couldn't load editor template: %s", err))
 	}
+	var conflictRenderer = templates.NewConflictRenderer()
+	if err := conflictRenderer.LoadAndWatch(l); err != nil {
+		panic(fmt.Errorf("couldn't load conflict template: %s", err))
+	}
+	var moveRenderer = templates.NewMoveRenderer()
+	if err := moveRenderer.LoadAndWatch(l); err != nil {
+		panic(fmt.Errorf("couldn't load move template: %s", err))
+	}
 
-	return &Editor{s, renderer}
+	return &Editor{store: s, renderer: renderer, conflictRenderer: conflictRenderer,
+		moveRenderer: moveRenderer, maxUploadBytes: maxUploadBytes}
+}
+
+func (e *Editor) isServeMover(request *http.Request) bool {
+	return request.Method == "POST" && router.Is(router.ModeMove, request)
+}
+
+func (e *Editor) isServeMoveUI(request *http.Request) bool {
+	return request.Method == "GET" && router.Is(router.ModeMove, request)
+}
+
+func (e *Editor) isServeUploader(request *http.Request) bool {
+	return request.Method == "POST" && router.Is(router.ModeUpload, request)
 }
 
 func (e *Editor) isServeWriter(request *http.Request) bool {
@@ -43,6 +75,18 @@ func (e *Editor) isServeDeleter(request *http.Request) bool {
 	return request.Method == "GET" && router.Is(router.ModeDelete, request)
 }
 
+func (e *Editor) isServeRestorer(request *http.Request) bool {
+	return request.Method == "GET" && router.Is(router.ModeRestore, request)
+}
+
+func (e *Editor) isServeUndeleter(request *http.Request) bool {
+	return request.Method == "GET" && router.Is(router.ModeUndelete, request)
+}
+
+func (e *Editor) isServePurger(request *http.Request) bool {
+	return request.Method == "GET" && router.Is(router.ModePurge, request)
+}
+
 func (e *Editor) isServeEditUI(request *http.Request) bool {
 	return request.Method == "GET" && router.Is(router.ModeEdit, request)
 }
@@ -52,6 +96,33 @@ func (e *Editor) isServeCreateUI(request *http.Request) bool {
 }
 
 func (e *Editor) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	e.forRequest().serveHTTP(writer, request)
+}
+
+// forRequest returns a copy of the Editor using its own store handle, as
+// store handles may not be shared between requests.
+func (e *Editor) forRequest() *Editor {
+	var result = *e
+	result.store = e.store.Handle()
+	return &result
+}
+
+func (e *Editor) serveHTTP(writer http.ResponseWriter, request *http.Request) {
+	if e.isServeMover(request) {
+		e.serveMover(writer, request)
+		return
+	}
+
+	if e.isServeMoveUI(request) {
+		e.serveMoveUI(writer, request)
+		return
+	}
+
+	if e.isServeUploader(request) {
+		e.serveUploader(writer, request)
+		return
+	}
+
 	if e.isServeWriter(request) {
 		e.serveWriter(writer, request)
 		return
@@ -62,6 +133,21 @@ func (e *Editor) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	if e.isServeRestorer(request) {
+		e.serveRestorer(writer, request)
+		return
+	}
+
+	if e.isServeUndeleter(request) {
+		e.serveUndeleter(writer, request)
+		return
+	}
+
+	if e.isServePurger(request) {
+		e.servePurger(writer, request)
+		return
+	}
+
 	if e.isServeCreateUI(request) || e.isServeEditUI(request) {
 		e.serveEditUI(writer, request)
 		return
@@ -85,10 +171,19 @@ func (e *Editor) serveWriter(writer http.ResponseWriter, request *http.Request)
 		return
 	}
 
-	e.store.WriteString(request, content)
-	if err := e.store.Err(); err != nil {
+	// HINT: requests without base hash don't take part in conflict detection
+	if baseHash := request.FormValue("basehash"); baseHash != "" {
+		e.store.WriteStringIfUnchanged(request, content, baseHash)
+	} else {
+		e.store.WriteString(request, content)
+	}
+	if err := e.store.Err(); store.IsConflictError(err) {
+		log.Printf("%s %s: conflict, file was changed concurrently", request.Method, request.URL)
+		e.serveConflict(writer, request, content)
+		return
+	} else if err != nil {
 		log.Printf("%s %s: %s", request.Method, request.URL, err)
-		failer.ServeInternalServerError(writer, request)
+		serveStoreError(writer, request, err)
 		return
 	}
 	log.Printf("%s %s: wrote %d bytes", request.Method, request.URL, len(content))
@@ -101,6 +196,32 @@ func (e *Editor) serveWriter(writer http.ResponseWriter, request *http.Request)
 	router.RedirectToEditMode(writer, request)
 }
 
+// serveConflict shows the differences between the current file content and
+// the content the user wanted to save, allowing to merge both.
+func (e *Editor) serveConflict(writer http.ResponseWriter, request *http.Request, content string) {
+	var current = e.store.ReadString(request)
+	if err := e.store.Err(); err != nil && !store.IsPathNotFoundError(err) {
+		log.Printf("%s %s: %s", request.Method, request.URL, err)
+		failer.ServeInternalServerError(writer, request)
+		return
+	}
+	var mimeType = e.store.MimeTypeForRequest(request)
+	e.store.Err() // don't care for errors
+	var hunks = diff.Hunks(diff.Strings(current, content), diff.DefaultContext)
+
+	var buf bytes.Buffer
+	err := e.conflictRenderer.Render(&buf, request.URL, content, store.ContentHash(current),
+		mimeType, hunks)
+	if err != nil {
+		log.Printf("%s %s: %s", request.Method, request.URL, err)
+		failer.ServeConflict(writer, request)
+		return
+	}
+
+	writer.WriteHeader(http.StatusConflict)
+	buf.WriteTo(writer)
+}
+
 func (e *Editor) serveDeleter(writer http.ResponseWriter, request *http.Request) {
 	if !e.store.HasDeleteAccessForRequest(request) {
 		log.Printf("%s %s: no delete permissions", request.Method, request.URL)
@@ -111,7 +232,7 @@ func (e *Editor) serveDeleter(writer http.ResponseWriter, request *http.Request)
 	e.store.Delete(request)
 	if err := e.store.Err(); err != nil {
 		log.Printf("%s %s: %s", request.Method, request.URL, err)
-		failer.ServeInternalServerError(writer, request)
+		serveStoreError(writer, request, err)
 		return
 	}
 	log.Printf("%s %s: deleted", request.Method, request.URL)
@@ -119,6 +240,142 @@ func (e *Editor) serveDeleter(writer http.ResponseWriter, request *http.Request)
 	fmt.Fprintf(writer, "Successfully deleted")
 }
 
+func (e *Editor) serveMover(writer http.ResponseWriter, request *http.Request) {
+	if !e.store.HasDeleteAccessForRequest(request) {
+		log.Printf("%s %s: no delete permissions", request.Method, request.URL)
+		failer.ServeUnauthorized(writer, request)
+		return
+	}
+
+	var targetPath = strings.TrimSpace(request.FormValue("to"))
+	if targetPath == "" {
+		log.Printf("%s %s: no target path in request", request.Method, request.URL)
+		failer.ServeBadRequest(writer, request)
+		return
+	}
+
+	var rewriteLinks = request.FormValue("rewritelinks") != ""
+	var targetURLPath = e.store.Move(request, targetPath, rewriteLinks)
+	if err := e.store.Err(); err != nil {
+		log.Printf("%s %s: %s", request.Method, request.URL, err)
+		if store.IsPathNotFoundError(err) {
+			failer.ServeNotFound(writer, request)
+			return
+		}
+		if store.IsAccessDeniedError(err) {
+			failer.ServeUnauthorized(writer, request)
+			return
+		}
+		serveStoreError(writer, request, err)
+		return
+	}
+	log.Printf("%s %s: moved to %s", request.Method, request.URL, targetURLPath)
+
+	router.Redirect(writer, request, &url.URL{Path: targetURLPath})
+}
+
+func (e *Editor) serveMoveUI(writer http.ResponseWriter, request *http.Request) {
+	if !e.store.HasDeleteAccessForRequest(request) {
+		log.Printf("%s %s: no delete permissions", request.Method, request.URL)
+		failer.ServeUnauthorized(writer, request)
+		return
+	}
+
+	var backlinks = e.store.Backlinks(request)
+	if err := e.store.Err(); err != nil {
+		// HINT: backlinks are informational only
+		log.Printf("%s %s: couldn't determine backlinks: %s", request.Method, request.URL, err)
+	}
+
+	if err := e.moveRenderer.Render(writer, request.URL, backlinks); err != nil {
+		log.Printf("%s %s: %s", request.Method, request.URL, err)
+		failer.ServeInternalServerError(writer, request)
+		return
+	}
+
+	log.Printf("%s %s: served from template", request.Method, request.URL)
+}
+
+func (e *Editor) serveRestorer(writer http.ResponseWriter, request *http.Request) {
+	if !e.store.HasWriteAccessForRequest(request) {
+		log.Printf("%s %s: no write permissions", request.Method, request.URL)
+		failer.ServeUnauthorized(writer, request)
+		return
+	}
+
+	var revisionID = request.FormValue("revision")
+	e.store.RestoreRevision(request, revisionID)
+	if err := e.store.Err(); err != nil {
+		log.Printf("%s %s: %s", request.Method, request.URL, err)
+		if store.IsPathNotFoundError(err) {
+			failer.ServeNotFound(writer, request)
+			return
+		}
+		serveStoreError(writer, request, err)
+		return
+	}
+	log.Printf("%s %s: restored revision %s", request.Method, request.URL, revisionID)
+
+	router.RedirectToViewMode(writer, request)
+}
+
+// serveUndeleter restores a deleted file from the trash of the requested
+// directory, and redirects to it.
+func (e *Editor) serveUndeleter(writer http.ResponseWriter, request *http.Request) {
+	var itemID = request.FormValue("item")
+	var restoredPath = e.store.RestoreTrashItem(request, itemID)
+	if err := e.store.Err(); err != nil {
+		log.Printf("%s %s: %s", request.Method, request.URL, err)
+		if store.IsPathNotFoundError(err) {
+			failer.ServeNotFound(writer, request)
+			return
+		}
+		if store.IsAccessDeniedError(err) {
+			failer.ServeUnauthorized(writer, request)
+			return
+		}
+		serveStoreError(writer, request, err)
+		return
+	}
+	log.Printf("%s %s: restored %s from trash", request.Method, request.URL, restoredPath)
+
+	router.Redirect(writer, request, &url.URL{Path: restoredPath})
+}
+
+// servePurger deletes a file from the trash of the requested directory for
+// good, and redirects back to the trash.
+func (e *Editor) servePurger(writer http.ResponseWriter, request *http.Request) {
+	var itemID = request.FormValue("item")
+	e.store.PurgeTrashItem(request, itemID)
+	if err := e.store.Err(); err != nil {
+		log.Printf("%s %s: %s", request.Method, request.URL, err)
+		if store.IsPathNotFoundError(err) {
+			failer.ServeNotFound(writer, request)
+			return
+		}
+		if store.IsAccessDeniedError(err) {
+			failer.ServeUnauthorized(writer, request)
+			return
+		}
+		serveStoreError(writer, request, err)
+		return
+	}
+	log.Printf("%s %s: purged %s from trash", request.Method, request.URL, itemID)
+
+	router.Redirect(writer, request, router.To(router.ModeTrash, request.URL))
+}
+
+// serveStoreError reports a failed change to the store.
+// When another writer kept the file locked, the client may simply try again
+// later.
+func serveStoreError(writer http.ResponseWriter, request *http.Request, err error) {
+	if store.IsLockTimeoutError(err) {
+		failer.ServeServiceUnavailable(writer, request)
+		return
+	}
+	failer.ServeInternalServerError(writer, request)
+}
+
 func (e *Editor) serveEditUI(writer http.ResponseWriter, request *http.Request) {
 	if !e.store.HasWriteAccessForRequest(request) {
 		log.Printf("%s %s: no write permissions", request.Method, request.URL)
@@ -155,7 +412,7 @@ func (e *Editor) serveEditUI(writer http.ResponseWriter, request *http.Request)
 		return
 	}
 
-	err := e.renderer.Render(writer, request.URL, content, mimeType,
+	err := e.renderer.Render(writer, request.URL, content, store.ContentHash(content), mimeType,
 		router.Is(router.ModeEdit, request))
 	if err != nil {
 		log.Printf("%s %s: %s", request.Method, request.URL, err)
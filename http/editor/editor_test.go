@@ -1,6 +1,9 @@
 package editor
 
 import (
+	"bytes"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -29,6 +32,58 @@ func TestWriteSuccess(t *testing.T) {
 
 }
 
+func TestWriteWhileLocked(t *testing.T) {
+
+	var response = httptest.NewRecorder()
+	var request = postRequest(t, "/someFile", "")
+	var s = mockstore.New()
+	var sut = createSut(s)
+
+	request.PostForm.Set("content", "content")
+	s.GivenWriteAccess()
+	s.GivenErr(store.NewLockTimeoutError("mocked LockTimeoutError"))
+	sut.ServeHTTP(response, request)
+
+	assertResponseCode(t, response, http.StatusServiceUnavailable)
+
+}
+
+func TestWriteWithCurrentBaseHashSuccess(t *testing.T) {
+
+	var response = httptest.NewRecorder()
+	var request = postRequest(t, "/someFile", "")
+	var store = mockstore.New()
+	var sut = createSut(store)
+
+	request.PostForm.Set("content", "new content")
+	request.PostForm.Set("basehash", baseHash("old content"))
+	store.GivenWriteAccess()
+	store.GivenContent("old content")
+	sut.ServeHTTP(response, request)
+
+	assertResponseCode(t, response, http.StatusFound)
+	assertResponseHeader(t, response, "Location", "/someFile?edit")
+
+}
+
+func TestWriteWithStaleBaseHashConflict(t *testing.T) {
+
+	var response = httptest.NewRecorder()
+	var request = postRequest(t, "/someFile", "")
+	var store = mockstore.New()
+	var sut = createSut(store)
+
+	request.PostForm.Set("content", "my content")
+	request.PostForm.Set("basehash", baseHash("old content"))
+	store.GivenWriteAccess()
+	store.GivenContent("their content")
+	sut.ServeHTTP(response, request)
+
+	assertResponseBodyNotEmpty(t, response)
+	assertResponseCode(t, response, http.StatusConflict)
+
+}
+
 func TestWriteUnauthorized(t *testing.T) {
 
 	var response = httptest.NewRecorder()
@@ -156,6 +211,201 @@ func TestEditUINotExists(t *testing.T) {
 
 }
 
+func TestMoveSuccess(t *testing.T) {
+
+	var response = httptest.NewRecorder()
+	var request = postRequest(t, "/someFile?move", "")
+	var store = mockstore.New()
+	var sut = createSut(store)
+
+	request.Form = url.Values{"move": {""}, "to": {"/otherFile"}}
+	store.GivenDeleteAccess()
+	sut.ServeHTTP(response, request)
+
+	assertResponseCode(t, response, http.StatusFound)
+	assertResponseHeader(t, response, "Location", "/otherFile")
+
+}
+
+func TestMoveUnauthorized(t *testing.T) {
+
+	var response = httptest.NewRecorder()
+	var request = postRequest(t, "/someFile?move", "")
+	var store = mockstore.New()
+	var sut = createSut(store)
+
+	request.Form = url.Values{"move": {""}, "to": {"/otherFile"}}
+	sut.ServeHTTP(response, request)
+
+	assertResponseBodyNotEmpty(t, response)
+	assertResponseCode(t, response, http.StatusUnauthorized)
+
+}
+
+func TestUndeleteSuccess(t *testing.T) {
+
+	var response = httptest.NewRecorder()
+	var request = getRequest(t, "/dir/?undelete&item=1")
+	var s = mockstore.New()
+	var sut = createSut(s)
+
+	s.GivenTrashItems(store.TrashItem{ID: "1", Path: "/dir/someFile"})
+	sut.ServeHTTP(response, request)
+
+	assertResponseCode(t, response, http.StatusFound)
+	assertResponseHeader(t, response, "Location", "/dir/someFile")
+
+}
+
+func TestUndeleteNotExists(t *testing.T) {
+
+	var response = httptest.NewRecorder()
+	var request = getRequest(t, "/dir/?undelete&item=1")
+	var sut = createSut(mockstore.New())
+
+	sut.ServeHTTP(response, request)
+
+	assertResponseBodyNotEmpty(t, response)
+	assertResponseCode(t, response, http.StatusNotFound)
+
+}
+
+func TestPurgeSuccess(t *testing.T) {
+
+	var response = httptest.NewRecorder()
+	var request = getRequest(t, "/dir/?purge&item=1")
+	var s = mockstore.New()
+	var sut = createSut(s)
+
+	s.GivenTrashItems(store.TrashItem{ID: "1", Path: "/dir/someFile"})
+	sut.ServeHTTP(response, request)
+
+	assertResponseCode(t, response, http.StatusFound)
+	assertResponseHeader(t, response, "Location", "/dir/?trash")
+	if items := s.TrashItems(request); len(items) != 0 {
+		t.Fatalf("expected trash to be empty, got %v", items)
+	}
+
+}
+
+func TestMoveUISuccess(t *testing.T) {
+
+	var response = httptest.NewRecorder()
+	var request = getRequest(t, "/someFile?move")
+	var store = mockstore.New()
+	var sut = createSut(store)
+
+	store.GivenDeleteAccess()
+	store.GivenBacklinks("/linkingFile")
+	sut.ServeHTTP(response, request)
+
+	assertResponseBodyNotEmpty(t, response)
+	assertResponseCode(t, response, http.StatusOK)
+
+}
+
+func TestUploadSuccess(t *testing.T) {
+
+	var response = httptest.NewRecorder()
+	var request = uploadRequest(t, "/someDir/someFile?upload", "image.png", "image data")
+	var store = mockstore.New()
+	var sut = createSut(store)
+
+	store.GivenWriteAccess()
+	store.GivenNotExists()
+	sut.ServeHTTP(response, request)
+
+	assertResponseCode(t, response, http.StatusCreated)
+	if response.Body.String() != "/someDir/image.png\n" {
+		t.Fatalf("expected location of uploaded file, but got %v", response.Body.String())
+	}
+	if content := store.ReadString(request); content != "image data" {
+		t.Fatalf("expected uploaded content, but got %v", content)
+	}
+
+}
+
+func TestUploadTooLarge(t *testing.T) {
+
+	var response = httptest.NewRecorder()
+	var request = uploadRequest(t, "/someDir/?upload", "image.png", strings.Repeat("x", 2048))
+	var store = mockstore.New()
+	var sut = createSut(store)
+
+	store.GivenWriteAccess()
+	store.GivenNotExists()
+	sut.ServeHTTP(response, request)
+
+	assertResponseBodyNotEmpty(t, response)
+	assertResponseCode(t, response, http.StatusRequestEntityTooLarge)
+
+}
+
+func TestUploadTooLargeWithoutContentLength(t *testing.T) {
+
+	var response = httptest.NewRecorder()
+	var request = uploadRequest(t, "/someDir/?upload", "image.png", strings.Repeat("x", 2048))
+	var s = mockstore.New()
+	var sut = createSut(s)
+
+	request.ContentLength = -1
+	s.GivenWriteAccess()
+	s.GivenNotExists()
+	sut.ServeHTTP(response, request)
+
+	assertResponseCode(t, response, http.StatusRequestEntityTooLarge)
+
+}
+
+func TestUploadUnauthorized(t *testing.T) {
+
+	var response = httptest.NewRecorder()
+	var request = uploadRequest(t, "/someDir/?upload", "image.png", "image data")
+	var store = mockstore.New()
+	var sut = createSut(store)
+
+	store.GivenNotExists()
+	sut.ServeHTTP(response, request)
+
+	assertResponseBodyNotEmpty(t, response)
+	assertResponseCode(t, response, http.StatusUnauthorized)
+
+}
+
+func TestUploadExistingFileConflict(t *testing.T) {
+
+	var response = httptest.NewRecorder()
+	var request = uploadRequest(t, "/someDir/?upload", "image.png", "image data")
+	var store = mockstore.New()
+	var sut = createSut(store)
+
+	store.GivenWriteAccess()
+	sut.ServeHTTP(response, request)
+
+	assertResponseBodyNotEmpty(t, response)
+	assertResponseCode(t, response, http.StatusConflict)
+
+}
+
+func TestUploadDeletesStoredFilesWhenLaterFileFails(t *testing.T) {
+
+	var response = httptest.NewRecorder()
+	var request = uploadRequestWithFiles(t, "/someDir/?upload",
+		"image.png", "image data", "image.png", "other image data")
+	var s = mockstore.New()
+	var sut = createSut(s)
+
+	s.GivenWriteAccess()
+	s.GivenNotExists()
+	sut.ServeHTTP(response, request)
+
+	assertResponseCode(t, response, http.StatusConflict)
+	if s.ModTimeForRequest(getRequest(t, "/someDir/image.png")); s.Err() == nil {
+		t.Fatalf("expected first uploaded file to be deleted again")
+	}
+
+}
+
 func assertResponseBodyNotEmpty(t *testing.T, response *httptest.ResponseRecorder) {
 	if response.Body.String() == "" {
 		t.Fatalf("body expected to be non-empty, but is empty")
@@ -191,6 +441,36 @@ func postRequest(t *testing.T, requestUrl string, content string) *http.Request
 	return request
 }
 
+func uploadRequest(t *testing.T, requestUrl string, fileName string, content string) *http.Request {
+	return uploadRequestWithFiles(t, requestUrl, fileName, content)
+}
+
+// uploadRequestWithFiles creates an upload request with one file per pair of
+// file name and content.
+func uploadRequestWithFiles(t *testing.T, requestUrl string, namesAndContents ...string) *http.Request {
+	var body bytes.Buffer
+	var multipartWriter = multipart.NewWriter(&body)
+	for i := 0; i+1 < len(namesAndContents); i += 2 {
+		var part, err = multipartWriter.CreateFormFile("file", namesAndContents[i])
+		if err != nil {
+			t.Fatalf("couldn't create multipart body: %v", err)
+		}
+		io.WriteString(part, namesAndContents[i+1])
+	}
+	multipartWriter.Close()
+
+	request, err := http.NewRequest("POST", requestUrl, &body)
+	if err != nil {
+		t.Fatalf("couldn't create http.Request: %v", err)
+	}
+	request.Header.Set("Content-Type", multipartWriter.FormDataContentType())
+
+	// HINT: GET-Parameter auswerten
+	request.ParseForm()
+
+	return request
+}
+
 func getRequest(t *testing.T, requestUrl string) *http.Request {
 	var request, err = http.NewRequest("GET", requestUrl, strings.NewReader(""))
 	if err != nil {
@@ -205,5 +485,10 @@ func getRequest(t *testing.T, requestUrl string) *http.Request {
 
 func createSut(s store.Store) *Editor {
 	var l = templates.NewStaticLoader()
-	return New(l, s)
+	return New(l, s, 1024)
+}
+
+// baseHash returns the hash the edit UI sends for the given content.
+func baseHash(content string) string {
+	return store.ContentHash(content)
 }
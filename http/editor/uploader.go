@@ -0,0 +1,216 @@
+package editor
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/fxnn/gone/http/failer"
+	"github.com/fxnn/gone/log"
+	"github.com/fxnn/gone/store"
+)
+
+// serveUploader stores the files contained in a multipart request.
+// They're stored in the directory the request points to or, when it points
+// to a file, next to that file.
+// Existing files are never overwritten.
+// When one of the files can't be stored, those already stored are deleted
+// again, so that the client isn't left with files it doesn't know of.
+//
+// The response lists the URL paths of the stored files, one per line.
+func (e *Editor) serveUploader(writer http.ResponseWriter, request *http.Request) {
+	if request.ContentLength > e.maxUploadBytes {
+		log.Printf("%s %s: upload of %d bytes exceeds limit of %d bytes",
+			request.Method, request.URL, request.ContentLength, e.maxUploadBytes)
+		failer.ServeRequestEntityTooLarge(writer, request)
+		return
+	}
+	var body = newLimitedBody(writer, request.Body, e.maxUploadBytes)
+	request.Body = body
+
+	reader, err := request.MultipartReader()
+	if err != nil {
+		log.Printf("%s %s: no multipart request: %s", request.Method, request.URL, err)
+		failer.ServeBadRequest(writer, request)
+		return
+	}
+
+	var directory = e.uploadDirectory(request)
+	var locations = make([]string, 0)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Printf("%s %s: %s", request.Method, request.URL, err)
+			e.deleteUploadedFiles(request, locations)
+			e.serveUploadStatus(writer, request, body.errorStatus(http.StatusBadRequest))
+			return
+		}
+		if part.FileName() == "" {
+			// HINT: no file, but an ordinary form field
+			continue
+		}
+
+		var location, code = e.storeUploadedFile(request, directory, part, body)
+		if code != http.StatusOK {
+			e.deleteUploadedFiles(request, locations)
+			e.serveUploadStatus(writer, request, code)
+			return
+		}
+		locations = append(locations, location)
+	}
+
+	if len(locations) == 0 {
+		log.Printf("%s %s: no files in request", request.Method, request.URL)
+		failer.ServeBadRequest(writer, request)
+		return
+	}
+	log.Printf("%s %s: uploaded %s", request.Method, request.URL, strings.Join(locations, ", "))
+
+	writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	writer.WriteHeader(http.StatusCreated)
+	for _, location := range locations {
+		fmt.Fprintln(writer, location)
+	}
+}
+
+// storeUploadedFile streams the given part into a new file inside the given
+// directory, and returns its URL path.
+// The returned status code is http.StatusOK on success.
+func (e *Editor) storeUploadedFile(request *http.Request, directory string,
+	part *multipart.Part, body *limitedBody) (string, int) {
+	var name = uploadedFileName(part.FileName())
+	if name == "" {
+		log.Printf("%s %s: invalid file name", request.Method, request.URL)
+		return "", http.StatusBadRequest
+	}
+	var location = path.Join(directory, name)
+	var fileRequest = requestForURLPath(request, location)
+
+	if !e.store.HasWriteAccessForRequest(fileRequest) {
+		log.Printf("%s %s: no write permissions for %s", request.Method, request.URL, location)
+		return "", http.StatusUnauthorized
+	}
+	var fileWriter = e.store.OpenWriterIfMissing(fileRequest)
+	if err := e.store.Err(); store.IsConflictError(err) {
+		log.Printf("%s %s: file to be uploaded already exists: %s", request.Method, request.URL, location)
+		return "", http.StatusConflict
+	} else if err != nil {
+		log.Printf("%s %s: %s", request.Method, request.URL, err)
+		if store.IsLockTimeoutError(err) {
+			return "", http.StatusServiceUnavailable
+		}
+		return "", http.StatusInternalServerError
+	}
+	_, err := io.Copy(fileWriter, part)
+	if closeErr := fileWriter.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// HINT: the store discards the content when writing fails, so nothing
+		// incomplete is left behind
+		log.Printf("%s %s: couldn't store %s: %s", request.Method, request.URL, location, err)
+		return "", body.errorStatus(http.StatusInternalServerError)
+	}
+
+	return location, http.StatusOK
+}
+
+// deleteUploadedFiles deletes the files stored by the request so far.
+// Failures are only logged, as the upload failed anyway.
+func (e *Editor) deleteUploadedFiles(request *http.Request, locations []string) {
+	for _, location := range locations {
+		e.store.Delete(requestForURLPath(request, location))
+		if err := e.store.Err(); err != nil {
+			log.Printf("%s %s: couldn't delete uploaded file %s: %s", request.Method, request.URL, location, err)
+		} else {
+			log.Printf("%s %s: deleted uploaded file %s again", request.Method, request.URL, location)
+		}
+	}
+}
+
+// uploadDirectory returns the URL path of the directory files are uploaded
+// to.
+func (e *Editor) uploadDirectory(request *http.Request) string {
+	var mimeType = e.store.MimeTypeForRequest(request)
+	e.store.Err() // don't care for errors
+	if mimeType == store.DirectoryMimeType {
+		return path.Clean(request.URL.Path)
+	}
+	return path.Dir(request.URL.Path)
+}
+
+func (e *Editor) serveUploadStatus(writer http.ResponseWriter, request *http.Request, code int) {
+	switch code {
+	case http.StatusBadRequest:
+		failer.ServeBadRequest(writer, request)
+	case http.StatusUnauthorized:
+		failer.ServeUnauthorized(writer, request)
+	case http.StatusConflict:
+		failer.ServeConflict(writer, request)
+	case http.StatusRequestEntityTooLarge:
+		failer.ServeRequestEntityTooLarge(writer, request)
+	case http.StatusServiceUnavailable:
+		failer.ServeServiceUnavailable(writer, request)
+	default:
+		failer.ServeInternalServerError(writer, request)
+	}
+}
+
+// limitedBody limits the request body like http.MaxBytesReader does, and
+// remembers when reading failed at the limit.
+// HINT: http.MaxBytesError tells the same, but needs Go 1.19
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	exceeded  bool
+}
+
+func newLimitedBody(writer http.ResponseWriter, body io.ReadCloser, limit int64) *limitedBody {
+	return &limitedBody{http.MaxBytesReader(writer, body, limit), limit, false}
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	var n, err = b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if err != nil && err != io.EOF && b.remaining <= 0 {
+		b.exceeded = true
+	}
+	return n, err
+}
+
+// errorStatus returns the status code for an error occuring while
+// processing the upload, which is the given fallback unless the upload was
+// too large.
+func (b *limitedBody) errorStatus(fallback int) int {
+	if b.exceeded {
+		return http.StatusRequestEntityTooLarge
+	}
+	return fallback
+}
+
+// uploadedFileName returns the name the client gave to the uploaded file,
+// without any directories, or the empty string if it's no valid name.
+func uploadedFileName(name string) string {
+	// HINT: some browsers send full paths, possibly with backslashes
+	name = name[strings.LastIndexAny(name, `/\`)+1:]
+	if name == "" || strings.HasPrefix(name, ".") {
+		return ""
+	}
+	return name
+}
+
+// requestForURLPath copies the request, letting it point to the given URL
+// path.
+func requestForURLPath(request *http.Request, urlPath string) *http.Request {
+	var u = *request.URL
+	u.Path, u.RawPath, u.RawQuery = urlPath, "", ""
+	var result = *request
+	result.URL = &u
+	return &result
+}
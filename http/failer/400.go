@@ -7,11 +7,14 @@ import (
 var (
 	BadRequestHandler           = newFailer("Oops, bad request", http.StatusBadRequest)
 	UnauthorizedHandler         = newFailer("Oops, unauthorized", http.StatusUnauthorized)
+	ForbiddenHandler            = newFailer("Sorry, forbidden", http.StatusForbidden)
 	NotFoundHandler             = newFailer("Sorry, not found", http.StatusNotFound)
 	MethodNotAllowedHandler     = newFailer("Oops, method not allowed", http.StatusMethodNotAllowed)
 	ConflictHandler             = newFailer("Sorry, there's a conflict", http.StatusConflict)
 	UnsupportedMediaTypeHandler = newFailer("Sorry, unsupported media type",
 		http.StatusUnsupportedMediaType)
+	RequestEntityTooLargeHandler = newFailer("Sorry, that's too large",
+		http.StatusRequestEntityTooLarge)
 )
 
 func ServeBadRequest(writer http.ResponseWriter, request *http.Request) {
@@ -22,6 +25,10 @@ func ServeUnauthorized(writer http.ResponseWriter, request *http.Request) {
 	UnauthorizedHandler.ServeHTTP(writer, request)
 }
 
+func ServeForbidden(writer http.ResponseWriter, request *http.Request) {
+	ForbiddenHandler.ServeHTTP(writer, request)
+}
+
 func ServeNotFound(writer http.ResponseWriter, request *http.Request) {
 	NotFoundHandler.ServeHTTP(writer, request)
 }
@@ -37,3 +44,7 @@ func ServeConflict(writer http.ResponseWriter, request *http.Request) {
 func ServeUnsupportedMediaType(writer http.ResponseWriter, request *http.Request) {
 	UnsupportedMediaTypeHandler.ServeHTTP(writer, request)
 }
+
+func ServeRequestEntityTooLarge(writer http.ResponseWriter, request *http.Request) {
+	RequestEntityTooLargeHandler.ServeHTTP(writer, request)
+}
@@ -9,8 +9,23 @@ var (
 		"Oops, internal server error",
 		http.StatusInternalServerError,
 	)
+	ServiceUnavailableHandler = newFailer(
+		"Sorry, that took too long",
+		http.StatusServiceUnavailable,
+	)
 )
 
+// ServeInternalServerError reports an error of the server.
+// When the request was canceled or exceeded its deadline, the error most
+// likely stems from that, so that ServeServiceUnavailable is used instead.
 func ServeInternalServerError(writer http.ResponseWriter, request *http.Request) {
+	if request.Context().Err() != nil {
+		ServeServiceUnavailable(writer, request)
+		return
+	}
 	InternalServerErrorHandler.ServeHTTP(writer, request)
 }
+
+func ServeServiceUnavailable(writer http.ResponseWriter, request *http.Request) {
+	ServiceUnavailableHandler.ServeHTTP(writer, request)
+}
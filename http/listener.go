@@ -2,34 +2,44 @@ package http
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/fxnn/gone/authenticator"
+	"github.com/fxnn/gone/http/api"
 	"github.com/fxnn/gone/http/editor"
 	"github.com/fxnn/gone/http/router"
 	"github.com/fxnn/gone/http/templates"
 	"github.com/fxnn/gone/http/viewer"
 	"github.com/fxnn/gone/log"
 	"github.com/fxnn/gone/store"
+	"github.com/fxnn/gone/thumbnail"
 
 	"github.com/gorilla/context"
 )
 
 // ListenAndServe brings up the web server component, waits for incoming HTTP
 // requests on the given bindAddress and serves them.
+// Serving a request is stopped after requestTimeout, unless that's zero.
 func ListenAndServe(
 	bindAddress string,
+	requestTimeout time.Duration,
+	uploadMaxBytes int64,
 	auth authenticator.HttpAuthenticator,
 	store store.Store,
+	thumbnails *thumbnail.Cache,
 	loader templates.Loader) {
 	var templateDeliverer = templates.NewTemplateDeliverer(loader)
-	var viewer = viewer.New(loader, store)
-	var editor = editor.New(loader, store)
-	var router = router.New(viewer, editor, templateDeliverer, auth.LoginHandler())
+	var viewer = viewer.New(loader, store, thumbnails)
+	var editor = editor.New(loader, store, uploadMaxBytes)
+	var api = api.New(store, uploadMaxBytes)
+	var router = router.New(viewer, editor, templateDeliverer, auth.LoginHandler(), auth.LogoutHandler(),
+		api)
 
 	var handlerChain = RequestLogger(
-		context.ClearHandler(
-			auth.MiddlewareHandler(
-				router)))
+		RequestDeadline(requestTimeout,
+			context.ClearHandler(
+				auth.MiddlewareHandler(
+					router))))
 
 	log.Fatal(http.ListenAndServe(bindAddress, handlerChain))
 }
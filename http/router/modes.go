@@ -14,8 +14,18 @@ const (
 	ModeEdit          = "edit"
 	ModeCreate        = "create"
 	ModeLogin         = "login"
+	ModeLogout        = "logout"
 	ModeDelete        = "delete"
 	ModeTemplate      = "template"
+	ModeHistory       = "history"
+	ModeRestore       = "restore"
+	ModeDiff          = "diff"
+	ModeSearch        = "search"
+	ModeMove          = "move"
+	ModeUpload        = "upload"
+	ModeTrash         = "trash"
+	ModeUndelete      = "undelete"
+	ModePurge         = "purge"
 )
 
 // To returns a URL that points to the same resource, but lets the
@@ -33,10 +43,32 @@ func Is(m Mode, r *http.Request) bool {
 
 	switch m {
 	case ModeView:
-		ok = !Is(ModeEdit, r) && !Is(ModeCreate, r) && !Is(ModeDelete, r)
-	case ModeEdit, ModeDelete, ModeCreate, ModeLogin, ModeTemplate:
+		ok = !Is(ModeEdit, r) && !Is(ModeCreate, r) && !Is(ModeDelete, r) &&
+			!Is(ModeHistory, r) && !Is(ModeRestore, r) && !Is(ModeDiff, r) && !Is(ModeSearch, r) &&
+			!Is(ModeMove, r) && !Is(ModeUpload, r) && !Is(ModeTrash, r) && !Is(ModeUndelete, r) &&
+			!Is(ModePurge, r)
+	case ModeEdit, ModeDelete, ModeCreate, ModeLogin, ModeLogout, ModeTemplate,
+		ModeHistory, ModeRestore, ModeDiff, ModeSearch, ModeMove, ModeUpload,
+		ModeTrash, ModeUndelete, ModePurge:
 		_, ok = r.Form[string(m)]
 	}
 
 	return ok
 }
+
+// IsModifying returns true, iff the given request might change content,
+// which is the case for all requests but GET and HEAD requests, and for GET
+// requests in modes changing content.
+// Other than Is, this works before the request's form is parsed.
+func IsModifying(r *http.Request) bool {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		return true
+	}
+	var query = r.URL.Query()
+	for _, m := range []Mode{ModeDelete, ModeRestore, ModeUndelete, ModePurge} {
+		if _, ok := query[string(m)]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -1,12 +1,16 @@
 package router
 
 import (
+	"mime"
 	"net/http"
+	"strings"
 
 	"github.com/fxnn/gone/http/failer"
 	"github.com/fxnn/gone/log"
 )
 
+const jsonMimeType = "application/json"
+
 // Router encapsulates http.Handler instances for all relevant views and
 // invokes the right one for each request.
 type Router struct {
@@ -14,6 +18,8 @@ type Router struct {
 	viewer            http.Handler
 	templateDeliverer http.Handler
 	authenticator     http.Handler
+	logout            http.Handler
+	api               http.Handler
 }
 
 // New constructs a new instance ready to use.
@@ -22,11 +28,19 @@ func New(
 	editor http.Handler,
 	templateDeliverer http.Handler,
 	authenticator http.Handler,
+	logout http.Handler,
+	api http.Handler,
 ) *Router {
-	return &Router{editor, viewer, templateDeliverer, authenticator}
+	return &Router{editor, viewer, templateDeliverer, authenticator, logout, api}
 }
 
 func (r Router) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if IsAPIRequest(request) {
+		// HINT: before parsing the form, which might consume the body
+		r.api.ServeHTTP(writer, request)
+		return
+	}
+
 	var err = request.ParseForm()
 	if err != nil {
 		log.Printf("%s %s: %s", request.Method, request.URL, err)
@@ -35,9 +49,28 @@ func (r Router) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 		r.templateDeliverer.ServeHTTP(writer, request)
 	} else if Is(ModeLogin, request) {
 		r.authenticator.ServeHTTP(writer, request)
-	} else if Is(ModeEdit, request) || Is(ModeCreate, request) || Is(ModeDelete, request) {
+	} else if Is(ModeLogout, request) {
+		r.logout.ServeHTTP(writer, request)
+	} else if Is(ModeEdit, request) || Is(ModeCreate, request) || Is(ModeDelete, request) ||
+		Is(ModeRestore, request) || Is(ModeMove, request) || Is(ModeUpload, request) ||
+		Is(ModeUndelete, request) || Is(ModePurge, request) {
 		r.editor.ServeHTTP(writer, request)
 	} else {
 		r.viewer.ServeHTTP(writer, request)
 	}
 }
+
+// IsAPIRequest returns true, iff the request is to be served by the JSON API,
+// which is the case for PUT and DELETE requests and for requests accepting
+// JSON.
+func IsAPIRequest(request *http.Request) bool {
+	if request.Method == "PUT" || request.Method == "DELETE" {
+		return true
+	}
+	for _, accepted := range strings.Split(request.Header.Get("Accept"), ",") {
+		if mimeType, _, err := mime.ParseMediaType(accepted); err == nil && mimeType == jsonMimeType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package templates
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/fxnn/gone/diff"
+)
+
+const conflictTemplateName string = "/conflict.html"
+
+// ConflictRenderer renders the UI for resolving conflicting changes, i.e.
+// when a file was changed by someone else while it was being edited.
+type ConflictRenderer struct {
+	*renderer
+}
+
+func NewConflictRenderer() *ConflictRenderer {
+	return &ConflictRenderer{newRenderer(conflictTemplateName)}
+}
+
+// Render renders the user's content along with the differences to the
+// current version of the file.
+// currentHash identifies the current version, so that the user might
+// knowingly overwrite it.
+func (r ConflictRenderer) Render(writer io.Writer, url *url.URL, content string,
+	currentHash string, mimeType string, hunks []diff.Hunk) error {
+	var data = make(map[string]interface{})
+	data["path"] = url.Path
+	data["content"] = content
+	data["contenthash"] = currentHash
+	data["contenttype"] = mimeType
+	data["hunks"] = hunks
+
+	if err := r.renderData(writer, data); err != nil {
+		return fmt.Errorf("couldn't render conflict template: %s", err)
+	}
+
+	return nil
+}
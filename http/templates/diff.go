@@ -0,0 +1,37 @@
+package templates
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/fxnn/gone/diff"
+)
+
+const diffTemplateName string = "/diff.html"
+
+// DiffRenderer renders the differences between two versions of a file.
+type DiffRenderer struct {
+	*renderer
+}
+
+func NewDiffRenderer() *DiffRenderer {
+	return &DiffRenderer{newRenderer(diffTemplateName)}
+}
+
+// Render renders the given hunks.
+// from and to describe the compared versions, e.g. a revision ID.
+func (r DiffRenderer) Render(writer io.Writer, url *url.URL, from string, to string,
+	hunks []diff.Hunk) error {
+	var data = make(map[string]interface{})
+	data["path"] = url.Path
+	data["from"] = from
+	data["to"] = to
+	data["hunks"] = hunks
+
+	if err := r.renderData(writer, data); err != nil {
+		return fmt.Errorf("couldn't render diff template: %s", err)
+	}
+
+	return nil
+}
@@ -20,11 +20,15 @@ func NewEditorRenderer() *EditorRenderer {
 	}
 }
 
+// Render renders the editor for the given content.
+// contentHash identifies the content's version, so that concurrent changes
+// can be detected when saving.
 func (r EditorRenderer) Render(writer io.Writer, url *url.URL, content string,
-	mimeType string, edit bool) error {
+	contentHash string, mimeType string, edit bool) error {
 	var data = make(map[string]interface{})
 	data["path"] = url.Path
 	data["content"] = content
+	data["contenthash"] = contentHash
 	data["contenttype"] = mimeType
 	if edit {
 		data["edit"] = "edit"
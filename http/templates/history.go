@@ -0,0 +1,32 @@
+package templates
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/fxnn/gone/store"
+)
+
+const historyTemplateName string = "/history.html"
+
+// HistoryRenderer renders the list of revisions of a file.
+type HistoryRenderer struct {
+	*renderer
+}
+
+func NewHistoryRenderer() *HistoryRenderer {
+	return &HistoryRenderer{newRenderer(historyTemplateName)}
+}
+
+func (r HistoryRenderer) Render(writer io.Writer, url *url.URL, revisions []store.Revision) error {
+	var data = make(map[string]interface{})
+	data["path"] = url.Path
+	data["revisions"] = revisions
+
+	if err := r.renderData(writer, data); err != nil {
+		return fmt.Errorf("couldn't render history template: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package templates
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+
+	"github.com/fxnn/gone/store"
+)
+
+const listingTemplateName string = "/listing.html"
+
+// ListingRenderer renders the entries of a directory.
+type ListingRenderer struct {
+	*renderer
+}
+
+func NewListingRenderer() *ListingRenderer {
+	return &ListingRenderer{newRenderer(listingTemplateName)}
+}
+
+func (r ListingRenderer) Render(writer io.Writer, url *url.URL, entries []store.Entry) error {
+	var data = make(map[string]interface{})
+	data["path"] = url.Path
+	data["entries"] = entries
+	if url.Path != "/" {
+		data["parentPath"] = path.Dir(path.Clean(url.Path))
+	}
+
+	if err := r.renderData(writer, data); err != nil {
+		return fmt.Errorf("couldn't render listing template: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package templates
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+)
+
+const loginTemplateName string = "/login.html"
+
+// LoginRenderer renders the login form.
+type LoginRenderer struct {
+	*renderer
+}
+
+func NewLoginRenderer() *LoginRenderer {
+	return &LoginRenderer{newRenderer(loginTemplateName)}
+}
+
+// Render renders the login form for the given URL.
+// failed tells whether a previous login attempt failed.
+func (r LoginRenderer) Render(writer io.Writer, url *url.URL, user string, failed bool) error {
+	var data = make(map[string]interface{})
+	data["path"] = url.Path
+	data["user"] = user
+	data["failed"] = failed
+
+	if err := r.renderData(writer, data); err != nil {
+		return fmt.Errorf("couldn't render login template: %s", err)
+	}
+
+	return nil
+}
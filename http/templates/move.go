@@ -0,0 +1,30 @@
+package templates
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+)
+
+const moveTemplateName string = "/move.html"
+
+// MoveRenderer renders the UI for moving a file.
+type MoveRenderer struct {
+	*renderer
+}
+
+func NewMoveRenderer() *MoveRenderer {
+	return &MoveRenderer{newRenderer(moveTemplateName)}
+}
+
+func (r MoveRenderer) Render(writer io.Writer, url *url.URL, backlinks []string) error {
+	var data = make(map[string]interface{})
+	data["path"] = url.Path
+	data["backlinks"] = backlinks
+
+	if err := r.renderData(writer, data); err != nil {
+		return fmt.Errorf("couldn't render move template: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package templates
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/fxnn/gone/store"
+)
+
+const searchTemplateName string = "/search.html"
+
+// SearchRenderer renders the results of a full-text search.
+type SearchRenderer struct {
+	*renderer
+}
+
+func NewSearchRenderer() *SearchRenderer {
+	return &SearchRenderer{newRenderer(searchTemplateName)}
+}
+
+func (r SearchRenderer) Render(writer io.Writer, url *url.URL, query string, results []store.SearchResult) error {
+	var data = make(map[string]interface{})
+	data["path"] = url.Path
+	data["query"] = query
+	data["results"] = results
+
+	if err := r.renderData(writer, data); err != nil {
+		return fmt.Errorf("couldn't render search template: %s", err)
+	}
+
+	return nil
+}
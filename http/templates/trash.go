@@ -0,0 +1,32 @@
+package templates
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/fxnn/gone/store"
+)
+
+const trashTemplateName string = "/trash.html"
+
+// TrashRenderer renders the list of deleted files below a directory.
+type TrashRenderer struct {
+	*renderer
+}
+
+func NewTrashRenderer() *TrashRenderer {
+	return &TrashRenderer{newRenderer(trashTemplateName)}
+}
+
+func (r TrashRenderer) Render(writer io.Writer, url *url.URL, items []store.TrashItem) error {
+	var data = make(map[string]interface{})
+	data["path"] = url.Path
+	data["items"] = items
+
+	if err := r.renderData(writer, data); err != nil {
+		return fmt.Errorf("couldn't render trash template: %s", err)
+	}
+
+	return nil
+}
@@ -17,10 +17,13 @@ func NewViewerRenderer() *ViewerRenderer {
 	return &ViewerRenderer{newRenderer(viewerTemplateName)}
 }
 
-func (r ViewerRenderer) Render(writer io.Writer, url *url.URL, htmlContent string) error {
+// Render renders the page's HTML content along with the URL paths of the
+// pages linking to it.
+func (r ViewerRenderer) Render(writer io.Writer, url *url.URL, htmlContent string, backlinks []string) error {
 	var data = make(map[string]interface{})
 	data["path"] = url.Path
 	data["htmlContent"] = template.HTML(htmlContent)
+	data["backlinks"] = backlinks
 
 	if err := r.renderData(writer, data); err != nil {
 		return fmt.Errorf("couldn't render viewer template: %s", err)
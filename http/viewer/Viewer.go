@@ -1,27 +1,73 @@
 package viewer
 
 import (
+	"fmt"
+	"mime"
 	"net/http"
 	"time"
 
 	"github.com/fxnn/gone/http/failer"
+	"github.com/fxnn/gone/http/router"
 	"github.com/fxnn/gone/http/templates"
 	"github.com/fxnn/gone/log"
 	"github.com/fxnn/gone/store"
+	"github.com/fxnn/gone/thumbnail"
 )
 
 // The Viewer serves HTTP requests with content from the filesystem.
 type Viewer struct {
-	store      store.Store
-	formatters formatters
+	store           store.Store
+	formatters      formatters
+	historyRenderer *templates.HistoryRenderer
+	trashRenderer   *templates.TrashRenderer
+	diffRenderer    *templates.DiffRenderer
+	searchRenderer  *templates.SearchRenderer
+	thumbnails      *thumbnail.Cache
 }
 
 // New initializes a Viewer instance ready to use.
-func New(l templates.Loader, s store.Store) *Viewer {
-	return &Viewer{s, newFormatters(l)}
+// Scaled images are cached in the given thumbnail cache.
+func New(l templates.Loader, s store.Store, thumbnails *thumbnail.Cache) *Viewer {
+	var historyRenderer = templates.NewHistoryRenderer()
+	if err := historyRenderer.LoadAndWatch(l); err != nil {
+		panic(fmt.Errorf("couldn't load history template: %s", err))
+	}
+	var trashRenderer = templates.NewTrashRenderer()
+	if err := trashRenderer.LoadAndWatch(l); err != nil {
+		panic(fmt.Errorf("couldn't load trash template: %s", err))
+	}
+	var diffRenderer = templates.NewDiffRenderer()
+	if err := diffRenderer.LoadAndWatch(l); err != nil {
+		panic(fmt.Errorf("couldn't load diff template: %s", err))
+	}
+	var searchRenderer = templates.NewSearchRenderer()
+	if err := searchRenderer.LoadAndWatch(l); err != nil {
+		panic(fmt.Errorf("couldn't load search template: %s", err))
+	}
+
+	return &Viewer{s, newFormatters(l), historyRenderer, trashRenderer, diffRenderer,
+		searchRenderer, thumbnails}
 }
 
 func (v *Viewer) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	v.forRequest().serveHTTP(writer, request)
+}
+
+// forRequest returns a copy of the Viewer using its own store handle, as
+// store handles may not be shared between requests.
+func (v *Viewer) forRequest() *Viewer {
+	var result = *v
+	result.store = v.store.Handle()
+	return &result
+}
+
+func (v *Viewer) serveHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method == "GET" && router.Is(router.ModeSearch, request) {
+		// HINT: access control is applied to each search result
+		v.serveSearch(writer, request)
+		return
+	}
+
 	if !v.store.HasReadAccessForRequest(request) {
 		log.Printf("%s %s: no read permissions", request.Method, request.URL)
 		failer.ServeUnauthorized(writer, request)
@@ -33,6 +79,21 @@ func (v *Viewer) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	if router.Is(router.ModeHistory, request) {
+		v.serveHistory(writer, request)
+		return
+	}
+
+	if router.Is(router.ModeDiff, request) {
+		v.serveDiff(writer, request)
+		return
+	}
+
+	if router.Is(router.ModeTrash, request) {
+		v.serveTrash(writer, request)
+		return
+	}
+
 	v.serveGET(writer, request)
 }
 
@@ -41,11 +102,17 @@ func (v *Viewer) serveNonGET(writer http.ResponseWriter, request *http.Request)
 }
 
 func (v *Viewer) serveGET(writer http.ResponseWriter, request *http.Request) {
-	if v.isNotModified(writer, request) {
+	var mimeType = v.mimeTypeForRequest(request)
+	if isCacheable(mimeType) && v.isNotModified(writer, request) {
 		return
 	}
 
-	var formatter = v.formatterForRequest(request)
+	if v.isServeThumbnail(request, mimeType) {
+		v.serveThumbnail(writer, request)
+		return
+	}
+
+	var formatter = v.formatters.mimeTypeFormatter(mimeType)
 	var readCloser = v.store.OpenReader(request)
 	if err := v.store.Err(); err != nil {
 		v.serveError(writer, request, err)
@@ -53,7 +120,72 @@ func (v *Viewer) serveGET(writer http.ResponseWriter, request *http.Request) {
 	}
 
 	defer readCloser.Close()
-	formatter.serveFromReader(readCloser, writer, request)
+	formatter.serveFromReader(v.store, readCloser, writer, request)
+}
+
+// serveHistory lists the revisions of the requested file or, when a revision
+// is given, serves that revision's content.
+func (v *Viewer) serveHistory(writer http.ResponseWriter, request *http.Request) {
+	if revisionID := request.FormValue("revision"); revisionID != "" {
+		v.serveRevision(writer, request, revisionID)
+		return
+	}
+
+	var revisions = v.store.Revisions(request)
+	if err := v.store.Err(); err != nil {
+		v.serveError(writer, request, err)
+		return
+	}
+
+	if err := v.historyRenderer.Render(writer, request.URL, revisions); err != nil {
+		v.log(request, err)
+		failer.ServeInternalServerError(writer, request)
+		return
+	}
+}
+
+func (v *Viewer) serveRevision(writer http.ResponseWriter, request *http.Request, revisionID string) {
+	var formatter = v.formatterForRequest(request)
+	var readCloser = v.store.OpenRevisionReader(request, revisionID)
+	if err := v.store.Err(); err != nil {
+		v.serveError(writer, request, err)
+		return
+	}
+
+	defer readCloser.Close()
+	formatter.serveFromReader(v.store, readCloser, writer, request)
+}
+
+// serveTrash lists the deleted files that were located below the requested
+// directory.
+func (v *Viewer) serveTrash(writer http.ResponseWriter, request *http.Request) {
+	var items = v.store.TrashItems(request)
+	if err := v.store.Err(); err != nil {
+		v.serveError(writer, request, err)
+		return
+	}
+
+	if err := v.trashRenderer.Render(writer, request.URL, items); err != nil {
+		v.log(request, err)
+		failer.ServeInternalServerError(writer, request)
+		return
+	}
+}
+
+// isCacheable returns true iff the content served for files of the given
+// MIME type only depends on the file itself, so that its modification time
+// tells whether it changed.
+//
+// A directory's modification time doesn't reflect changes of its entries'
+// contents, so listings aren't cached.
+// Rendered Markdown shows links to missing pages and backlinks from other
+// pages; its formatter validates the rendered page by an ETag instead.
+func isCacheable(mediaType string) bool {
+	var mimeType, _, err = mime.ParseMediaType(mediaType)
+	if err != nil {
+		return true
+	}
+	return mimeType != store.DirectoryMimeType && mimeType != store.MarkdownMimeType
 }
 
 // isNotModified handles the complete Last-Modified / If-Modified-Since logic
@@ -91,7 +223,11 @@ func (v *Viewer) log(request *http.Request, err error) {
 }
 
 func (v *Viewer) formatterForRequest(request *http.Request) formatter {
+	return v.formatters.mimeTypeFormatter(v.mimeTypeForRequest(request))
+}
+
+func (v *Viewer) mimeTypeForRequest(request *http.Request) string {
 	var mimeType = v.store.MimeTypeForRequest(request)
 	v.store.Err() // don't care for errors
-	return v.formatters.mimeTypeFormatter(mimeType)
+	return mimeType
 }
@@ -0,0 +1,81 @@
+package viewer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/fxnn/gone/diff"
+	"github.com/fxnn/gone/http/failer"
+	"github.com/fxnn/gone/store"
+)
+
+const currentVersionLabel = "current version"
+
+// serveDiff shows the differences between two revisions, given by the "from"
+// and "to" parameters.
+// When "to" is missing, the current version is used instead.
+// When "from" is missing, the newest revision is used.
+func (v *Viewer) serveDiff(writer http.ResponseWriter, request *http.Request) {
+	var fromID, toID = request.FormValue("from"), request.FormValue("to")
+	var err error
+	if fromID == "" {
+		if fromID, err = v.newestRevisionID(request); err != nil {
+			v.serveError(writer, request, err)
+			return
+		}
+	}
+
+	oldContent, err := v.readRevision(request, fromID)
+	if err != nil {
+		v.serveError(writer, request, err)
+		return
+	}
+
+	var newContent, toLabel string
+	if toID == "" {
+		toLabel = currentVersionLabel
+		newContent = v.store.ReadString(request)
+		err = v.store.Err()
+	} else {
+		toLabel = toID
+		newContent, err = v.readRevision(request, toID)
+	}
+	if err != nil {
+		v.serveError(writer, request, err)
+		return
+	}
+
+	var hunks = diff.Hunks(diff.Strings(oldContent, newContent), diff.DefaultContext)
+	if err := v.diffRenderer.Render(writer, request.URL, fromID, toLabel, hunks); err != nil {
+		v.log(request, err)
+		failer.ServeInternalServerError(writer, request)
+		return
+	}
+}
+
+func (v *Viewer) newestRevisionID(request *http.Request) (string, error) {
+	var revisions = v.store.Revisions(request)
+	if err := v.store.Err(); err != nil {
+		return "", err
+	}
+	if len(revisions) == 0 {
+		return "", store.NewPathNotFoundError(
+			fmt.Sprintf("there are no revisions of %s", request.URL.Path))
+	}
+	return revisions[0].ID, nil
+}
+
+func (v *Viewer) readRevision(request *http.Request, revisionID string) (string, error) {
+	var readCloser = v.store.OpenRevisionReader(request, revisionID)
+	if err := v.store.Err(); err != nil {
+		return "", err
+	}
+	defer readCloser.Close()
+
+	content, err := ioutil.ReadAll(readCloser)
+	if err != nil {
+		return "", fmt.Errorf("couldn't read revision %s: %s", revisionID, err)
+	}
+	return string(content), nil
+}
@@ -9,8 +9,11 @@ import (
 	"github.com/fxnn/gone/store"
 )
 
+// formatter serves content of a certain type.
+// The store is the handle of the current request, see store.Store.
 type formatter interface {
-	serveFromReader(reader io.Reader, writer http.ResponseWriter, request *http.Request)
+	serveFromReader(s store.Store, reader io.Reader, writer http.ResponseWriter,
+		request *http.Request)
 }
 
 type formatters struct {
@@ -21,6 +24,7 @@ func newFormatters(l templates.Loader) formatters {
 	var formatterByMimeType = map[string]formatter{
 		store.MarkdownMimeType: newMarkdownFormatter(l),
 		store.UrlMimeType: newRedirectFormatter(l),
+		store.DirectoryMimeType: newListingFormatter(l),
 	}
 	return formatters{formatterByMimeType}
 }
@@ -0,0 +1,53 @@
+package viewer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/fxnn/gone/http/failer"
+	"github.com/fxnn/gone/http/templates"
+	"github.com/fxnn/gone/log"
+	"github.com/fxnn/gone/store"
+)
+
+const listingFormatterOutputMimeType = "text/html"
+
+// listingFormatter shows the entries of directories without index document.
+type listingFormatter struct {
+	renderer *templates.ListingRenderer
+}
+
+func newListingFormatter(l templates.Loader) listingFormatter {
+	var result = listingFormatter{templates.NewListingRenderer()}
+	if err := result.renderer.LoadAndWatch(l); err != nil {
+		panic(fmt.Errorf("couldn't load listing template: %s", err))
+	}
+	return result
+}
+
+// serveFromReader ignores the reader, as the entries are retrieved from the
+// store.
+func (f listingFormatter) serveFromReader(s store.Store, reader io.Reader, writer http.ResponseWriter,
+	request *http.Request) {
+	var entries = s.List(request)
+	if err := s.Err(); err != nil {
+		log.Warnf("%s %s: %s", request.Method, request.URL, err)
+		if store.IsPathNotFoundError(err) {
+			failer.ServeNotFound(writer, request)
+			return
+		}
+		failer.ServeInternalServerError(writer, request)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := f.renderer.Render(&buf, request.URL, entries); err != nil {
+		log.Warnf("%s %s: %s", request.Method, request.URL, err)
+		failer.ServeInternalServerError(writer, request)
+		return
+	}
+	writer.Header().Set("Content-Type", listingFormatterOutputMimeType)
+	buf.WriteTo(writer)
+}
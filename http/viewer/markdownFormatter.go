@@ -1,20 +1,41 @@
 package viewer
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strings"
 
 	"github.com/fxnn/gone/log"
 
 	"github.com/fxnn/gone/http/failer"
 	"github.com/fxnn/gone/http/templates"
+	"github.com/fxnn/gone/links"
+	"github.com/fxnn/gone/store"
 	"github.com/russross/blackfriday"
 )
 
 const markdownFormatterOutputMimeType = "text/html"
 
+// markdownHtmlFlags and markdownExtensions equal blackfriday's common
+// settings.
+const (
+	markdownHtmlFlags = blackfriday.HTML_USE_XHTML |
+		blackfriday.HTML_USE_SMARTYPANTS |
+		blackfriday.HTML_SMARTYPANTS_FRACTIONS |
+		blackfriday.HTML_SMARTYPANTS_LATEX_DASHES
+	markdownExtensions = blackfriday.EXTENSION_NO_INTRA_EMPHASIS |
+		blackfriday.EXTENSION_TABLES |
+		blackfriday.EXTENSION_FENCED_CODE |
+		blackfriday.EXTENSION_AUTOLINK |
+		blackfriday.EXTENSION_STRIKETHROUGH |
+		blackfriday.EXTENSION_SPACE_HEADERS |
+		blackfriday.EXTENSION_HEADER_IDS |
+		blackfriday.EXTENSION_BACKSLASH_LINE_BREAK
+)
+
 type markdownFormatter struct {
 	renderer *templates.ViewerRenderer
 }
@@ -28,7 +49,8 @@ func newMarkdownFormatter(l templates.Loader) markdownFormatter {
 	return result
 }
 
-func (f markdownFormatter) serveFromReader(reader io.Reader, writer http.ResponseWriter, request *http.Request) {
+func (f markdownFormatter) serveFromReader(s store.Store, reader io.Reader, writer http.ResponseWriter,
+	request *http.Request) {
 	writer.Header().Set("Content-Type", markdownFormatterOutputMimeType)
 
 	markdown, err := ioutil.ReadAll(reader)
@@ -38,8 +60,46 @@ func (f markdownFormatter) serveFromReader(reader io.Reader, writer http.Respons
 		return
 	}
 
-	html := blackfriday.MarkdownCommon(markdown)
-	if err := f.renderer.Render(writer, request.URL, string(html)); err != nil {
+	var renderer = newWikiLinkRenderer(
+		blackfriday.HtmlRenderer(markdownHtmlFlags, "", ""), s, request)
+	html := blackfriday.MarkdownOptions(links.ReplaceWikiLinks(markdown), renderer,
+		blackfriday.Options{Extensions: markdownExtensions})
+	// HINT: without index, finding backlinks is too expensive for each view
+	var backlinks = s.IndexedBacklinks(request)
+	if err := s.Err(); err != nil {
+		// HINT: the page is still worth showing
+		log.Warnf("%s %s: couldn't find backlinks: %s", request.Method, request.URL, err)
+	}
+
+	var page bytes.Buffer
+	if err := f.renderer.Render(&page, request.URL, string(html), backlinks); err != nil {
+		log.Warnf("%s %s: %s", request.Method, request.URL, err)
+		failer.ServeInternalServerError(writer, request)
+		return
+	}
+
+	// HINT: the page shows whether linked pages exist and which pages link
+	// to it, so only the rendered page tells whether it changed
+	var eTag = `"` + store.ContentHash(page.String()) + `"`
+	writer.Header().Set("ETag", eTag)
+	if isNoneMatchHit(request.Header.Get("If-None-Match"), eTag) {
+		writer.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if _, err := page.WriteTo(writer); err != nil {
 		log.Warnf("%s %s: %s", request.Method, request.URL, err)
 	}
 }
+
+// isNoneMatchHit returns true iff the If-None-Match header value names the
+// given entity tag, so that the client's copy is still valid.
+// As If-None-Match compares weakly, weak entity tags match as well.
+func isNoneMatchHit(header string, eTag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == eTag {
+			return true
+		}
+	}
+	return false
+}
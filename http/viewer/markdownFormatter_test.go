@@ -0,0 +1,42 @@
+package viewer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fxnn/gone/http/templates"
+	"github.com/fxnn/gone/store/mockstore"
+)
+
+func TestMarkdownIsNotModifiedWhileRenderedPageIsUnchanged(t *testing.T) {
+	var sut = newMarkdownFormatter(templates.NewStaticLoader())
+	var s = mockstore.New()
+
+	var first = serveMarkdown(sut, s, "# Title", "")
+	var eTag = first.Header().Get("ETag")
+	if first.Code != http.StatusOK || eTag == "" {
+		t.Fatalf("expected page with ETag, but got %d and %q", first.Code, eTag)
+	}
+
+	if second := serveMarkdown(sut, s, "# Title", eTag); second.Code != http.StatusNotModified {
+		t.Fatalf("expected %d for unchanged page, but got %d", http.StatusNotModified, second.Code)
+	}
+
+	s.GivenBacklinks("/other.md")
+	if third := serveMarkdown(sut, s, "# Title", eTag); third.Code != http.StatusOK {
+		t.Fatalf("expected %d for page with new backlink, but got %d", http.StatusOK, third.Code)
+	}
+}
+
+func serveMarkdown(sut markdownFormatter, s *mockstore.MockStore, markdown string,
+	ifNoneMatch string) *httptest.ResponseRecorder {
+	var response = httptest.NewRecorder()
+	var request = httptest.NewRequest("GET", "/page.md", nil)
+	if ifNoneMatch != "" {
+		request.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	sut.serveFromReader(s, strings.NewReader(markdown), response, request)
+	return response
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/fxnn/gone/log"
 
 	"github.com/fxnn/gone/http/failer"
+	"github.com/fxnn/gone/store"
 )
 
 type rawFormatter struct {
@@ -17,7 +18,8 @@ func newRawFormatter(mimeType string) rawFormatter {
 	return rawFormatter{mimeType}
 }
 
-func (f rawFormatter) serveFromReader(reader io.Reader, writer http.ResponseWriter, request *http.Request) {
+func (f rawFormatter) serveFromReader(s store.Store, reader io.Reader, writer http.ResponseWriter,
+	request *http.Request) {
 	writer.Header().Set("Content-Type", f.mimeType)
 
 	// TODO: Use http.ServeContent instead
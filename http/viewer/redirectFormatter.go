@@ -10,6 +10,7 @@ import (
 	"github.com/fxnn/gone/log"
 	"github.com/fxnn/gone/http/failer"
 	"github.com/fxnn/gone/http/templates"
+	"github.com/fxnn/gone/store"
 )
 
 var winUrlRegexp = regexp.MustCompile("(?m)^URL\\=(.+)$")
@@ -21,7 +22,8 @@ func newRedirectFormatter(l templates.Loader) redirectFormatter {
 	return redirectFormatter{}
 }
 
-func (f redirectFormatter) serveFromReader(reader io.Reader, writer http.ResponseWriter, request *http.Request) {
+func (f redirectFormatter) serveFromReader(s store.Store, reader io.Reader, writer http.ResponseWriter,
+	request *http.Request) {
 	contents, err := ioutil.ReadAll(reader)
 	if err != nil {
 		log.Warnf("%s %s: could not read from reader: %s", request.Method, request.URL, err)
@@ -0,0 +1,29 @@
+package viewer
+
+import (
+	"net/http"
+
+	"github.com/fxnn/gone/http/failer"
+	"github.com/fxnn/gone/store"
+)
+
+// serveSearch lists all files below the requested directory matching the
+// query given by the "search" parameter.
+// Without a query, only the search form is shown.
+func (v *Viewer) serveSearch(writer http.ResponseWriter, request *http.Request) {
+	var query = request.FormValue("search")
+	var results []store.SearchResult
+	if query != "" {
+		results = v.store.Search(request, query)
+		if err := v.store.Err(); err != nil {
+			v.serveError(writer, request, err)
+			return
+		}
+	}
+
+	if err := v.searchRenderer.Render(writer, request.URL, query, results); err != nil {
+		v.log(request, err)
+		failer.ServeInternalServerError(writer, request)
+		return
+	}
+}
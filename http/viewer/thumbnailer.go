@@ -0,0 +1,67 @@
+package viewer
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/fxnn/gone/http/failer"
+	"github.com/fxnn/gone/thumbnail"
+)
+
+const thumbnailWidthParameter = "w"
+
+// isServeThumbnail returns true iff the request asks for a scaled version of
+// an image of the given mime type.
+func (v *Viewer) isServeThumbnail(request *http.Request, mimeType string) bool {
+	if request.FormValue(thumbnailWidthParameter) == "" {
+		return false
+	}
+	var mediaType, _, err = mime.ParseMediaType(mimeType)
+	return err == nil && thumbnail.IsSupportedMimeType(mediaType)
+}
+
+// serveThumbnail serves the requested image scaled to the requested width,
+// rounded by thumbnail.RoundWidth.
+// Scaled images are cached until the image's modification time changes.
+func (v *Viewer) serveThumbnail(writer http.ResponseWriter, request *http.Request) {
+	var width, err = strconv.Atoi(request.FormValue(thumbnailWidthParameter))
+	if err == nil && width < 1 {
+		err = fmt.Errorf("thumbnail width %d is not positive", width)
+	}
+	if err != nil {
+		v.log(request, err)
+		failer.ServeBadRequest(writer, request)
+		return
+	}
+
+	var modTime = v.store.ModTimeForRequest(request)
+	var urlPath = v.store.URLPathForRequest(request)
+	if err := v.store.Err(); err != nil {
+		v.serveError(writer, request, err)
+		return
+	}
+
+	reader, mimeType, err := v.thumbnails.Open(urlPath, modTime, thumbnail.RoundWidth(width),
+		func() (io.ReadCloser, error) {
+			var readCloser = v.store.OpenReader(request)
+			return readCloser, v.store.Err()
+		})
+	if err == thumbnail.ErrUnsupportedImage || err == thumbnail.ErrImageTooLarge {
+		v.log(request, err)
+		failer.ServeUnsupportedMediaType(writer, request)
+		return
+	}
+	if err != nil {
+		v.serveError(writer, request, err)
+		return
+	}
+	defer reader.Close()
+
+	writer.Header().Set("Content-Type", mimeType)
+	if _, err := io.Copy(writer, reader); err != nil {
+		v.log(request, err)
+	}
+}
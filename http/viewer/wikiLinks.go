@@ -0,0 +1,80 @@
+package viewer
+
+import (
+	"bytes"
+	"html"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/fxnn/gone/links"
+	"github.com/fxnn/gone/store"
+	"github.com/russross/blackfriday"
+)
+
+const (
+	// missingPageClass is the CSS class of links to pages that don't exist.
+	missingPageClass = "missing"
+
+	// defaultPageExtension is used when creating missing pages.
+	defaultPageExtension = ".md"
+)
+
+// wikiLinkRenderer resolves links written in wiki link syntax relative to
+// the requested page.
+// Links to existing pages point to the page, links to missing pages allow to
+// create it.
+type wikiLinkRenderer struct {
+	blackfriday.Renderer
+	store   store.Store
+	request *http.Request
+}
+
+func newWikiLinkRenderer(r blackfriday.Renderer, s store.Store, request *http.Request) *wikiLinkRenderer {
+	return &wikiLinkRenderer{r, s, request}
+}
+
+func (r *wikiLinkRenderer) Link(out *bytes.Buffer, link []byte, title []byte, content []byte) {
+	target, ok := links.WikiLinkTarget(string(link))
+	if !ok {
+		r.Renderer.Link(out, link, title, content)
+		return
+	}
+
+	var targetPath = links.Resolve(r.request.URL.Path, target)
+	if r.exists(targetPath) {
+		r.Renderer.Link(out, []byte(pathToURL(targetPath)), title, content)
+		return
+	}
+
+	if path.Ext(targetPath) == "" {
+		targetPath += defaultPageExtension
+	}
+	out.WriteString(`<a href="`)
+	out.WriteString(html.EscapeString(pathToURL(targetPath) + "?create"))
+	out.WriteString(`" class="` + missingPageClass + `">`)
+	out.Write(content)
+	out.WriteString("</a>")
+}
+
+// exists uses the store to find the target page.
+// As the store resolves missing file extensions, so does this method.
+// Pages the user may not read are treated as missing, so that links don't
+// reveal whether they exist.
+func (r *wikiLinkRenderer) exists(targetPath string) bool {
+	var targetURL = *r.request.URL
+	targetURL.Path = targetPath
+	var targetRequest = *r.request
+	targetRequest.URL = &targetURL
+
+	if !r.store.HasReadAccessForRequest(&targetRequest) {
+		r.store.Err() // don't care for errors
+		return false
+	}
+	r.store.ModTimeForRequest(&targetRequest)
+	return r.store.Err() == nil
+}
+
+func pathToURL(p string) string {
+	return (&url.URL{Path: p}).String()
+}
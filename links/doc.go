@@ -0,0 +1,7 @@
+// Package links finds the links between pages.
+//
+// Besides standard Markdown links, it supports wiki links like
+// "[[Page Name]]" or "[[Page Name|label]]".
+// Wiki links are turned into Markdown links using a special scheme, so that
+// they can be handled by a Markdown renderer.
+package links
@@ -0,0 +1,64 @@
+package links
+
+import (
+	"bytes"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/russross/blackfriday"
+)
+
+// Resolve returns the URL path of the given target as linked from the given
+// page.
+// Relative targets are relative to the page's directory.
+func Resolve(pagePath string, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return path.Clean(target)
+	}
+	return path.Join(path.Dir(pagePath), target)
+}
+
+// Extract finds the URL paths of all pages the given Markdown page links
+// to, both through Markdown and wiki links.
+// Links to other sites are left out.
+func Extract(pagePath string, markdown []byte) []string {
+	var collector = &linkCollector{Renderer: blackfriday.HtmlRenderer(0, "", ""), seen: make(map[string]bool)}
+	blackfriday.Markdown(ReplaceWikiLinks(markdown), collector,
+		blackfriday.EXTENSION_TABLES|blackfriday.EXTENSION_FENCED_CODE|
+			blackfriday.EXTENSION_STRIKETHROUGH|blackfriday.EXTENSION_SPACE_HEADERS)
+
+	var result = make([]string, 0, len(collector.targets))
+	for _, target := range collector.targets {
+		result = append(result, Resolve(pagePath, target))
+	}
+	return result
+}
+
+// linkCollector is a Markdown renderer that only records link targets.
+type linkCollector struct {
+	blackfriday.Renderer
+	targets []string
+	seen    map[string]bool
+}
+
+func (c *linkCollector) Link(out *bytes.Buffer, link []byte, title []byte, content []byte) {
+	if target, ok := WikiLinkTarget(string(link)); ok {
+		c.add(target)
+		return
+	}
+
+	u, err := url.Parse(string(link))
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+		// HINT: external links and links inside the page
+		return
+	}
+	c.add(u.Path)
+}
+
+func (c *linkCollector) add(target string) {
+	if !c.seen[target] {
+		c.seen[target] = true
+		c.targets = append(c.targets, target)
+	}
+}
@@ -0,0 +1,69 @@
+package links
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReplaceWikiLinks(t *testing.T) {
+	var result = string(ReplaceWikiLinks([]byte("[[Page Name]] and [[dir/page| label ]]")))
+
+	var expected = "[Page Name](wiki:Page%20Name) and [label](wiki:dir%2Fpage)"
+	if result != expected {
+		t.Fatalf("expected %q, but got %q", expected, result)
+	}
+}
+
+func TestReplaceWikiLinksSkipsCode(t *testing.T) {
+	var markdown = "`[[span]]`\n```\n[[fenced]]\n```\n"
+
+	if result := string(ReplaceWikiLinks([]byte(markdown))); result != markdown {
+		t.Fatalf("expected code to be untouched, but got %q", result)
+	}
+}
+
+func TestWikiLinkTarget(t *testing.T) {
+	if target, ok := WikiLinkTarget("wiki:Page%20Name"); !ok || target != "Page Name" {
+		t.Fatalf("expected target 'Page Name', but got %q", target)
+	}
+	if _, ok := WikiLinkTarget("/page"); ok {
+		t.Fatalf("expected standard link not to be a wiki link")
+	}
+}
+
+func TestExtractResolvesInternalLinks(t *testing.T) {
+	var markdown = "[[Other Page]], [up](../up.md#section), [abs](/abs), " +
+		"[ext](https://example.com/), [anchor](#top), [[Other Page|again]]\n\n" +
+		"[ref][r]\n\n[r]: ref.md\n"
+
+	var result = Extract("/dir/page.md", []byte(markdown))
+
+	var expected = "/dir/Other Page,/up.md,/abs,/dir/ref.md"
+	if strings.Join(result, ",") != expected {
+		t.Fatalf("expected %s, but got %v", expected, result)
+	}
+}
+
+func TestRewriteChangesLinksToOldTargets(t *testing.T) {
+	var markdown = "[[old]], [[old|Label]], [x](../dir/old.md#top \"title\"), [[other]]\n\n" +
+		"`[[old]]`\n\n[r]: /dir/old\n"
+
+	var result, changed = Rewrite("/dir/page.md", []byte(markdown),
+		[]string{"/dir/old.md", "/dir/old"}, "/new/name.md")
+
+	var expected = "[[/new/name|old]], [[/new/name|Label]], [x](/new/name.md#top \"title\"), [[other]]\n\n" +
+		"`[[old]]`\n\n[r]: /new/name\n"
+	if !changed || string(result) != expected {
+		t.Fatalf("expected %q, but got %q", expected, result)
+	}
+}
+
+func TestRewriteReportsUnchangedContent(t *testing.T) {
+	var markdown = "[[other]] and [y](https://example.com/old.md)"
+
+	var result, changed = Rewrite("/page.md", []byte(markdown), []string{"/old.md"}, "/new.md")
+
+	if changed || string(result) != markdown {
+		t.Fatalf("expected unchanged content, but got %q", result)
+	}
+}
@@ -0,0 +1,88 @@
+package links
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+)
+
+// inlineLinkRegexp matches the destination of inline links like
+// "[label](destination "title")".
+var inlineLinkRegexp = regexp.MustCompile(`(\]\()([^)\s]+)((?:\s+"[^"]*")?\))`)
+
+// referenceRegexp matches the destination of reference definitions like
+// "[ref]: destination".
+var referenceRegexp = regexp.MustCompile(`^( {0,3}\[[^\]]+\]:[ \t]*)(\S+)`)
+
+// Rewrite changes all links in the given Markdown page that point to any of
+// the old targets, so that they point to the new path instead.
+// Rewritten links are absolute; they omit the file extension if the original
+// link did.
+// The second result tells whether anything was changed.
+func Rewrite(pagePath string, markdown []byte, oldTargets []string, newPath string) ([]byte, bool) {
+	var r = rewriter{pagePath, oldTargets, newPath, false}
+	var result = mapOutsideCode(markdown, func(text []byte, atLineStart bool) []byte {
+		text = wikiLinkRegexp.ReplaceAllFunc(text, r.rewriteWikiLink)
+		text = inlineLinkRegexp.ReplaceAllFunc(text, func(match []byte) []byte {
+			return r.rewriteDestination(inlineLinkRegexp, match)
+		})
+		if atLineStart {
+			text = referenceRegexp.ReplaceAllFunc(text, func(match []byte) []byte {
+				return r.rewriteDestination(referenceRegexp, match)
+			})
+		}
+		return text
+	})
+	return result, r.changed
+}
+
+type rewriter struct {
+	pagePath   string
+	oldTargets []string
+	newPath    string
+	changed    bool
+}
+
+func (r *rewriter) rewriteWikiLink(match []byte) []byte {
+	var target, label = parseWikiLink(match)
+	if !r.isOldTarget(target) {
+		return match
+	}
+	r.changed = true
+	return []byte("[[" + r.newTarget(target) + "|" + label + "]]")
+}
+
+// rewriteDestination rewrites the second group of the regexp's match.
+func (r *rewriter) rewriteDestination(re *regexp.Regexp, match []byte) []byte {
+	var groups = re.FindSubmatch(match)
+	u, err := url.Parse(string(groups[2]))
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" || !r.isOldTarget(u.Path) {
+		return match
+	}
+	r.changed = true
+	u.Path = r.newTarget(u.Path)
+
+	var result = append([]byte{}, groups[1]...)
+	result = append(result, u.String()...)
+	for _, rest := range groups[3:] {
+		result = append(result, rest...)
+	}
+	return result
+}
+
+func (r *rewriter) isOldTarget(target string) bool {
+	var resolved = Resolve(r.pagePath, target)
+	for _, old := range r.oldTargets {
+		if resolved == old {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *rewriter) newTarget(oldTarget string) string {
+	if path.Ext(oldTarget) == "" {
+		return r.newPath[:len(r.newPath)-len(path.Ext(r.newPath))]
+	}
+	return r.newPath
+}
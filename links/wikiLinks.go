@@ -0,0 +1,78 @@
+package links
+
+import (
+	"bytes"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// wikiLinkScheme marks links that were written in wiki link syntax.
+const wikiLinkScheme = "wiki:"
+
+// wikiLinkRegexp matches "[[Page Name]]" and "[[Page Name|label]]".
+var wikiLinkRegexp = regexp.MustCompile(`\[\[([^\]|]+)(?:\|([^\]]+))?\]\]`)
+
+// fenceRegexp matches lines opening or closing a fenced code block.
+var fenceRegexp = regexp.MustCompile("^ {0,3}(```|~~~)")
+
+// ReplaceWikiLinks turns wiki links into Markdown links, whose targets can
+// be recognized using WikiLinkTarget.
+// Code blocks and code spans are left untouched.
+func ReplaceWikiLinks(markdown []byte) []byte {
+	return mapOutsideCode(markdown, func(text []byte, atLineStart bool) []byte {
+		return wikiLinkRegexp.ReplaceAllFunc(text, func(match []byte) []byte {
+			var target, label = parseWikiLink(match)
+			return []byte("[" + label + "](" + wikiLinkScheme + url.PathEscape(target) + ")")
+		})
+	})
+}
+
+// parseWikiLink returns target and label of a wiki link.
+// When no label is given, the target is used as label.
+func parseWikiLink(wikiLink []byte) (target string, label string) {
+	var groups = wikiLinkRegexp.FindSubmatch(wikiLink)
+	target = strings.TrimSpace(string(groups[1]))
+	label = strings.TrimSpace(string(groups[2]))
+	if label == "" {
+		label = target
+	}
+	return
+}
+
+// mapOutsideCode applies the mapping to all parts of the Markdown text that
+// are no code blocks or code spans.
+// The mapping is told whether the part starts at the beginning of a line.
+func mapOutsideCode(markdown []byte, mapping func(text []byte, atLineStart bool) []byte) []byte {
+	var lines = bytes.SplitAfter(markdown, []byte("\n"))
+	var inFence = false
+	for i, line := range lines {
+		if fenceRegexp.Match(line) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		var parts = bytes.Split(line, []byte("`"))
+		for j := 0; j < len(parts); j += 2 {
+			parts[j] = mapping(parts[j], j == 0)
+		}
+		lines[i] = bytes.Join(parts, []byte("`"))
+	}
+	return bytes.Join(lines, nil)
+}
+
+// WikiLinkTarget returns the target page of a link created by
+// ReplaceWikiLinks.
+// The second result is false for all other links.
+func WikiLinkTarget(link string) (string, bool) {
+	if !strings.HasPrefix(link, wikiLinkScheme) {
+		return "", false
+	}
+	target, err := url.PathUnescape(strings.TrimPrefix(link, wikiLinkScheme))
+	if err != nil {
+		return "", false
+	}
+	return target, true
+}
@@ -9,4 +9,12 @@ var AllFileNames = []string{
 	"/js/ace/ace.js",
 	"/js/ace/mode-css.js",
 	"/js/editor.js",
+	"/history.html",
+	"/diff.html",
+	"/conflict.html",
+	"/search.html",
+	"/listing.html",
+	"/move.html",
+	"/login.html",
+	"/trash.html",
 }
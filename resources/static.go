@@ -186,23 +186,85 @@ func FSMustString(useLocal bool, name string) string {
 
 var _escData = map[string]*_escFile{
 
+	"/conflict.html": {
+		local:   "static/conflict.html",
+		size:    1829,
+		modtime: 1792314334,
+		compressed: `
+H4sIAAAAAAAC/4xU72/bNhD9LP0VVw1Dt04W42YZEkcWkDoFGiBdgsTFNhT9QIsnk6hECiRtxRD0vw+k
+JNcOgrZf9OP43jvy7vHSV9d3i+V/9++B26qE+0/vbm8WEE0I+ed0Qcj18hr+/bD8eAvT5ASWmkojrFCS
+loS8/zsKI25tPSOkaZqkOU2UXpPlA3lyWlNHHj4n9oCZMMuiLAxTn/GpKqWZv6Azvbi46Ok9GCnLwiC1
+wpaYLZQsSpFbUBLaNqmp5V2Xkn4xDIPU2F2JYHc1ziOLT5bkxkRZGATkDaSvPi+ur5ZXn+ENCYNgpdgO
+2jAIgkJJOyloJcrdDBa0FCstYlhQyaimMTziWmEMr/0bPt28juGutqKiMVxpQcsYDJVmYlCL4jIMgi4M
+giRX0qK0fYKK6rWQM5gmZ1iNED6Ngb+NgZ/GwP+MgZ/FwP86JExKLOwMJieHtIRv5NcX9l0pqUxNc3TA
+oOHC4sT/z6DWOGk0rS8PpFfKWlXNYPpcOXEVR91nyFWp9Ax+OT8/f4aijCHrQSuaf11rtZFsMuJZwZ7h
+NVZq+x1GwfYM1zeqkfbYRjDLZzA9Ofn18vuH7vo2f/mS+QanxJshC1PSmyhMXcudmZjYQl5SY+bR0Cfv
+kZRPjxyWUuAai3m0t1qUHbiOZinhU0+s3TN4VBUqiYClQcg5lWtkYLkwUIgSoeHuuVMbaFAjIBNWyDUI
+mzjyOyxVA1QjWI7ARFGgRpmjgRXaBlG6uNCwRW2EkvBbaqxWcp1NUjJ8/e50qGQuhzbfEH98Q/hUH1Gv
+cZDrt2lASKs8b0wQO4AEQ7cIdE2FdNSU1O6aBW2rHQ18c03nKn9YVBf1FT2OemP5En7wn66ITGw9cK94
+KySarjsktq0oILkxV85zXeet17a+yv3CQ2+urhtc1rYo2dCte42FeOq6tk2W+GTHnAPEn2nYwxhysULp
+Ciq0XLF5dH/3uIyA5m6OHZnBn1DIemOHkcMFYygjkLTCeeQ6HMGWlpv9D/kRZ0UNcmr4nte24yxx4a77
+CY0B75ZekHHhQcbr7K/bETkCrRozj96e+SoOUT9sB/xA761/vB2zWVXutL2is9B+H4/+h/wU6UqyB7Qb
+LY/Y4Bw+xkehF27qtTA51Qyq3Whyd2XDYHCxe7kuZ+HogJT088ENDFuVWfj/AH0aScQlBwAA
+`,
+	},
+
+	"/diff.html": {
+		local:   "static/diff.html",
+		size:    1203,
+		modtime: 1792314266,
+		compressed: `
+H4sIAAAAAAAC/3xSYU/zNhD+nPyKezNpr4SS+M0YE5Q0U9cygcQGYkHbhPjg1pfaIrEj29BWUf77ZCdU
+GULvl9a5e5577u65/Mvqbln+e38F3DY13D/+dnuzhCgh5O/TJSGrcgX/XJd/3EKWfoNSU2mEFUrSmpCr
+P6Mw4ta2M0J2u126O02V3pLygexdrcyRx2diJ8yUWRYVYZh7xX1TSzP/pE52cXEx0AcwUlaEQW6FrbFY
+ciq3aEBV0HVpSy3v+5wMuTAMcmMPNYI9tDiPLO4t2RgTFWEQkBPIvzwtV4ty8QQnJAyCtWIH6MIgCCol
+bVLRRtSHGSxpLdZaxLCkklFNY/gLtwpj+Or/4fHmawx3rRUNjWGhBa1jMFSaxKAW1WUYBH0YBOlGSYvS
+DgIN1VshZ5ClZ9i8Q3gWA/8pBn4aA/85Bn4WA/9lSkhqrOwMkm9TWspf5csnfTdKKtPSDTpgsOPCYuK/
+Z9BqTHaatpeT0mtlrWpmkH2snLqFox4UNqpWegY/nJ+ff0BRxpANoDXdvGy1epUsecezin3Aa2zU23cY
+FTsyyAk8PxfepJx4Q4swJ8MdhLmzzd0DE2+wqakx82jctfc559n0SHIKXGM1j47XEhWTw6FFTnjmea37
+DX7XqoHcWK3k1gErrRoHHCNg1TRr1STn6D9KRg2//ET2Vy6MVfoQFdfDw4k7XeKFu067nsFvy/RuDdMJ
+XdSP9/+od8oPdO2frhsm3jzwWPFWSDR9PyV2naggvTELZ2Lfey+7DmuDMCQeBrf6frSt61CycXf3Giux
+7/uuS0vc23fNEeJHGnsYSg6xtig5agSqEaQCJqoKNcoNmvS4grHAyM/JYLXz3jZ1Ef43AJzu/yizBAAA
+`,
+	},
+
 	"/editor.html": {
 		local:   "static/editor.html",
-		size:    1523,
-		modtime: 1459710177,
+		size:    1591,
+		modtime: 1792322185,
 		compressed: `
-H4sIAAAJbogA/6RUf2vbOhT924V+B1Xvv0Ks5PU9aDPHpUsKG3RraTO2UUpRLCV2Z0tGuvnRBX/36Yft
-uCUdgwWSXF3pnnt0zrWjo8n1ePr95hKlUOTo5sv7q49jhHuEfD0ZEzKZTtC3D9NPV2gQ9tFUUaEzyKSg
-OSGXn/HhAU4ByiEh6/U6XJ+EUi3I9JZsLNjAVtdhDzqlIQOG48ODw4PINd0UudCjPUiDs7MzD9Ac55SZ
-KIggg5zH221YUkirKiI+YQ8FkYbnnCN4LvkIA98ASbS2AEFAjlF0dD+eXEwv7tExsSmWrUKa8B5nGUiF
-tsgmg1J6skNEZ1rmS+DvXB5kOUR9H6tskUK7mkkAWQzRf/1y4zM5n++2f/Yywfhm+L9fV/YnTKQAJXON
-tr9tmnLfaQfdNOvv6fSSVtMXDfoNUZr8WCi5FKyXyFyqIfqHc76PVajk2lND5lNQtcgMt9OaRFUL+vAQ
-eykj4oQ3QkekNsqEM8menWVzqQqUsRGeq8KpjVHBIZUmc3N9N8WIJvbyI9y66j2LMlEuobYzzRjjAiNB
-C7PyKCuaL9sF6dZ0uz0+mlzP3o0LsGB4L2T3gJMrqOENq86eIedauWZ2xqji9O1+r9AxMsqaif+3X/eI
-d+humGtAfxczoa+QEwH1vGKU5FQbqN0I4zgipqQht7+68bitbxNx47errHcNXbth08ErR/RyVmTtBTVd
-8daRO7cgf1x4Idgth6USLxAQFQw1+Q7YdpvNUWivVFVtMogoShWfd6bonPGcA8fxxP1HhHYxuGCVm+Sg
-Ua0TRcQOrVPSKaITlZWAtEpGmDxpYkS33/BJnwMvypxCO1XuvfNEV9TXGJ1TqjSHEV7CvHdqTfI78RvY
-3sy/hY5I/fy5h9K8SE34KwAA//9YrgAz8wUAAA==
+H4sIAAAAAAAC/6RUXW/zNBS+TqX+h/Oau0mNW16Q3pUk02gngTTYtBUBmqbJjd3GI7Ej+/RjRPnvyM5H
+u9IBEhdb7eec8zzny4k+ze9mi9/vbyDDIof7X76//XEGZETpr59nlM4Xc/jth8VPtzAJx7AwTFmJUiuW
+U3rzMxkOSIZYTind7Xbh7nOozZouHujekU1cdHsc4VFoyJGTZDgYDiIvui9yZeMzTJPLy8uGoHMXjCfD
+QRChxFwkVRWWDLO6jmgDOKcgsviWC8C3UsQExR5paq0jCAJ6AdGnp9n8enH9BBfUQVxuQ5aKkeAStYEK
+HBiUukl2Cmxpdb5B8Z3HUZdTGDdnI9cZ9relRtTFFL4Zl/sGycXqYP5zJBUX++m3zb12/8JUKzQ6t1D9
+o2gmGqUDdSc2PqP0Pq1OFybjLlGW/rE2eqP4KNW5NlP4SghxLqvQ6F2TGgBAwcxaqil8KfcHZ3oBz89J
+08qI+sYnw0FE20ENB9FS8zc/spU2BUgek5UpfLcJFAIzzWNyf/e4IMBSV3xM+qk2M4ukKjfYjjOTnAtF
+QLFCxKRh2bJ801/ov8UsmRUZs1kfV1W+YqHQwXV9wnGc8cuLVOWo9Xbk5KzEsYNvefA3KWfrpLyY21Nm
+BPtY74SdgNE7G5Ovx61GcmD3D6IlbGrhcnvCnCpsd55AmjNrY3J4BiSJKJfbLrnz0d2e9PE9kHQ74yNb
+q9E7Z3BwcDIhu1kWsi/Qsq3op/PoL/Q/B14r/iBwY9Q7BmCKQ4cfkVWVXEHoSqrrHgwiBpkRq6NNvOIi
+FyhIMve/EWXHHELx2r+GoOva0SmibvF9J31HbGpkiWBNGhP6ailLhfsLX+0ViqLMGfZb5b9dr2zLmhgC
+acaMFRiTDa5GX9yQGkvyAXczzP9LHdH2Dbuj+xgnw8FfAwDvKq63NwYAAA==
+`,
+	},
+
+	"/history.html": {
+		local:   "static/history.html",
+		size:    1321,
+		modtime: 1792314266,
+		compressed: `
+H4sIAAAAAAAC/3xS72/bNhD9LP0VV2FYgUASpaQpEJXWkNkdGqBdi0TdDxT9QFsnkwBFCiRrxxP0vw+U
+bE/Zmn3SiXfv3d17R1+sPi6rPz+9Be5aCZ8+//z+bglRQsjvV0tCVtUK/nhXfXgPeZpBZZiywgmtmCTk
+7a9RGHHnuoKQ/X6f7q9SbbakuiePniv34GOYuBkyrV0dlWFIx46PrVR28R2e/ObmZoJPxcjqMgyoE05i
++U5Yp80BdAN9n3bM8WGgZMqFYUCtO0gEd+hwETl8dGRjbVSGQUAugL74slzdVrdf4IKEQbDW9QH6MAiC
+RiuXNKwV8lDAkkmxNiKGJVM1MyyGB9xqjOHl+IXPdy9j+Ng50bIYbo1gMgbLlE0sGtG8CYNgCIMg3Wjl
+ULmpQcvMVqgC8vQa21MJz2PglzHwqxj4qxj4dQz89RyQSGxcAUk2hzm2ljhVrbWp0SQbLSXrLBZwis61
+PAZXT8Udq2uhtgVk6SW2kGN7jDJfHXixEibFVhXg254oyAV8/VqOilEyqluGlEymhNRr6M2pxQ42klm7
+iI6Lj6JTns8dowy4wWYRna2LypmLrKSE5x7X96KB1OBOWKGVHfwgdFzcZwPqzPgNqOPlPXaSbbAG5ihx
+/DuJ9eFp4kH8hU9fzn+UHKn73jC1xX8PMW9d+9kr0WL6izYtcxBdZtnrJMuT7BLy6yJ7VWTX8OGhisYT
+rec4v97tN8e1GYa+n4UoLQ4DxbZkSqtDq79ZSrAt+x5V/V+e1O8yDLA+OLRPk2MQzCT/4Sj0T3xy5EfW
+dm9O6y36Pr1beUN+E7j3XjyPr0XTjODG6PYf4Eo0zf8DDfrG+Ezj+yl7pjivMzNlFCEcn07XcNLMv3Zl
+xdEgMIOgNDTatGhgh2Z00J+g48JCIySmlHRlOOOkpBY7f9vTTfsjd60sw78HAE3Fjo0pBQAA
 `,
 	},
 
@@ -2461,37 +2523,147 @@ UrpYtLqOZHStHAkFEOXt5w8f5lgcHbnAW2Wv93hH7M5lj2W6pV2W5VcTwc7jDfDkjth96P4fAAD//4zv
 
 	"/js/editor.js": {
 		local:   "static/js/editor.js",
-		size:    2224,
-		modtime: 1459710812,
+		size:    3949,
+		modtime: 1792322196,
+		compressed: `
+H4sIAAAAAAAC/7xXUVPbPhJ/NjN8h/0zN8iZgNPeI1yO4YBemYGGaeHuZtpORtjrRNSWdJIcyBS++83K
+suMkptf24c8Djle7v139drVe7e4suIF/KokXmXDKwBgkPsZ5JVMnlIwH8H13Z3cnIq2pUcrBGNxc2OPd
+HQAAL8YCS5TOwhi+12L6S5V0KN0RyKooDlZy9H5acRQFxdulxi3lXJmyFtayl+MmFlHyGb4TBX7gJd5w
+59BIGMPoSxJrOXt+0Hgye56J/NkuZs+PeK8HfxmJ1rpUGdobNGcr33X0UbT3wBfcpkZot3cE3Tcf7Z7D
+Jzeau7KgVf/syFNrSUyP3Z2Ioq3jJsoSIQXRt85tFE1Jfnp2EQ+OVxuf3guZ3V1eLIjYduUlPDvk50Jm
+F6sErIO3aE2KkkA2jCFTaUWyZIYuAPxjeZnFLDflISVpOhVSHwYDRiFE0RZQYO7XwNxSI1vbbQtLCf8Z
+PDY4hhUnTVpTpZd1HTeJVbf45LhB3svN9n6SBS8q2tFqpS5YiuQTWuvt6eVfpOgzE/WkhXLanqifcmzd
+ssAkE1YXfAljYFJJZGvI0WZQMAaeon+J91ZUp9Id1gp7g+NXTBOL7naOJcZ7PMWRo5+jdG5UiT8wWiPB
+NiS8wmIoGZ49VDaQca0ybKs52vJC2U94lvmyvxLWoUQTM1vdl8Kxgx8mOHgj4u90oXi2dmrattEu95zE
+0QjeX364PYKUa1cZBDdHQH8E4R5zZRBOzy5gzmVWoKXVErgFOvmQGaXt+hEJnBEnXEg0PTvLDJ+pBRra
+m5Lnhs8mCzQH4ExL36/BKd1AKd2B6VKw8tOlwG8z8CByiKfBj6Uea8NyWI/8W6KNf55jzqvC1UehWbNO
+6RujNJ/xmmG/+NITitJ/ehjRtKorpFbKuOO3hkubo0ly8tMf7VoorwVNmtTfSGEbHvb3e6SJN/A+DbrK
+yICwvw+nxvBloo1yimSJkBk+TfIk5UURe60DYD4gNoC/j+HNZrarrWKvd7iKliKpP/vwTpnynDseeMqV
+gZhUBBAyCPgbeOukQDlz82MQw2GTDIJJuNYoqU2LAtlBrfxZfG35bHwa/G+F1gW3/7m+eu+c/lgLg/Og
+kiiNMmY3k0+3VNnrrWKG7tQ5I+4rhzHjfn9sAENgJ/W+2QaWfOXk+1prtKzjrrLwx3gMf33zttGIeIHG
+xSx0j5yLArMjYDCEDcMhsB4x9alQfiHL9cuL/0+kFCr1VUql09gatFpJi2SdWF0IF7Mvkg2oTh2a1aDW
+GLfh1j5aUL8dxoLP5hxsNhchLW2yjSQpuY6nJTffMvUor4T8NkgelJB1ECGtaxRbyj/Vwlbf6aJ0U7AR
+OelKXtI3uFlJbHVvnRFy1ionBbfusj4MMRv5pL8dHDcA2mAunmDcPycmjsqMnAzgBNgfDI4aagJrwX4I
+7DNl0sczBPY1pjeUqcrw7uNlJ/QhsAHb3PHml6+n8Noj1p2nhOydULu12reezLmdPErqd2jcMk67pvv7
+3jaKph3xWehoa6ptBf3fr7//mjMaHyiaEYNh8BH1hfe54+TroFP8fX12I8Auc03vCqnqHUnrAaTplrHt
+741+GLjsG8m7U3XcmSzqXMad2m4nvu7A/uog3s6129/u88l1IOtK8Qwz6nZ1gK/NMTSL9A4xt5PzyRFc
+ltqoBfq7B3yaXF+8n/y7BopoaEyVzMUspLEq8M4UPaPgAeyNHuyolR/W8uTBnjgsdcFdZ1z8Iawvke5t
+aoVMS4erpd8Fry9jG7Ak/F1AusZt4qXWbsF1LgAvA18cqwu1v/V52e7O/wYAOauOK20PAAA=
+`,
+	},
+
+	"/listing.html": {
+		local:   "static/listing.html",
+		size:    1412,
+		modtime: 1792321102,
+		compressed: `
+H4sIAAAAAAAC/4xTXW/bNhR9ln7FrV4KBBIpJU2BeIyGzO6wAE0bLCq2oegDbV6ZBPQF8qKJK+i/D5Rs
+R866rU+kiPNxeY4oXq0+Lou/7t+BprqC+0+/vL9dQpRw/sfFkvNVsYI/fyvu3kPGUiisbJwh0zay4vzd
+hyiMNFG34Pzx8ZE9XrDWbnnxO3/yWpkn77cJzZhMkYryMBSj41NdNe76OzrZ1dXVRJ/AKFUeBoIMVZj3
+Pesk6WEQfDoIw0A42lUItOvwOiJ8Ir5xLsrDIOBnIF59Xq5uipvPcMbDIFi3agd9GARB2TaUlLI21W4B
+S1mZtTUxLGWjpJUxPOC2xRhejyt8un0dw8eOTC1juLFGVjE42bjEoTXlT2EQDGEQsE3bEDY0GdTSbk2z
+gIxdYn2A6CwGfR6DvohBv4lBX8ag384JSYUlLSBJ5zSS6won1Lq1Cm2yaatKdg4XcNgdsToGUhO4k0qZ
+ZruAlJ1jDRnW+13q0YEPK5GV2TYL8LZHCcWc+bY3nIOs2eojip/Bly/5mKvgYwd5KPjUVyh80r43Zb7C
+ppLOXUf7eMZqhM7y5fTtoC1hVqzORsB4Z78LBNlxDQTp/IOsUXDSzycP5tuLk7tWmdKgOj0tdt0zTvC9
+aN+bElgnLTZ0P07wwlLlQoK2WF5HfX8CjHLGBJe54KRm6B/5mtljoybPvrey2SIwbMgadP8zyWGGvmc+
+k2GYbnLrVsbihlq7Gwa+l//HlIdKfM1ewpTQtPSC3ffMZzsMsN4RuqPW/EZ9z+5aVZga2a+trSVBdJ6m
+b5M0S9JzyC4X6ZtFegl3D0X0Paqp0dcyDP+VjODHf+F50nlIossLbRyow+xgHGDd0Y4J3uXhiVg32Zx0
+6pP82aG0Gx3lD+MKdKLoE/wXHlnpdJSvsEJCBaWp0O3hk7vgynz1b2N6E/6RUF3l4d8DAHv4yvmEBQAA
+`,
+	},
+
+	"/login.html": {
+		local:   "static/login.html",
+		size:    1120,
+		modtime: 1792318171,
+		compressed: `
+H4sIAAAAAAAC/3xSUU/bPBR9tn/Fxd8DEkrj9mMgkbmZWIs0JDbQKNomxIObOLU1x45sl1JV/e+Tkwby
+MPaUe+Nzzj0+vuxofjtb/Lq7AhlqDXcPn2+uZ0BGlP44nVE6X8zh55fF1xuYpGNYOG68Csoarim9+kYw
+kSE0GaWbzSbdnKbWrejiO32JWpNIPpSjMGCmZShJjjFrJ77U2vjpX3QmFxcXHb0DC17mGLGgghb5jV0p
+w2jXYIyYD1stIGwbMSVBvARaeE9yjBA9AXb0OJtfLi4f4YRihJa23MIOI4Qqa8Ko4rXS2wxmXKulUwnM
+uCm54wnci5UVCRy3X3i4Pk7gtgmq5glcOsV1Ap4bP/LCqeojRmiPEUoLa4IwoRtQc7dSJoNJeibqHiIn
+Ccj/E5CnCcgPCcizBOT5kDDSogoZjMZDmuZLoTtUqXyj+TYDZbQyYrTUtvgdcWijyiAzOH+jpcI56zpe
+YbV1GfxXjMf9MT2Bp6e8DYbRNsQcM9qFjVmMKoZeqmcoNPd+Sg73a7NlctK/hJzEH7udqiCtuNKi3Ed5
+1vS81gbJ761z2wSC5AHWXjgwvBZgHTTc+411JSgPG2fNKmW06TSFOYhV1tVQiyBtOSWN9YEAL+JSTclu
+lzY8yP3+k46GWnuItQIIsS67yropiUNJ/tCPzhhtDw9AZZp1GKwRAVUeOC28r5+5Xot2auz3ewJ8HWxl
+i7WfkteSAO180OZdP/29SX53qP5h6RXc2nrrOmtv/Ttjh1J+vaxVeL1J+4w9ETEO0olqECvJZ9wUQjPK
+h9qMxjeJK0JL9RxXp1uZuEOh1jn+MwCl2tBoYAQAAA==
+`,
+	},
+
+	"/move.html": {
+		local:   "static/move.html",
+		size:    1210,
+		modtime: 1792317349,
+		compressed: `
+H4sIAAAAAAAC/3xSW2vjOhB+ln/FVC+F4lh1c3qgOYoPPUnhFHpjN2V3KX1QbDkSlSVjT26E/PfFspN1
+yrJPnijzzXeZ4WfT58nsx8sdKCwMvLz+93A/ATpg7Ntwwth0NoXv/88eHyCOLmFWCVtr1M4Kw9jdEw2o
+QixHjK3X62g9jFy1YLMvbNPMihtwVw6wh4wyzGgSBNwzbgpj6/Fv5sQ3NzctvG2WIksCwlGjkcmjW0nY
+7aJSoNrvOWtfg4DwGrdGAm5LOaYoN8jSuqZJQAi7AH72Npnezm7f4IIFhMxdtoVdQAjJncVBLgpttiOY
+CKPnlQ5hImwmKhHCV7lwMoRz/4XX+/MQnkvUhQjhttLChFALWw9qWen8n4CQfUBIlDqL0mJLUIhqoe0I
+4uhaFocWFYegrkJQwxDUXyGo6xDU333AwMgcRzC47MO0LZf45h02Bt9bwFpnqEYwvPzVyC7g/T3xXjnz
+uSQBZ22QAW/cN4FmegWpEXU9pp1kHxdXcZsyF6AqmY/pMW6a9JIXCWcq9ojcVQUUEpXLxrR0NVIQabPz
+Hvbfwq2kJyC89B/CjZhLA7mrxhQdTZ7kGprmEWf+n67L2+4tloLOPACsKGRbrYRZyr5SEEt0uUuX9Zge
+SwqsFcDKUyF9ilTJ9GPuNi1NJdeVRmm0/agPhKdvHXVMwSNl1o2Q2YHuxOgJOJkoYRcS/C/QFhwqWUEp
+FrIGdFA6bbEpUEmwXTz9dP7opF7OC41Hhc1SPyXAWbO6ptrtdA7RXKQfXsu+OSOurpIXL6V503YBSlaS
+M3XlsctWwm5XeQ+fwIQbnfRPqDufw+kY3aGlzVo21g48vnCW6VVzuO3BNheMhUmCnwMAPl3WlLoEAAA=
+`,
+	},
+
+	"/search.html": {
+		local:   "static/search.html",
+		size:    1273,
+		modtime: 1792315300,
+		compressed: `
+H4sIAAAAAAAC/2xSYW/bNhD9TP6KKwesQCCLUTMPiEsLyJwCLdC1weJiG4p+oCXKJEqRGkklNgT994GS
+7MhbP4nivffu+N6xV/efN9u/H96BDLWGhy+/ffywAbKg9M+bDaX323v46/3294+Qpdewddx4FZQ1XFP6
+7hPBRIbQrCh9fn5On29S6/Z0+wc9RK0skqfjIsyYaRlKkmPMho6HWhu//oFOdnt7O9JHsOBljhELKmiR
+PwruCgldlzY8yL5ndLzHGDEfjlpAODZiTYI4BFp4T3KMEL0C9urr5v5ue/cVrihGaGfLI3QYIVRZExYV
+r5U+rmDDtdo5lcCGm5I7nsCj2FuRwOvhC18+vE7gcxNUzRO4c4rrBDw3fuGFU9VbjFCPEUoLa4IwYWxQ
+c7dXZgVZuhT1CSKzBOSbBORNAvKXBOQyAfnrnLDQogorWFzPaVaPkIaXpTL7CZO9ALS60NjZEGx9gUi9
+UU0j/jPcdfpG1HAdQaiw2roV/LRcLk+kmrvvI2HHi+97Z1tTLk64qrqd85SRwqlwotIr+PYtH1xndEgo
+x4yOoWIWc4jhluoJCs29X5PJvCE4JrNT4oyDdKJak3P0JJ9tAc8ZldnAqayrgRdx5+ZoqEWQtlyTvRjF
+EVOmacNsYQgYXos18UNLAk9ct2LQ+KcV7hhFeBtsZYvWr8n5SID+X8+3u1qFs8bjJDkgGY0zxlPXqQpS
+J3yrg++jX8zqQazrHDd7cVlETKuhiuZ2PLzY8XC2A6O5yOMY+qRy4fe0DyT/WQqtVfP2TOr7cbz3KvQ9
+izsQW2zFIf7S6V9oL/r+fN91wpTnz0mS0VI9nUYaKsNj6PSalztGx+ePuhC7T9bHYpN/slApLTzUPBQS
+jrZ1MKaVMtrkeKY19WR03LG4dKHWOf53AFfCd6f5BAAA
+`,
+	},
+
+	"/trash.html": {
+		local:   "static/trash.html",
+		size:    1318,
+		modtime: 1792321102,
 		compressed: `
-H4sIAAAJbogA/5xWT2+bThA9Y8nfYeVDIPrF8DvHSqs2sVpLiYgUpz1UlUVgibFgF+0uTqzI370zywJr
-Q9I0HEJ4O/NmePMHj0fbSJBvnNF5kikuyAVh9MlLKxarjDPvlLyMR+ORg1YrwbkCA7XO5Gw8InBpmOa0
-oExJOHqpYbxizhSg54RVeX7W4VTHaWHHMYbLXUl7xikXRQ3W2H7W5FLwhMpbKi477zq+40w20TaSschK
-NTkn9pOON1H0WQVrVeR4qu8WHkuJMN4AxHh1ZHxpP2MZCnCojuOsEP9yOfdOZ13qq4eMJfeL+RalaU/2
-5m7Jl4LdvJPwkLxla0T2jVxgmfC4Qsx/pMoQfN0tEs9NRTFFmVeQWDk1Di6mALkeExnl/o1MgZN78LYt
-LZbsPXzgTjpNmrLGvNzVndgUli+hLJGg0aA2/ffxt1Fe4Rt1J3XLYSZ3VErtjw8/0FBXxhkoC9a0nYl3
-BZZql1M/yWSZRztwchmMlXvA7BwnBWZRTPWDN+mkjpma1gaTRuWeqy+pWq4B8iZAESj8N4jXghf0DacD
-EWQjwisqmpaJkk0ljRg3MHdtNzu9KFh9P0oS3fbXmQQuKjxXVg8FlPzszQLbM9L0w3HogemDkMRDY7uh
-Mza4IoyLk6XEGzr315EMn9it4CUVaufFtuvJifYFQSwYnaOMyQPTJszf5ddyulg/zCZwyX8mhjOU3i8r
-yO+6OM4e/+7bXdWMUS9BWzlpEhRUVYL1l4uWQncArLyEPocpuny6IP/PjgLhlNwvhnaivdY800jdTHVj
-Z42cvTFf3YTtYul32VV4Y8S65lFCE+y3OsGh1jJLeyD3ICDL8Co8J4uiFHxL9fInd+HN/Hv407Q8Ti2o
-lWaPpoxVTu9FPjCLZ2QSbGTQ4tMa9zfys6IF7AplzeubtLpF7M9Zx4xH0+7oo+T11/CIFsGPEuJ39JgP
-sB6dtYH3p7o5ut8k+rOrsfHoTwAAAP//xfVaGrAIAAA=
+H4sIAAAAAAAC/3RSYW/bNhD9LP2KqzCsQCCJUtIUiEtrSO0WC9CuQaNiG4p+oM2zSYAiBZJpogn67wMl
+21Pa7JNOx/fu7t07+mL9aVX/ffsOhG8U3H55++FmBUlGyJ8XK0LW9Rr++r3++AHKvIDaMu2kl0YzRci7
+P5I4Ed63C0IeHh7yh4vc2D2pP5PHUKsM5EOY+Rkz554nVRzTseNjo7RbPlOnvLq6mugTGBmv4oh66RVW
+a1TokcNOKnQgNfR93jIvhoGSCRHHEXW+Uwi+a3GZeHz0ZOtcUsVRRM6Avvi6Wl/X11/hjMRRtDG8gz6O
+omhntM92rJGqW8CKKbmxMoUV05xZlsId7g2m8HL8wpeblyl8ar1sWArXVjKVgmPaZQ6t3L2Jo2iIoyjf
+Gu1R+6lBw+xe6gWU+SU2R4goUxDnKYiLFMSrFMRlCuL1nJAp3PkFZMWc5tlG4YTaGMvRZlujFGsdLuAY
+nbAiBc8ncMs4l3q/gCI/xwZKbA5REdBRWFbGlNzrBYS2xxLkDL59q8aNUTJut4opmayJadhhsIjL77BV
+zLllchA+Lp2K8mffKANhcbdMTgYm1cxLVlEiysDue7mDXHps3BBGoaP08BJRb8dvRL2o3kuFlHjxX+bY
+k/nn85vuaf5O/vNDhdMfJYdWfW+Z3uN8oPkYPGi4Pd4jf5KuZYP5e2Mb5iE5L4rXWVFmxTmUl4vi1aK4
+hI93dfIzL6g/TPy2G4a+f/qHyuEwUGwqpo3uGnPvKMGm6nvU/JkpgshhgE3n0T19HINo5ssvBzd+u9d8
+bPkra9o3Qfiy7/ObdbDsMzpvLAbD/p/f3tv9c+RJCOyMhb0x/FTkNNds7aOaeEwd/T+KD9m2qgVaBGYR
+tAH+47l5IR1waXHrje1yStoqnpWlhMvv4aSnUw637RtVxf8OAEVpp7cmBQAA
 `,
 	},
 
 	"/viewer.html": {
 		local:   "static/viewer.html",
-		size:    533,
-		modtime: 1449873698,
+		size:    848,
+		modtime: 1792316388,
 		compressed: `
-H4sIAAAJbogA/2xQXWvbQBB89v2KjV4CQdLGdVOwexG4cqCBtAmtQltCHi7W2To4faBbKguj/949yQU/
-5GHQsjczqxl5sXlMsz9Pd1BQaeHp+cvDfQpBhPhrkSJusg38/pp9e4B5fA1ZqypnyNSVsoh33wMRFETN
-CrHrurhbxHW7x+wHHrzX3ItPY0RnyjinPEiEkOPFQ2krd/uOz3y5XE7yiaxVnoiZJENWJ8dj3CgqhkHi
-tBD85Ki3Gqhv9G1A+kC4dY61sxlegbx4STfrbP0CV8ibtzrv4cjDbFdXFO1UaWy/glRZ89aakIcqV60K
-4afe1zqEy/ELz/eXITw2ZEp+WrdG2RAcJ4ucbs3uM/sNjHjLnrqi6UCp2r2pVlzgjS7/U4p5CMUHxoLx
-kXHD+HQuiKze0Qqi63MZB3l9TcYIEse4iZA4VSOkD+Urys1f2FrluNbTn4wtcGe+zXRaDYP3YKp3mJTe
-igmJ+BcAAP//x9XRxhUCAAA=
+H4sIAAAAAAAC/2yRQU/cMBCFz/avGMIBCSXxBkolgtcSzSIViRbUBrUV4uBNnMTCcSLbZXcb5b9XiYFu
+q54mejPvm/gNPVjdZvmPuytoXKvg7v7DzXUGQUTIt9OMkFW+gu8f8083kMQLyA3XVjrZaa4Iufoc4KBx
+rk8J2Ww28eY07kxN8i9kO7GSyfzyGbk9Z1y6MmAY03njtlXaLv/DSc7Pz73dDwteMoyok04JNgxxz10z
+jpR4AWNErdspAW7Xi2XgxNaRwtqAYYTIMdCDh2x1mV8+wDHBCK27cgcDRghVnXZRxVupdilkXMm1kSFk
+XJfc8BC+iroTIRzNFe6vj0K47Z1seQiXRnIVguXaRlYYWV1ghEaMUFx02gnt/IKWm1rqFJL4TLSvI00S
+QnMSQnMaQvMuhOYshOb9viFSonIpRIt9G49baa3UtZ8sOtWZFA6LxeJt95oXT0rqJ/sXzHV9CicehNad
+KYXxWtJvwXZKlnBYFMXFWyJW/hIp2JYrJcwrnBzD4yObE6RkTpthSvxlMJ0ynS5UymcoFLd2GbwEMR9h
+GOLpmJmXxnGWZAV//njW9u1vnRmAaM/ueC0sTNoUQiOMSCnpffenmisaBsN1Lf4FI0SVZJRDY0S1DIYh
+HseAzYUSzihR8tUvdOktlHgoJaV8Zniv96JQ4l89xeBaxfDvAQBRjPBWUAMAAA==
 `,
 	},
 
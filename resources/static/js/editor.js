@@ -8,6 +8,7 @@ var GoneEditor = new(function() {
 		contentType: null,
         form: null
     };
+	var _imageFileNamePattern = /\.(png|jpe?g|gif|svg|webp)$/i;
 	var _modesPerContentType = {
 		"javascript": "javascript",
 		"text/html": "html",
@@ -38,8 +39,62 @@ var GoneEditor = new(function() {
 		_adjustEditorMode();
     	
     	_elements.form.addEventListener('submit', _copyEditorContentToTextarea);
+		_initUpload();
     };
 
+	var _initUpload = function() {
+		// HINT: capture the events before ACE handles them as text drops
+		_elements.editor.container.addEventListener('dragover', _onDragOver, true);
+		_elements.editor.container.addEventListener('drop', _onDrop, true);
+	};
+
+	var _onDragOver = function(event) {
+		if (_containsFiles(event)) {
+			event.preventDefault();
+			event.stopPropagation();
+		}
+	};
+
+	var _onDrop = function(event) {
+		if (_containsFiles(event)) {
+			event.preventDefault();
+			event.stopPropagation();
+			_upload(event.dataTransfer.files);
+		}
+	};
+
+	var _containsFiles = function(event) {
+		var types = event.dataTransfer && event.dataTransfer.types;
+		return types && Array.prototype.indexOf.call(types, 'Files') >= 0;
+	};
+
+	var _upload = function(files) {
+		var data = new FormData();
+		for (var i = 0; i < files.length; i++) {
+			data.append('file', files[i]);
+		}
+
+		var request = new XMLHttpRequest();
+		request.open('POST', _elements.form.getAttribute('action') + '?upload');
+		request.onload = function() {
+			if (request.status !== 201) {
+				alert('Upload failed: ' + request.status + ' ' + request.statusText);
+				return;
+			}
+			var locations = request.responseText.split('\n').filter(function(location) {
+				return location !== '';
+			});
+			_elements.editor.insert(locations.map(_markdownLink).join('\n'));
+		};
+		request.send(data);
+	};
+
+	var _markdownLink = function(location) {
+		var name = location.substring(location.lastIndexOf('/') + 1);
+		var prefix = _imageFileNamePattern.test(name) ? '!' : '';
+		return prefix + '[' + name + '](' + encodeURI(location) + ')';
+	};
+
 	var _adjustEditorMode = function() {
 		for (var contentType in _modesPerContentType) {
 			if (_modesPerContentType.hasOwnProperty(contentType) &&
@@ -0,0 +1,7 @@
+// Package search implements the text matching behind the full-text search:
+// interpreting queries, scoring texts and extracting snippets showing the
+// hits.
+//
+// It doesn't know where texts come from; finding and reading them is up to
+// the store.
+package search
@@ -0,0 +1,275 @@
+package search
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// indexFormatVersion changes whenever persisted indexes become incompatible.
+const indexFormatVersion = 2
+
+// Index is an inverted index, mapping tokens to the documents containing
+// them.
+// Documents are identified by their path, whose base name is indexed along
+// with the content.
+// The index also keeps the paths each document links to, allowing to find
+// backlinks.
+//
+// Lookups return candidates only: they may contain documents that don't
+// actually match, but never miss a document that does.
+// Use a Matcher on each candidate's content to be sure.
+//
+// An Index is safe for concurrent use.
+type Index struct {
+	mutex     sync.RWMutex
+	documents map[string]*document
+	postings  map[string]map[string]bool
+	backlinks map[string]map[string]bool
+}
+
+// document is what the index knows about each indexed file.
+type document struct {
+	ModTime time.Time
+	Size    int64
+	Tokens  []string
+	Links   []string
+}
+
+// persistedIndex is the format in which indexes are written.
+// Postings are not written, as they can be derived from the documents.
+type persistedIndex struct {
+	Version   int
+	Documents map[string]*document
+}
+
+// NewIndex creates an empty index.
+func NewIndex() *Index {
+	return &Index{
+		documents: make(map[string]*document),
+		postings:  make(map[string]map[string]bool),
+		backlinks: make(map[string]map[string]bool),
+	}
+}
+
+// ReadIndex reads an index previously written using Write.
+func ReadIndex(reader io.Reader) (*Index, error) {
+	var persisted persistedIndex
+	if err := gob.NewDecoder(reader).Decode(&persisted); err != nil {
+		return nil, fmt.Errorf("couldn't decode index: %s", err)
+	}
+	if persisted.Version != indexFormatVersion {
+		return nil, fmt.Errorf("index has format version %d, expected %d",
+			persisted.Version, indexFormatVersion)
+	}
+
+	var result = NewIndex()
+	for p, d := range persisted.Documents {
+		result.addDocument(p, d)
+	}
+	return result, nil
+}
+
+// Write writes the index in a format suitable for ReadIndex.
+func (i *Index) Write(writer io.Writer) error {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	var persisted = persistedIndex{indexFormatVersion, i.documents}
+	if err := gob.NewEncoder(writer).Encode(&persisted); err != nil {
+		return fmt.Errorf("couldn't encode index: %s", err)
+	}
+	return nil
+}
+
+// Add indexes the given document, replacing any previous version.
+// Modification time and size allow to tell whether the document is up to
+// date later on.
+// The links are the paths of all documents the document links to.
+func (i *Index) Add(p string, modTime time.Time, size int64, content string, links []string) {
+	var tokens = Tokens(path.Base(p) + " " + content)
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.removeDocument(p)
+	i.addDocument(p, &document{modTime, size, tokens, links})
+}
+
+// Remove removes the given document from the index.
+func (i *Index) Remove(p string) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.removeDocument(p)
+}
+
+// RemoveBelow removes all documents inside the given directory.
+func (i *Index) RemoveBelow(dir string) {
+	var prefix = strings.TrimSuffix(dir, "/") + "/"
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	for p := range i.documents {
+		if strings.HasPrefix(p, prefix) {
+			i.removeDocument(p)
+		}
+	}
+}
+
+// IsUpToDate returns true iff the given document is indexed with the given
+// modification time and size.
+func (i *Index) IsUpToDate(p string, modTime time.Time, size int64) bool {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	var d, ok = i.documents[p]
+	return ok && d.ModTime.Equal(modTime) && d.Size == size
+}
+
+// Paths lists all indexed documents in alphabetical order.
+func (i *Index) Paths() []string {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	var result = make([]string, 0, len(i.documents))
+	for p := range i.documents {
+		result = append(result, p)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Backlinks returns the documents linking to any of the given paths, in
+// alphabetical order.
+func (i *Index) Backlinks(targets ...string) []string {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	var sources = make(map[string]bool)
+	for _, target := range targets {
+		for p := range i.backlinks[target] {
+			sources[p] = true
+		}
+	}
+
+	var result = make([]string, 0, len(sources))
+	for p := range sources {
+		result = append(result, p)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Lookup returns the documents that possibly match the query, in
+// alphabetical order.
+func (i *Index) Lookup(query string) []string {
+	var tokens []string
+	for _, term := range Terms(query) {
+		var termTokens = Tokens(term)
+		if len(termTokens) == 0 {
+			// HINT: terms without letters and digits can't be looked up
+			return i.Paths()
+		}
+		tokens = append(tokens, termTokens...)
+	}
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	var candidates map[string]bool
+	for _, token := range tokens {
+		var found = i.documentsContaining(token)
+		if candidates == nil {
+			candidates = found
+			continue
+		}
+		for p := range candidates {
+			if !found[p] {
+				delete(candidates, p)
+			}
+		}
+	}
+
+	var result = make([]string, 0, len(candidates))
+	for p := range candidates {
+		result = append(result, p)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// documentsContaining finds all documents with a token containing the given
+// one.
+// As terms match parts of words, the complete vocabulary is scanned.
+func (i *Index) documentsContaining(token string) map[string]bool {
+	var result = make(map[string]bool)
+	for t, documents := range i.postings {
+		if strings.Contains(t, token) {
+			for p := range documents {
+				result[p] = true
+			}
+		}
+	}
+	return result
+}
+
+func (i *Index) addDocument(p string, d *document) {
+	i.documents[p] = d
+	for _, token := range d.Tokens {
+		if i.postings[token] == nil {
+			i.postings[token] = make(map[string]bool)
+		}
+		i.postings[token][p] = true
+	}
+	for _, target := range d.Links {
+		if i.backlinks[target] == nil {
+			i.backlinks[target] = make(map[string]bool)
+		}
+		i.backlinks[target][p] = true
+	}
+}
+
+func (i *Index) removeDocument(p string) {
+	var d, ok = i.documents[p]
+	if !ok {
+		return
+	}
+	delete(i.documents, p)
+	for _, token := range d.Tokens {
+		delete(i.postings[token], p)
+		if len(i.postings[token]) == 0 {
+			delete(i.postings, token)
+		}
+	}
+	for _, target := range d.Links {
+		delete(i.backlinks[target], p)
+		if len(i.backlinks[target]) == 0 {
+			delete(i.backlinks, target)
+		}
+	}
+}
+
+// Tokens splits the text into its distinct words, ignoring case.
+// Words are sequences of letters and digits.
+func Tokens(text string) []string {
+	var result []string
+	var seen = make(map[string]bool)
+	var words = strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if !seen[word] {
+			seen[word] = true
+			result = append(result, word)
+		}
+	}
+	return result
+}
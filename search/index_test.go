@@ -0,0 +1,97 @@
+package search
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLookupFindsPartsOfWordsAndNames(t *testing.T) {
+	var sut = NewIndex()
+	sut.Add("/wiki.md", time.Time{}, 0, "nothing", nil)
+	sut.Add("/dir/about.md", time.Time{}, 0, "All about wikis.", nil)
+	sut.Add("/other.md", time.Time{}, 0, "something else", nil)
+
+	var paths = sut.Lookup("WIKI")
+
+	if strings.Join(paths, ",") != "/dir/about.md,/wiki.md" {
+		t.Fatalf("expected /dir/about.md and /wiki.md, but got %v", paths)
+	}
+}
+
+func TestLookupRequiresAllTerms(t *testing.T) {
+	var sut = NewIndex()
+	sut.Add("/a.md", time.Time{}, 0, "gone wiki", nil)
+	sut.Add("/b.md", time.Time{}, 0, "gone", nil)
+
+	if paths := sut.Lookup("wiki gone"); strings.Join(paths, ",") != "/a.md" {
+		t.Fatalf("expected only /a.md, but got %v", paths)
+	}
+}
+
+func TestAddReplacesAndRemoveDeletes(t *testing.T) {
+	var sut = NewIndex()
+	sut.Add("/dir/a.md", time.Time{}, 0, "old", nil)
+	sut.Add("/dir/a.md", time.Time{}, 0, "new", nil)
+	sut.Add("/dir/b.md", time.Time{}, 0, "new", nil)
+	sut.Add("/c.md", time.Time{}, 0, "new", nil)
+
+	if paths := sut.Lookup("old"); len(paths) != 0 {
+		t.Fatalf("expected no match for replaced content, but got %v", paths)
+	}
+
+	sut.Remove("/c.md")
+	sut.RemoveBelow("/dir")
+	if paths := sut.Paths(); len(paths) != 0 {
+		t.Fatalf("expected empty index, but got %v", paths)
+	}
+}
+
+func TestWriteAndReadIndex(t *testing.T) {
+	var modTime = time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	var original = NewIndex()
+	original.Add("/a.md", modTime, 42, "gone wiki", nil)
+
+	var buf bytes.Buffer
+	if err := original.Write(&buf); err != nil {
+		t.Fatalf("failed to write index: %s", err)
+	}
+	sut, err := ReadIndex(&buf)
+	if err != nil {
+		t.Fatalf("failed to read index: %s", err)
+	}
+
+	if !sut.IsUpToDate("/a.md", modTime, 42) {
+		t.Fatalf("expected /a.md to be up to date")
+	}
+	if paths := sut.Lookup("wiki"); strings.Join(paths, ",") != "/a.md" {
+		t.Fatalf("expected /a.md, but got %v", paths)
+	}
+}
+
+func TestLookupReturnsAllDocumentsForTermsWithoutWords(t *testing.T) {
+	var sut = NewIndex()
+	sut.Add("/a.md", time.Time{}, 0, "a -> b", nil)
+	sut.Add("/b.md", time.Time{}, 0, "c", nil)
+
+	if paths := sut.Lookup("->"); len(paths) != 2 {
+		t.Fatalf("expected all documents, but got %v", paths)
+	}
+}
+
+func TestBacklinks(t *testing.T) {
+	var sut = NewIndex()
+	sut.Add("/a.md", time.Time{}, 0, "", []string{"/target", "/other"})
+	sut.Add("/b.md", time.Time{}, 0, "", []string{"/target.md"})
+	sut.Add("/c.md", time.Time{}, 0, "", []string{"/other"})
+
+	if paths := sut.Backlinks("/target", "/target.md"); strings.Join(paths, ",") != "/a.md,/b.md" {
+		t.Fatalf("expected /a.md and /b.md, but got %v", paths)
+	}
+
+	sut.Add("/a.md", time.Time{}, 0, "", nil)
+	if paths := sut.Backlinks("/target"); len(paths) != 0 {
+		t.Fatalf("expected no backlinks after update, but got %v", paths)
+	}
+}
@@ -0,0 +1,140 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// nameMatchWeight is how much more a hit in a file's name counts
+	// compared to a hit in its content.
+	nameMatchWeight = 10
+
+	// snippetContext is the number of bytes shown around each hit.
+	snippetContext = 60
+)
+
+// Fragment is a part of a Snippet, either being a hit or not.
+type Fragment struct {
+	Text string
+	Hit  bool
+}
+
+// Snippet is an excerpt of a text, consisting of fragments.
+type Snippet []Fragment
+
+// Terms splits the query into its terms, ignoring case and duplicates.
+func Terms(query string) []string {
+	var result []string
+	var seen = make(map[string]bool)
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		if !seen[term] {
+			seen[term] = true
+			result = append(result, term)
+		}
+	}
+	return result
+}
+
+// Matcher finds the terms of one query in texts.
+// Matching is case insensitive, and all terms must be found.
+type Matcher struct {
+	terms   []string
+	regexps []*regexp.Regexp
+	any     *regexp.Regexp
+}
+
+// NewMatcher creates a matcher for the given query.
+func NewMatcher(query string) *Matcher {
+	var terms = Terms(query)
+	var m = &Matcher{terms: terms, regexps: make([]*regexp.Regexp, len(terms))}
+	var quoted = make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+		m.regexps[i] = regexp.MustCompile("(?i)" + quoted[i])
+	}
+	m.any = regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+	return m
+}
+
+// IsEmpty returns true iff the query contains no terms.
+func (m *Matcher) IsEmpty() bool {
+	return len(m.terms) == 0
+}
+
+// Score tells how well a file with the given name and content matches the
+// query; higher is better.
+// The second result is false if any term can't be found.
+func (m *Matcher) Score(name string, content string) (int, bool) {
+	if m.IsEmpty() {
+		return 0, false
+	}
+
+	var score = 0
+	for _, r := range m.regexps {
+		var hits = len(r.FindAllStringIndex(content, -1)) +
+			nameMatchWeight*len(r.FindAllStringIndex(name, -1))
+		if hits == 0 {
+			return 0, false
+		}
+		score += hits
+	}
+	return score, true
+}
+
+// Snippets extracts up to max excerpts of the content around hits.
+func (m *Matcher) Snippets(content string, max int) []Snippet {
+	if m.IsEmpty() {
+		return nil
+	}
+
+	var result []Snippet
+	var end = 0
+	for _, hit := range m.any.FindAllStringIndex(content, -1) {
+		if len(result) >= max {
+			break
+		}
+		if hit[0] < end {
+			// HINT: already part of the previous snippet
+			continue
+		}
+		var from = runeStart(content, hit[0]-snippetContext)
+		end = runeStart(content, hit[1]+snippetContext)
+		result = append(result, m.snippet(content[from:end]))
+	}
+	return result
+}
+
+func (m *Matcher) snippet(excerpt string) Snippet {
+	excerpt = strings.Join(strings.Fields(excerpt), " ")
+
+	var result Snippet
+	var pos = 0
+	for _, hit := range m.any.FindAllStringIndex(excerpt, -1) {
+		if hit[0] > pos {
+			result = append(result, Fragment{excerpt[pos:hit[0]], false})
+		}
+		result = append(result, Fragment{excerpt[hit[0]:hit[1]], true})
+		pos = hit[1]
+	}
+	if pos < len(excerpt) {
+		result = append(result, Fragment{excerpt[pos:], false})
+	}
+	return result
+}
+
+// runeStart moves the given index into the text to the start of a rune,
+// staying inside the text's bounds.
+func runeStart(text string, i int) int {
+	if i <= 0 {
+		return 0
+	}
+	if i >= len(text) {
+		return len(text)
+	}
+	for i > 0 && !utf8.RuneStart(text[i]) {
+		i--
+	}
+	return i
+}
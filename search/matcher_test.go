@@ -0,0 +1,59 @@
+package search
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTermsIgnoresCaseAndDuplicates(t *testing.T) {
+	var terms = Terms("  Gone wiki GONE ")
+
+	if strings.Join(terms, ",") != "gone,wiki" {
+		t.Fatalf("expected terms gone,wiki, but got %v", terms)
+	}
+}
+
+func TestScoreRequiresAllTerms(t *testing.T) {
+	var sut = NewMatcher("gone wiki")
+
+	if _, ok := sut.Score("readme.md", "gone is a wiki"); !ok {
+		t.Fatalf("expected match")
+	}
+	if _, ok := sut.Score("readme.md", "gone is great"); ok {
+		t.Fatalf("expected no match")
+	}
+}
+
+func TestScorePrefersHitsInName(t *testing.T) {
+	var sut = NewMatcher("gone")
+
+	var inName, _ = sut.Score("gone.md", "some text")
+	var inContent, _ = sut.Score("readme.md", "gone gone")
+	if inName <= inContent {
+		t.Fatalf("expected score for name hit (%d) to be higher than for content hits (%d)",
+			inName, inContent)
+	}
+}
+
+func TestSnippetsHighlightHits(t *testing.T) {
+	var sut = NewMatcher("wiki")
+
+	var snippets = sut.Snippets("Gone is a\nWiki engine.", 3)
+
+	if len(snippets) != 1 {
+		t.Fatalf("expected 1 snippet, but got %d", len(snippets))
+	}
+	var s = snippets[0]
+	if len(s) != 3 || s[0].Text != "Gone is a " || !s[1].Hit || s[1].Text != "Wiki" || s[2].Text != " engine." {
+		t.Fatalf("unexpected snippet %v", s)
+	}
+}
+
+func TestSnippetsAreLimited(t *testing.T) {
+	var sut = NewMatcher("x")
+	var content = strings.Repeat("x"+strings.Repeat(" ", 2*snippetContext), 10)
+
+	if snippets := sut.Snippets(content, 3); len(snippets) != 3 {
+		t.Fatalf("expected 3 snippets, but got %d", len(snippets))
+	}
+}
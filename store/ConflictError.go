@@ -0,0 +1,18 @@
+package store
+
+// ConflictError is set when a conditional write finds the file's content
+// changed by someone else.
+type ConflictError string
+
+func NewConflictError(msg string) ConflictError {
+	return ConflictError(msg)
+}
+
+func (e ConflictError) Error() string {
+	return string(e)
+}
+
+func IsConflictError(e interface{}) bool {
+	_, ok := e.(ConflictError)
+	return ok
+}
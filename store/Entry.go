@@ -0,0 +1,25 @@
+package store
+
+import "time"
+
+// Entry describes a file or directory inside a directory.
+type Entry struct {
+	// Name is the entry's name inside its directory.
+	Name string
+
+	// Path is the URL path of the entry.
+	Path string
+
+	// IsDirectory tells whether the entry is a directory.
+	IsDirectory bool
+
+	// Size is the size of a file's content in bytes.
+	Size int64
+
+	// ModTime is the point in time when the entry was last modified.
+	ModTime time.Time
+
+	// MimeType is the entry's MIME type, as MimeTypeForRequest would report
+	// it.
+	MimeType string
+}
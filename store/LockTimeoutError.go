@@ -0,0 +1,18 @@
+package store
+
+// LockTimeoutError is set when a file stays locked by another writer for
+// longer than the configured lock timeout.
+type LockTimeoutError string
+
+func NewLockTimeoutError(msg string) LockTimeoutError {
+	return LockTimeoutError(msg)
+}
+
+func (e LockTimeoutError) Error() string {
+	return string(e)
+}
+
+func IsLockTimeoutError(e interface{}) bool {
+	_, ok := e.(LockTimeoutError)
+	return ok
+}
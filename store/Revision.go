@@ -0,0 +1,20 @@
+package store
+
+import "time"
+
+// Revision describes a version of a file kept by the store.
+// How and when revisions are recorded depends on the store implementation.
+type Revision struct {
+	// ID identifies the revision among all revisions of the same file.
+	ID string
+
+	// Author is the unique id of the user the revision is attributed to, or
+	// the empty string if the user wasn't authenticated.
+	Author string
+
+	// Time is the point in time when the revision was recorded.
+	Time time.Time
+
+	// Size is the size of the revision's content in bytes.
+	Size int64
+}
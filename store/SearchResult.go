@@ -0,0 +1,15 @@
+package store
+
+import "github.com/fxnn/gone/search"
+
+// SearchResult describes a file matching a search query.
+type SearchResult struct {
+	// Path is the URL path of the file.
+	Path string
+
+	// Score tells how well the file matches the query; higher is better.
+	Score int
+
+	// Snippets are excerpts of the file's content showing the hits.
+	Snippets []search.Snippet
+}
@@ -12,22 +12,111 @@ import (
 // Elementary to use the Store interface is the Err() method.
 // As soon as an error occurs, all functions in Store turn to no-ops.
 // The Err() method clears the error value and allows for error checking.
+//
+// As the error value is kept between operations, one Store value must not be
+// used by concurrent requests.
+// Instead, each request obtains its own value from the Handle() method.
+//
+// Operations respect the context of the request they're given: once the
+// request is canceled or exceeds its deadline, long operations stop and
+// record the context's error.
+//
+// Writing, deleting and moving a file locks it against other writers, also
+// in other processes.
+// When the lock can't be acquired in time, a LockTimeoutError is recorded.
 type Store interface {
+	// Handle returns a new Store for the same contents, but with its own
+	// error value.
+	// Handles may be used concurrently, while one handle may not.
+	Handle() Store
+
 	HasReadAccessForRequest(request *http.Request) bool
 	HasWriteAccessForRequest(request *http.Request) bool
 	HasDeleteAccessForRequest(request *http.Request) bool
 
 	OpenReader(request *http.Request) io.ReadCloser
 	OpenWriter(request *http.Request) io.WriteCloser
+	// OpenWriterIfUnchanged works like OpenWriter, but records a
+	// ConflictError unless the file's current content has the expected
+	// ContentHash.
+	// Checking and writing happen under the same lock, so that no other
+	// writer may come in between.
+	OpenWriterIfUnchanged(request *http.Request, expectedHash string) io.WriteCloser
+	// OpenWriterIfMissing works like OpenWriter, but records a ConflictError
+	// when the file already exists.
+	// Again, checking and creating happen under the same lock.
+	OpenWriterIfMissing(request *http.Request) io.WriteCloser
 
 	ReadString(request *http.Request) string
 	WriteString(request *http.Request, content string)
+	// WriteStringIfUnchanged writes the content like WriteString, but only
+	// if the file's current content has the expected ContentHash.
+	WriteStringIfUnchanged(request *http.Request, content string, expectedHash string)
 
+	// Delete deletes the file or the empty directory.
+	// Files are moved into the trash, from which they may be restored until
+	// the store purges them.
 	Delete(request *http.Request)
 
+	// Move moves the file to the given URL path and returns the URL path it
+	// was moved to.
+	// When the target lacks a file extension, the file's extension is kept;
+	// when it's a directory, the file is moved into it.
+	// With rewriteLinks, links in other pages pointing to the file are
+	// changed to point to the new location.
+	Move(request *http.Request, targetPath string, rewriteLinks bool) string
+
+	// List returns the entries of the directory the request points to,
+	// directories first, each ordered by name.
+	// Hidden entries and entries the user may not read are left out.
+	List(request *http.Request) []Entry
+	// Walk calls walkFn for each entry below the directory the request points
+	// to, in the order List returns them.
+	// Directories are visited before their contents.
+	// Hidden entries and entries the user may not read are left out, and
+	// symlinked directories are not entered.
+	Walk(request *http.Request, walkFn WalkFunc)
+
+	// Backlinks returns the URL paths of all readable pages linking to the
+	// file, in alphabetical order.
+	// Without a search index, all pages are read to find them.
+	Backlinks(request *http.Request) []string
+	// IndexedBacklinks returns the same as Backlinks, but only when they can
+	// be looked up in a search index; otherwise, the result is nil.
+	IndexedBacklinks(request *http.Request) []string
+
+	// Revisions lists the recorded versions of the file, newest first.
+	Revisions(request *http.Request) []Revision
+	// OpenRevisionReader opens a reader for the content of the given revision.
+	OpenRevisionReader(request *http.Request, revisionID string) io.ReadCloser
+	// RestoreRevision replaces the file's content with the content of the
+	// given revision.
+	// The replaced content is kept as a new revision.
+	RestoreRevision(request *http.Request, revisionID string)
+
+	// TrashItems lists the deleted files that were located below the
+	// directory the request points to, newest first.
+	// Files the user may not create again are left out.
+	TrashItems(request *http.Request) []TrashItem
+	// RestoreTrashItem moves the given deleted file back to where it was
+	// located, and returns its URL path.
+	// The request points to a directory containing the original location.
+	RestoreTrashItem(request *http.Request, itemID string) string
+	// PurgeTrashItem deletes the given deleted file for good.
+	// The request points to a directory containing the original location.
+	PurgeTrashItem(request *http.Request, itemID string)
+
+	// Search finds all readable files below the directory the request points
+	// to whose name or content matches the query, best matches first.
+	Search(request *http.Request, query string) []SearchResult
+
 	FileSizeForRequest(request *http.Request) int64
 	MimeTypeForRequest(request *http.Request) string
 	ModTimeForRequest(request *http.Request) time.Time
+	// URLPathForRequest returns the URL path of the file the request points
+	// to, with its file extension guessed and index documents resolved.
+	// Thus, it's the same for all requests to the same file.
+	URLPathForRequest(request *http.Request) string
 
 	// Err() clears and returns the error value.
 	// It allows for error checking after one or more operations.
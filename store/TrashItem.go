@@ -0,0 +1,23 @@
+package store
+
+import "time"
+
+// TrashItem describes a deleted file kept by the store, so that it may be
+// restored.
+type TrashItem struct {
+	// ID identifies the item among all items in the trash.
+	ID string
+
+	// Path is the URL path the file was deleted from.
+	Path string
+
+	// DeletedBy is the unique id of the user that deleted the file, or the
+	// empty string if the user wasn't authenticated.
+	DeletedBy string
+
+	// Time is the point in time when the file was deleted.
+	Time time.Time
+
+	// Size is the size of the file's content in bytes.
+	Size int64
+}
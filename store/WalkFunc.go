@@ -0,0 +1,14 @@
+package store
+
+import "errors"
+
+// WalkFunc is called by Store.Walk for each entry visited.
+//
+// When it returns SkipDir for a directory, the directory's contents are
+// skipped.
+// Any other error stops the walk and is returned by the Store's Err() method.
+type WalkFunc func(entry Entry) error
+
+// SkipDir is returned by a WalkFunc to skip the contents of a directory.
+// It's never returned as an error by Store.Err().
+var SkipDir = errors.New("skip this directory")
@@ -0,0 +1,13 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// ContentHash identifies a version of a file's content.
+// A missing file has the hash of empty content.
+func ContentHash(content string) string {
+	var sum = sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
@@ -11,15 +11,18 @@ import (
 )
 
 // accessControl implements permission checking for incoming requests
-// based on the file system's permissions.
+// based on access control lists or, where there are none, on the file
+// system's permissions.
 type accessControl struct {
 	authenticator authenticator.Authenticator
+	acls          *aclCache
 	*pathIO
 	*errStore
 }
 
-func newAccessControl(a authenticator.Authenticator, p *pathIO, s *errStore) *accessControl {
-	return &accessControl{a, p, s}
+func newAccessControl(a authenticator.Authenticator, acls *aclCache, p *pathIO,
+	s *errStore) *accessControl {
+	return &accessControl{a, acls, p, s}
 }
 
 func (a *accessControl) assertHasWriteAccessForRequest(request *http.Request) {
@@ -36,10 +39,14 @@ func (a *accessControl) assertHasWriteAccessForRequest(request *http.Request) {
 }
 
 func (a *accessControl) assertHasReadAccessForRequest(request *http.Request) {
+	a.assertHasReadAccessForPath(request, a.pathFromRequest(request))
+}
+
+func (a *accessControl) assertHasReadAccessForPath(request *http.Request, p gopath.GoPath) {
 	if a.hasErr() {
 		return
 	}
-	if !a.HasReadAccessForRequest(request) {
+	if !a.hasReadAccessForPath(request, p) {
 		var msg = fmt.Sprintf("read access denied on %s", request.URL)
 		if a.hasErr() {
 			msg = fmt.Sprintf("%s: %s", msg, a.err)
@@ -62,57 +69,105 @@ func (a *accessControl) assertHasDeleteAccessForRequest(request *http.Request) {
 }
 
 func (a *accessControl) HasWriteAccessForRequest(request *http.Request) bool {
-	if a.authenticator.IsAuthenticated(request) {
+	var p = a.pathFromRequest(request)
+	if permission, ok := a.aclPermissionForPath(request, p); ok {
+		return permission >= aclWrite
+	}
+	if a.hasFullAccess(request) {
 		// HINT: OK, as long as the gone process can read the file
 		return true
 	}
 
-	var p = a.pathFromRequest(request)
-	if !a.canEnterAllParentDirectories(p) {
+	if !a.canEnterAllParentDirectories(request, p) {
 		return false
 	}
 	if !p.IsExists() {
 		// HINT: Create file
-		return a.canWriteDirectory(p.Dir())
+		return a.canWriteDirectory(request, p.Dir())
 	}
-	return a.canWriteFile(p)
+	return a.canWriteFile(request, p)
 }
 
 func (a *accessControl) HasReadAccessForRequest(request *http.Request) bool {
-	if a.authenticator.IsAuthenticated(request) {
+	return a.hasReadAccessForPath(request, a.pathFromRequest(request))
+}
+
+// hasReadAccessForPath checks read access on the given file or directory for
+// the user sending the request.
+func (a *accessControl) hasReadAccessForPath(request *http.Request, p gopath.GoPath) bool {
+	if permission, ok := a.aclPermissionForPath(request, p); ok {
+		return permission >= aclRead
+	}
+	if a.hasFullAccess(request) {
 		// HINT: OK, as long as the gone process can read the file
 		return true
 	}
 
-	var p = a.pathFromRequest(request)
-	if !a.canEnterAllParentDirectories(p) {
+	if !a.canEnterAllParentDirectories(request, p) {
 		return false
 	}
-	return a.canReadFile(p)
+	if p.IsDirectory() {
+		return a.canListDirectory(request, p)
+	}
+	return a.canReadFile(request, p)
 }
 
 func (a *accessControl) HasDeleteAccessForRequest(request *http.Request) bool {
-	if a.authenticator.IsAuthenticated(request) {
+	var p = a.pathFromRequest(request)
+	if permission, ok := a.aclPermissionForPath(request, p); ok {
+		return permission >= aclWrite
+	}
+	if a.hasFullAccess(request) {
 		// HINT: OK, as long as the gone process can read the file
 		return true
 	}
 
-	var p = a.pathFromRequest(request)
-	if !a.canEnterAllParentDirectories(p) {
+	if !a.canEnterAllParentDirectories(request, p) {
 		return false
 	}
 
-	return a.canWriteDirectory(p.Dir())
+	return a.canWriteDirectory(request, p.Dir())
+}
+
+// aclPermissionForPath returns what the access control list applying to the
+// given file or directory permits the user sending the request.
+// When no access control list applies, it returns false, and the file
+// system's permissions are to be used instead.
+func (a *accessControl) aclPermissionForPath(request *http.Request, p gopath.GoPath) (aclPermission, bool) {
+	if p.HasErr() {
+		return aclNone, false
+	}
+	var dir = p
+	if !p.IsDirectory() {
+		dir = p.Dir()
+	}
+	var acl, ok = a.aclForDirectory(dir)
+	if !ok {
+		return aclNone, false
+	}
+	return acl.permissionFor(a.authenticator.IsAuthenticated(request),
+		a.authenticator.UserID(request), a.authenticator.Groups(request)), true
+}
+
+// hasFullAccess returns true iff the user sending the request may access
+// everything the gone process may access.
+// This is the case for authenticated users, unless they're mapped to Unix
+// users.
+func (a *accessControl) hasFullAccess(request *http.Request) bool {
+	if _, ok := a.authenticator.(authenticator.UnixAuthenticator); ok {
+		return false
+	}
+	return a.authenticator.IsAuthenticated(request)
 }
 
 // hasAccessForAllParentDirectories returns true iff all parent directories can
-// be entered using world permissions.
-func (a *accessControl) canEnterAllParentDirectories(p gopath.GoPath) bool {
+// be entered by the user sending the request.
+func (a *accessControl) canEnterAllParentDirectories(request *http.Request, p gopath.GoPath) bool {
 	var parentDir = a.contentRoot
 
 	// NOTE: Implicitly skips the last path component, which isn't a parent directory
 	for _, component := range a.pathComponentsTo(p) {
-		if !a.canEnterDirectory(parentDir) {
+		if !a.canEnterDirectory(request, parentDir) {
 			return false
 		}
 		parentDir = parentDir.JoinPath(component)
@@ -121,44 +176,68 @@ func (a *accessControl) canEnterAllParentDirectories(p gopath.GoPath) bool {
 	return true
 }
 
-func (a *accessControl) canEnterDirectory(p gopath.GoPath) bool {
+func (a *accessControl) canEnterDirectory(request *http.Request, p gopath.GoPath) bool {
 	if p.HasErr() || !p.IsDirectory() {
 		return false
 	}
-	return a.hasWorldExecutePermission(p.FileMode())
+	return a.hasExecutePermission(a.permissionBits(request, p))
 }
 
-func (a *accessControl) canWriteDirectory(p gopath.GoPath) bool {
+// canListDirectory returns true iff the directory's entries can be both listed
+// and inspected by the user sending the request.
+func (a *accessControl) canListDirectory(request *http.Request, p gopath.GoPath) bool {
 	if p.HasErr() || !p.IsDirectory() {
 		return false
 	}
-	return a.hasWorldWritePermission(p.FileMode())
+	var bits = a.permissionBits(request, p)
+	return a.hasReadPermission(bits) && a.hasExecutePermission(bits)
 }
 
-func (a *accessControl) canReadFile(p gopath.GoPath) bool {
+func (a *accessControl) canWriteDirectory(request *http.Request, p gopath.GoPath) bool {
+	if p.HasErr() || !p.IsDirectory() {
+		return false
+	}
+	return a.hasWritePermission(a.permissionBits(request, p))
+}
+
+func (a *accessControl) canReadFile(request *http.Request, p gopath.GoPath) bool {
 	if p.HasErr() || !p.IsRegular() {
 		return false
 	}
-	return a.hasWorldReadPermission(p.FileMode())
+	return a.hasReadPermission(a.permissionBits(request, p))
 }
 
-func (a *accessControl) canWriteFile(p gopath.GoPath) bool {
+func (a *accessControl) canWriteFile(request *http.Request, p gopath.GoPath) bool {
 	if p.HasErr() || !p.IsRegular() {
 		return false
 	}
-	return a.hasWorldWritePermission(p.FileMode())
+	return a.hasWritePermission(a.permissionBits(request, p))
+}
+
+// permissionBits returns the read, write and execute bits of the given file's
+// mode that apply to the user sending the request.
+// These are the world permissions, unless the user is mapped to a Unix user;
+// then, the owner or group permissions apply as the operating system would
+// apply them.
+func (a *accessControl) permissionBits(request *http.Request, p gopath.GoPath) os.FileMode {
+	if unixAuth, ok := a.authenticator.(authenticator.UnixAuthenticator); ok {
+		if unixUser, ok := unixAuth.UnixUser(request); ok {
+			return unixPermissionBits(p, unixUser)
+		}
+	}
+	return p.FileMode() & 0007
 }
 
-func (a *accessControl) hasWorldExecutePermission(mode os.FileMode) bool {
-	return mode&0001 != 0
+func (a *accessControl) hasExecutePermission(bits os.FileMode) bool {
+	return bits&0001 != 0
 }
 
-func (a *accessControl) hasWorldWritePermission(mode os.FileMode) bool {
-	return mode&0002 != 0
+func (a *accessControl) hasWritePermission(bits os.FileMode) bool {
+	return bits&0002 != 0
 }
 
-func (a *accessControl) hasWorldReadPermission(mode os.FileMode) bool {
-	return mode&0004 != 0
+func (a *accessControl) hasReadPermission(bits os.FileMode) bool {
+	return bits&0004 != 0
 }
 
 // getRelevantFileModeForPath returns the FileMode for the given file or, when
@@ -0,0 +1,67 @@
+package filestore
+
+import (
+	"net/http"
+	"syscall"
+	"testing"
+
+	"github.com/fxnn/gone/authenticator"
+)
+
+func TestUnixUsersGetGroupPermissions(t *testing.T) {
+	tmpdir := createTempWdInCurrentwd(t, 0751) // world execute flag
+	defer removeTempWdFromCurrentwd(t, tmpdir)
+
+	tmpfile := createTempFileInCurrentwd(t, 0640) // group read flag
+	defer removeTempFileFromCurrentwd(t, tmpfile)
+
+	var stat, ok = getwdPath(t).JoinPath(tmpfile).FileInfo().Sys().(*syscall.Stat_t)
+	if !ok {
+		t.Skipf("no Unix file ownership")
+	}
+	var request = requestGET("/" + tmpfile)
+
+	var member = New(getwdPath(t), &unixAuthenticator{
+		authenticator.UnixUser{UID: stat.Uid + 1, GIDs: []uint32{stat.Gid}}, true}, testLockTimeout, testTrashRetention)
+	if !member.HasReadAccessForRequest(request) {
+		t.Fatalf("expected group member to have read access")
+	}
+	if member.HasWriteAccessForRequest(request) {
+		t.Fatalf("expected group member to have no write access")
+	}
+
+	var other = New(getwdPath(t), &unixAuthenticator{authenticator.UnixUser{UID: stat.Uid + 1}, true}, testLockTimeout, testTrashRetention)
+	if other.HasReadAccessForRequest(request) {
+		t.Fatalf("expected other user to have no read access")
+	}
+
+	var unmapped = New(getwdPath(t), &unixAuthenticator{authenticator.UnixUser{}, false}, testLockTimeout, testTrashRetention)
+	if unmapped.HasReadAccessForRequest(request) {
+		t.Fatalf("expected user without Unix user to have no read access")
+	}
+}
+
+// unixAuthenticator always authenticates a user with the same Unix user.
+type unixAuthenticator struct {
+	unixUser authenticator.UnixUser
+	ok       bool
+}
+
+func (a *unixAuthenticator) IsAuthenticated(request *http.Request) bool {
+	return true
+}
+
+func (a *unixAuthenticator) UserID(request *http.Request) string {
+	return "Aladdin"
+}
+
+func (a *unixAuthenticator) Groups(request *http.Request) []string {
+	return nil
+}
+
+func (a *unixAuthenticator) SetUserID(writer http.ResponseWriter, request *http.Request, userID string) {
+}
+
+func (a *unixAuthenticator) UnixUser(request *http.Request) (authenticator.UnixUser, bool) {
+	return a.unixUser, a.ok
+}
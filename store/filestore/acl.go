@@ -0,0 +1,171 @@
+package filestore
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fxnn/gone/log"
+	"github.com/fxnn/gopath"
+)
+
+// aclFileName is the name of the hidden file holding a directory's access
+// control list.
+const aclFileName = ".acl"
+
+// aclPermission is what an access control list allows; each permission
+// includes the lower ones.
+type aclPermission int
+
+const (
+	aclNone aclPermission = iota
+	aclRead
+	aclWrite
+)
+
+// An aclEntry grants a permission to the users matched by a principal, which
+// is one of "user:<name>", "group:<name>", "authenticated" or "everyone".
+type aclEntry struct {
+	principal  string
+	permission aclPermission
+}
+
+// An acl is the access control list read from an .acl file, with one entry
+// per line:
+//
+//	# the docs editors may change the handbook, everyone else may read it
+//	group:docs-editors  write
+//	everyone            read
+//
+// Permissions are "none", "read" and "write", where "write" allows to
+// create, change and delete files.
+// A user gets the highest permission of all matching entries.
+type acl []aclEntry
+
+// permissionFor returns the permission the acl grants to a user with the
+// given id and groups; authenticated is false for anonymous users.
+func (l acl) permissionFor(authenticated bool, userID string, groups []string) aclPermission {
+	var result = aclNone
+	for _, entry := range l {
+		if entry.permission > result && entry.matches(authenticated, userID, groups) {
+			result = entry.permission
+		}
+	}
+	return result
+}
+
+func (e aclEntry) matches(authenticated bool, userID string, groups []string) bool {
+	switch {
+	case e.principal == "everyone":
+		return true
+	case !authenticated:
+		return false
+	case e.principal == "authenticated":
+		return true
+	case strings.HasPrefix(e.principal, "user:"):
+		return e.principal[len("user:"):] == userID
+	case strings.HasPrefix(e.principal, "group:"):
+		for _, group := range groups {
+			if e.principal[len("group:"):] == group {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func parseACL(content []byte) (acl, error) {
+	var result = make(acl, 0)
+	var scanner = bufio.NewScanner(bytes.NewReader(content))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		var line = strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var fields = strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected principal and permission", lineNumber)
+		}
+		if !isValidACLPrincipal(fields[0]) {
+			return nil, fmt.Errorf("line %d: invalid principal %s", lineNumber, fields[0])
+		}
+		var permission, ok = map[string]aclPermission{
+			"none": aclNone, "read": aclRead, "write": aclWrite}[fields[1]]
+		if !ok {
+			return nil, fmt.Errorf("line %d: invalid permission %s", lineNumber, fields[1])
+		}
+		result = append(result, aclEntry{fields[0], permission})
+	}
+	return result, scanner.Err()
+}
+
+func isValidACLPrincipal(principal string) bool {
+	return principal == "everyone" || principal == "authenticated" ||
+		(strings.HasPrefix(principal, "user:") && len(principal) > len("user:")) ||
+		(strings.HasPrefix(principal, "group:") && len(principal) > len("group:"))
+}
+
+// aclCache keeps the parsed .acl files, rereading them when they change.
+type aclCache struct {
+	mutex   sync.Mutex
+	entries map[string]aclCacheEntry
+}
+
+type aclCacheEntry struct {
+	modTime time.Time
+	acl     acl
+}
+
+func newACLCache() *aclCache {
+	return &aclCache{entries: make(map[string]aclCacheEntry)}
+}
+
+// aclForDirectory returns the acl applying to the given directory, which is
+// read from the nearest .acl file in the directory or its parents inside
+// the content root.
+// When there's no such file, it returns false.
+func (a *accessControl) aclForDirectory(dir gopath.GoPath) (acl, bool) {
+	for dir.IsDirectory() && a.isPathInsideContentRoot(dir) {
+		var aclFile = dir.JoinPath(aclFileName).Stat()
+		if aclFile.IsExists() {
+			return a.acls.load(aclFile), true
+		}
+		if a.normalizePath(dir).Path() == a.contentRoot.Path() {
+			break
+		}
+		dir = dir.Dir()
+	}
+	return nil, false
+}
+
+// load returns the acl from the given file.
+// An unreadable file yields an empty acl, which denies all access.
+func (c *aclCache) load(aclFile gopath.GoPath) acl {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var modTime = aclFile.FileInfo().ModTime()
+	if entry, ok := c.entries[aclFile.Path()]; ok && entry.modTime.Equal(modTime) {
+		return entry.acl
+	}
+
+	var content, err = ioutil.ReadFile(aclFile.Path())
+	var result acl
+	if err == nil {
+		result, err = parseACL(content)
+	}
+	if err != nil {
+		log.Warnf("denying all access by %s: %s", aclFile.Path(), err)
+		result = acl{}
+	}
+	if !os.IsNotExist(err) {
+		c.entries[aclFile.Path()] = aclCacheEntry{modTime, result}
+	}
+	return result
+}
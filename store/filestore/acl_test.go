@@ -0,0 +1,124 @@
+package filestore
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestParseACL(t *testing.T) {
+	var acl, err = parseACL([]byte("# comment\ngroup:editors write\n\neveryone read\n"))
+	if err != nil {
+		t.Fatalf("couldn't parse acl: %s", err)
+	}
+
+	if p := acl.permissionFor(true, "Jasmine", []string{"editors"}); p != aclWrite {
+		t.Fatalf("expected write permission for editor, got %d", p)
+	}
+	if p := acl.permissionFor(true, "Aladdin", nil); p != aclRead {
+		t.Fatalf("expected read permission for other user, got %d", p)
+	}
+	if p := acl.permissionFor(false, "", nil); p != aclRead {
+		t.Fatalf("expected read permission for anonymous user, got %d", p)
+	}
+}
+
+func TestParseACLRejectsInvalidLines(t *testing.T) {
+	for _, content := range []string{"everyone", "everyone execute", "nobody read", "user: write"} {
+		if _, err := parseACL([]byte(content)); err == nil {
+			t.Fatalf("expected error on %q", content)
+		}
+	}
+}
+
+func TestACLOverridesFilePermissions(t *testing.T) {
+	tmpdir := createTempDirInCurrentwd(t, 0700) // no world permissions
+	defer os.RemoveAll(tmpdir)
+	writeTestFile(t, path.Join(tmpdir, aclFileName), "group:editors write\neveryone read\n")
+	writeTestFile(t, path.Join(tmpdir, "page.md"), "content")
+
+	var request = requestGET("/" + tmpdir + "/page.md")
+	var editor = New(getwdPath(t), &groupAuthenticator{"Jasmine", []string{"editors"}}, testLockTimeout, testTrashRetention)
+	var user = New(getwdPath(t), &groupAuthenticator{"Aladdin", nil}, testLockTimeout, testTrashRetention)
+	var anonymous = sutNotAuthenticated(t)
+
+	if !editor.HasWriteAccessForRequest(request) || !editor.HasDeleteAccessForRequest(request) {
+		t.Fatalf("expected editor to have write access")
+	}
+	if !user.HasReadAccessForRequest(request) || user.HasWriteAccessForRequest(request) ||
+		user.HasDeleteAccessForRequest(request) {
+		t.Fatalf("expected user to have read access only")
+	}
+	if !anonymous.HasReadAccessForRequest(request) || anonymous.HasWriteAccessForRequest(request) {
+		t.Fatalf("expected anonymous user to have read access only")
+	}
+}
+
+func TestACLIsInheritedUntilOverridden(t *testing.T) {
+	tmpdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(tmpdir)
+	writeTestFile(t, path.Join(tmpdir, aclFileName), "user:Jasmine write\n")
+	if err := os.MkdirAll(path.Join(tmpdir, "inherited"), 0777); err != nil {
+		t.Fatalf("couldn't create directory: %s", err)
+	}
+	if err := os.MkdirAll(path.Join(tmpdir, "overridden"), 0777); err != nil {
+		t.Fatalf("couldn't create directory: %s", err)
+	}
+	writeTestFile(t, path.Join(tmpdir, "overridden", aclFileName), "user:Aladdin write\n")
+
+	var jasmine = New(getwdPath(t), &groupAuthenticator{"Jasmine", nil}, testLockTimeout, testTrashRetention)
+	var aladdin = New(getwdPath(t), &groupAuthenticator{"Aladdin", nil}, testLockTimeout, testTrashRetention)
+	var inherited = requestGET("/" + tmpdir + "/inherited/new.md")
+	var overridden = requestGET("/" + tmpdir + "/overridden/new.md")
+
+	if !jasmine.HasWriteAccessForRequest(inherited) || aladdin.HasWriteAccessForRequest(inherited) {
+		t.Fatalf("expected acl to be inherited")
+	}
+	if jasmine.HasWriteAccessForRequest(overridden) || !aladdin.HasWriteAccessForRequest(overridden) {
+		t.Fatalf("expected acl to be overridden")
+	}
+	if aladdin.HasReadAccessForRequest(requestGET("/" + tmpdir + "/inherited")) {
+		t.Fatalf("expected acl to deny listing the directory")
+	}
+}
+
+func TestInvalidACLDeniesAllAccess(t *testing.T) {
+	tmpdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(tmpdir)
+	writeTestFile(t, path.Join(tmpdir, aclFileName), "everyone everything\n")
+	writeTestFile(t, path.Join(tmpdir, "page.md"), "content")
+
+	if sutAuthenticated(t).HasReadAccessForRequest(requestGET("/" + tmpdir + "/page.md")) {
+		t.Fatalf("expected invalid acl to deny access")
+	}
+}
+
+func writeTestFile(t *testing.T, name string, content string) {
+	if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+		t.Fatalf("couldn't write %s: %s", name, err)
+	}
+}
+
+// groupAuthenticator always authenticates the same user with the same
+// groups.
+type groupAuthenticator struct {
+	userID string
+	groups []string
+}
+
+func (a *groupAuthenticator) IsAuthenticated(request *http.Request) bool {
+	return true
+}
+
+func (a *groupAuthenticator) UserID(request *http.Request) string {
+	return a.userID
+}
+
+func (a *groupAuthenticator) Groups(request *http.Request) []string {
+	return a.groups
+}
+
+func (a *groupAuthenticator) SetUserID(writer http.ResponseWriter, request *http.Request, userID string) {
+}
@@ -0,0 +1,129 @@
+package filestore
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// atomicWriter writes the new content of a file into a hidden temporary file
+// next to it, which replaces the file when the writer gets closed.
+// This way, a crash or a full disk never leaves a partially written file
+// behind.
+// When any write failed, closing discards the new content.
+//
+// Files that can't be replaced, as their directory isn't writeable or their
+// owner can't be kept, are overwritten in place instead.
+//
+// Writing fails as soon as the given context is done.
+type atomicWriter struct {
+	ctx  context.Context
+	file *os.File
+	// target is the path of the file to be replaced, or the empty string when
+	// writing in place.
+	target string
+	err    error
+	// discarded is set when closing dropped the new content, so that the
+	// file still has its previous content.
+	discarded bool
+}
+
+// openAtomicWriter opens an atomicWriter for the file at the given path.
+func openAtomicWriter(ctx context.Context, path string) (*atomicWriter, error) {
+	// HINT: replace the file a symlink points to, not the symlink itself
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	var file, err = createTempFileFor(path)
+	if err == nil {
+		if err = keepModeAndOwner(file, path); err != nil {
+			file.Close()
+			os.Remove(file.Name())
+		}
+	}
+	if os.IsPermission(err) {
+		// HINT: the file can't be replaced, so it's overwritten in place
+		if file, err = os.Create(path); err != nil {
+			return nil, err
+		}
+		return &atomicWriter{ctx: ctx, file: file}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &atomicWriter{ctx: ctx, file: file, target: path}, nil
+}
+
+// createTempFileFor creates a new hidden file in the directory of the given
+// path.
+// Other than ioutil.TempFile, it respects the umask like os.Create does.
+func createTempFileFor(path string) (*os.File, error) {
+	var prefix = filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".")
+	for i := 0; i < 10000; i++ {
+		var file, err = os.OpenFile(prefix+strconv.Itoa(rand.Int())+".tmp",
+			os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+		if !os.IsExist(err) {
+			return file, err
+		}
+	}
+	return nil, &os.PathError{Op: "createtemp", Path: prefix + "*.tmp", Err: os.ErrExist}
+}
+
+// keepModeAndOwner gives the file the mode and owner of the file at the
+// given path, if that exists.
+func keepModeAndOwner(file *os.File, path string) error {
+	var info, err = os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := file.Chmod(info.Mode().Perm()); err != nil {
+		return err
+	}
+	return keepOwner(file, info)
+}
+
+func (w *atomicWriter) Write(p []byte) (int, error) {
+	if w.err == nil {
+		w.err = w.ctx.Err()
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	var n, err = w.file.Write(p)
+	w.err = err
+	return n, err
+}
+
+// Close makes the written content durable and, unless writing in place,
+// lets it replace the target file.
+func (w *atomicWriter) Close() error {
+	var err = w.err
+	if err == nil {
+		err = w.file.Sync()
+	}
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	if w.target == "" {
+		return err
+	}
+
+	if err == nil {
+		err = os.Rename(w.file.Name(), w.target)
+	}
+	if err != nil {
+		os.Remove(w.file.Name())
+		w.discarded = true
+		return err
+	}
+	return syncDirectory(filepath.Dir(w.target))
+}
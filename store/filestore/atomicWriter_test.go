@@ -0,0 +1,184 @@
+package filestore
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+)
+
+func TestAtomicWriterKeepsPreviousContentUntilClosed(t *testing.T) {
+	tmpdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(tmpdir)
+	var file = path.Join(tmpdir, "page.md")
+	writeTestFile(t, file, "old content")
+
+	sut, err := openAtomicWriter(context.Background(), file)
+	if err != nil {
+		t.Fatalf("couldn't open writer: %s", err)
+	}
+	if _, err := sut.Write([]byte("new content")); err != nil {
+		t.Fatalf("couldn't write: %s", err)
+	}
+	assertFileContent(t, file, "old content")
+
+	if err := sut.Close(); err != nil {
+		t.Fatalf("couldn't close: %s", err)
+	}
+	assertFileContent(t, file, "new content")
+	assertDirectoryEntries(t, tmpdir, 1)
+}
+
+func TestAtomicWriterDiscardsContentWhenWriteFails(t *testing.T) {
+	tmpdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(tmpdir)
+	var file = path.Join(tmpdir, "page.md")
+	writeTestFile(t, file, "old content")
+
+	sut, err := openAtomicWriter(context.Background(), file)
+	if err != nil {
+		t.Fatalf("couldn't open writer: %s", err)
+	}
+	if _, err := sut.Write([]byte("new")); err != nil {
+		t.Fatalf("couldn't write: %s", err)
+	}
+	// HINT: simulates an I/O error, like a full disk
+	sut.file.Close()
+	if _, err := sut.Write([]byte(" content")); err == nil {
+		t.Fatalf("expected write to fail")
+	}
+
+	if err := sut.Close(); err == nil {
+		t.Fatalf("expected close to report the failed write")
+	}
+	assertFileContent(t, file, "old content")
+	assertDirectoryEntries(t, tmpdir, 1)
+}
+
+func TestAtomicWriterKeepsFileMode(t *testing.T) {
+	skipOnWindows(t)
+
+	tmpdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(tmpdir)
+	var file = path.Join(tmpdir, "page.md")
+	writeTestFile(t, file, "old content")
+	if err := os.Chmod(file, 0640); err != nil {
+		t.Fatalf("couldn't chmod: %s", err)
+	}
+
+	sut, err := openAtomicWriter(context.Background(), file)
+	if err != nil {
+		t.Fatalf("couldn't open writer: %s", err)
+	}
+	sut.Write([]byte("new content"))
+	if err := sut.Close(); err != nil {
+		t.Fatalf("couldn't close: %s", err)
+	}
+
+	if info, err := os.Stat(file); err != nil || info.Mode().Perm() != 0640 {
+		t.Fatalf("expected mode 0640, got %v (%v)", info.Mode(), err)
+	}
+}
+
+func TestAtomicWriterReplacesTargetOfSymlink(t *testing.T) {
+	skipOnWindows(t)
+
+	tmpdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(tmpdir)
+	var file = path.Join(tmpdir, "page.md")
+	var link = path.Join(tmpdir, "link.md")
+	writeTestFile(t, file, "old content")
+	if err := os.Symlink("page.md", link); err != nil {
+		t.Fatalf("couldn't create symlink: %s", err)
+	}
+
+	sut, err := openAtomicWriter(context.Background(), link)
+	if err != nil {
+		t.Fatalf("couldn't open writer: %s", err)
+	}
+	sut.Write([]byte("new content"))
+	if err := sut.Close(); err != nil {
+		t.Fatalf("couldn't close: %s", err)
+	}
+
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("expected %s to stay a symlink (%v)", link, err)
+	}
+	assertFileContent(t, file, "new content")
+}
+
+func TestAtomicWriterWritesInPlaceWhenDirectoryIsNotWriteable(t *testing.T) {
+	skipOnWindows(t)
+	if os.Geteuid() == 0 {
+		t.Skip("test skipped for root, who may write anywhere")
+	}
+
+	tmpdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(tmpdir)
+	var file = path.Join(tmpdir, "page.md")
+	writeTestFile(t, file, "old content")
+	if err := os.Chmod(tmpdir, 0555); err != nil {
+		t.Fatalf("couldn't chmod: %s", err)
+	}
+	defer os.Chmod(tmpdir, 0777)
+
+	sut, err := openAtomicWriter(context.Background(), file)
+	if err != nil {
+		t.Fatalf("couldn't open writer: %s", err)
+	}
+	sut.Write([]byte("new content"))
+	if err := sut.Close(); err != nil {
+		t.Fatalf("couldn't close: %s", err)
+	}
+	assertFileContent(t, file, "new content")
+}
+
+func TestCanceledWriteKeepsPreviousContent(t *testing.T) {
+	tmpdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(tmpdir)
+	writeTestFile(t, path.Join(tmpdir, "page.md"), "old content")
+	defer os.RemoveAll(historyDirectoryName)
+
+	sut := sutAuthenticated(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	writer := sut.OpenWriter(requestGET("/" + tmpdir + "/page.md").WithContext(ctx))
+	if err := sut.Err(); err != nil {
+		t.Fatalf("couldn't open writer: %s", err)
+	}
+	writer.Write([]byte("new"))
+	cancel()
+	if _, err := writer.Write([]byte(" content")); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	if err := writer.Close(); err != context.Canceled {
+		t.Fatalf("expected close to report context.Canceled, got %v", err)
+	}
+	assertFileContent(t, path.Join(tmpdir, "page.md"), "old content")
+	assertDirectoryEntries(t, tmpdir, 1)
+	if revisions := sut.Revisions(requestGET("/" + tmpdir + "/page.md")); len(revisions) != 0 {
+		t.Fatalf("expected no revision of the unchanged file, got %v", revisions)
+	}
+}
+
+// assertDirectoryEntries makes sure no temporary files were left behind.
+// assertDirectoryEntries counts the entries in the directory, except for
+// lock files.
+func assertDirectoryEntries(t *testing.T, dir string, expected int) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("couldn't read %s: %s", dir, err)
+	}
+	var count = 0
+	for _, entry := range entries {
+		if isLockFile, _ := filepath.Match(LockFilePattern, entry.Name()); !isLockFile {
+			count++
+		}
+	}
+	if count != expected {
+		t.Fatalf("expected %d entries in %s, got %d", expected, dir, count)
+	}
+}
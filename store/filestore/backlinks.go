@@ -0,0 +1,101 @@
+package filestore
+
+import (
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/fxnn/gone/links"
+	"github.com/fxnn/gone/store"
+	"github.com/fxnn/gopath"
+)
+
+// backlinksForPath finds all readable Markdown pages linking to the given
+// file.
+// The index is used, if it's active; otherwise, all pages are read.
+func (s *searcher) backlinksForPath(request *http.Request, p gopath.GoPath) []string {
+	if s.hasErr() {
+		return nil
+	}
+
+	var targets = linkTargetsForURLPath(s.urlPathForPath(p))
+	if index := s.indexer.activeIndex(); index != nil {
+		return s.readableBacklinks(request, p, index.Backlinks(targets...))
+	}
+	return s.readableBacklinks(request, p, s.backlinksFromFiles(request, targets))
+}
+
+// indexedBacklinksForPath finds the backlinks like backlinksForPath does,
+// but only if the index is active; otherwise, the result is nil.
+func (s *searcher) indexedBacklinksForPath(request *http.Request, p gopath.GoPath) []string {
+	var index = s.indexer.activeIndex()
+	if s.hasErr() || index == nil {
+		return nil
+	}
+
+	var targets = linkTargetsForURLPath(s.urlPathForPath(p))
+	return s.readableBacklinks(request, p, index.Backlinks(targets...))
+}
+
+// readableBacklinks leaves out the given file itself and all pages the user
+// may not read.
+func (s *searcher) readableBacklinks(request *http.Request, p gopath.GoPath, candidates []string) []string {
+	var urlPath = s.urlPathForPath(p)
+	var result = make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		if candidate != urlPath && s.hasReadAccessForPath(request, s.contentRoot.JoinPath(candidate)) {
+			result = append(result, candidate)
+		}
+	}
+	return result
+}
+
+// backlinksFromFiles reads all Markdown pages to find those linking to any
+// of the given targets.
+func (s *searcher) backlinksFromFiles(request *http.Request, targets []string) []string {
+	var result []string
+	s.walkDirectory(request, s.contentRoot, func(entry store.Entry) error {
+		if entry.IsDirectory || !strings.HasPrefix(entry.MimeType, store.MarkdownMimeType) {
+			return nil
+		}
+		content, err := ioutil.ReadFile(s.contentRoot.JoinPath(entry.Path).Path())
+		if err != nil {
+			return nil
+		}
+		if containsAny(links.Extract(entry.Path, content), targets) {
+			result = append(result, entry.Path)
+		}
+		return nil
+	})
+	sort.Strings(result)
+	return result
+}
+
+// linkTargetsForURLPath returns all link targets that refer to the given
+// file.
+// Links may omit the file extension, and index documents may be referred to
+// by their directory.
+func linkTargetsForURLPath(urlPath string) []string {
+	var result = []string{urlPath}
+	var withoutExt = strings.TrimSuffix(urlPath, path.Ext(urlPath))
+	if withoutExt != urlPath {
+		result = append(result, withoutExt)
+	}
+	if path.Base(withoutExt) == "index" {
+		result = append(result, path.Dir(withoutExt))
+	}
+	return result
+}
+
+func containsAny(haystack []string, needles []string) bool {
+	for _, h := range haystack {
+		for _, n := range needles {
+			if h == n {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package filestore
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestBacklinksWithoutIndex(t *testing.T) {
+	tempWd := createTempWdInCurrentwd(t, 0777)
+	defer removeTempWdFromCurrentwd(t, tempWd)
+
+	writeSearchFixture(t, "target.md", "content", 0644)
+	defer removeTempFileFromCurrentwd(t, "target.md")
+	writeSearchFixture(t, "wiki.md", "[[target]]", 0644)
+	defer removeTempFileFromCurrentwd(t, "wiki.md")
+	writeSearchFixture(t, "standard.md", "[see](/target.md)", 0644)
+	defer removeTempFileFromCurrentwd(t, "standard.md")
+	writeSearchFixture(t, "none.md", "[other](/other.md)", 0644)
+	defer removeTempFileFromCurrentwd(t, "none.md")
+
+	sut := sutAuthenticated(t)
+	backlinks := sut.Backlinks(requestGET("/target"))
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to find backlinks: %s", err)
+	}
+
+	if strings.Join(backlinks, ",") != "/standard.md,/wiki.md" {
+		t.Fatalf("expected /standard.md and /wiki.md, but got %v", backlinks)
+	}
+}
+
+func TestIndexedBacklinksWithoutIndex(t *testing.T) {
+	tempWd := createTempWdInCurrentwd(t, 0777)
+	defer removeTempWdFromCurrentwd(t, tempWd)
+
+	writeSearchFixture(t, "target.md", "content", 0644)
+	defer removeTempFileFromCurrentwd(t, "target.md")
+	writeSearchFixture(t, "wiki.md", "[[target]]", 0644)
+	defer removeTempFileFromCurrentwd(t, "wiki.md")
+
+	sut := sutAuthenticated(t)
+	backlinks := sut.IndexedBacklinks(requestGET("/target"))
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to find backlinks: %s", err)
+	}
+
+	if backlinks != nil {
+		t.Fatalf("expected no backlinks without index, but got %v", backlinks)
+	}
+}
+
+func TestBacklinksFromIndexAreUpdatedByWrites(t *testing.T) {
+	tempWd := createTempWdInCurrentwd(t, 0777)
+	defer removeTempWdFromCurrentwd(t, tempWd)
+	defer os.RemoveAll(indexDirectoryName)
+
+	if err := os.Mkdir("dir", 0755); err != nil {
+		t.Fatalf("couldnt create directory: %s", err)
+	}
+	defer os.RemoveAll("dir")
+	writeSearchFixture(t, "dir/index.md", "content", 0644)
+	writeSearchFixture(t, "source.md", "nothing yet", 0644)
+	defer removeTempFileFromCurrentwd(t, "source.md")
+
+	sut := startedIndexSut(t)
+	defer sut.stopIndex()
+
+	sut.WriteString(requestGET("/source.md"), "[[dir]]")
+	backlinks := sut.Backlinks(requestGET("/dir"))
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to find backlinks: %s", err)
+	}
+
+	if strings.Join(backlinks, ",") != "/source.md" {
+		t.Fatalf("expected /source.md, but got %v", backlinks)
+	}
+	if indexed := sut.IndexedBacklinks(requestGET("/dir")); strings.Join(indexed, ",") != "/source.md" {
+		t.Fatalf("expected /source.md from index, but got %v", indexed)
+	}
+}
@@ -0,0 +1,30 @@
+package filestore
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// cancelableReader fails as soon as the request's context is done, so that
+// nobody keeps reading a large file for a client that's gone.
+type cancelableReader struct {
+	io.ReadCloser
+	ctx context.Context
+}
+
+// newCancelableReader wraps the reader, if any, into a cancelableReader
+// bound to the given request.
+func newCancelableReader(request *http.Request, reader io.ReadCloser) io.ReadCloser {
+	if reader == nil {
+		return nil
+	}
+	return &cancelableReader{reader, request.Context()}
+}
+
+func (r *cancelableReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.ReadCloser.Read(p)
+}
@@ -8,4 +8,11 @@
 // This way, implementation gets a lot easier and more readable.
 // However, callers and developers always have to ensure to correctly check
 // for errors, as the type system won't ensure this anymore.
+//
+// The saved error value belongs to one store handle, which therefore may only
+// be used by one request at a time.
+// New handles with their own error value are created by the Handle() method.
+//
+// Writers of the same file are serialized by advisory locks on hidden lock
+// files, which other processes may take part in; see LockFilePattern.
 package filestore
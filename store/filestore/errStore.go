@@ -2,6 +2,7 @@ package filestore
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 
 	"github.com/fxnn/gone/store"
@@ -32,6 +33,15 @@ func (s *errStore) errAndClear() error {
 	return result
 }
 
+// assertNotCanceled sets the Err() value when the request was canceled or
+// exceeded its deadline, so that long operations stop early.
+func (s *errStore) assertNotCanceled(request *http.Request) {
+	if s.hasErr() {
+		return
+	}
+	s.setErr(request.Context().Err())
+}
+
 func (s *errStore) setErr(err error) {
 	s.err = s.wrapErr(err)
 }
@@ -70,8 +80,12 @@ func (s *errStore) prependErr(prefix string) {
 			s.err = store.NewPathNotFoundError(msg)
 		case store.IsAccessDeniedError(s.err):
 			s.err = store.NewAccessDeniedError(msg)
+		case store.IsLockTimeoutError(s.err):
+			s.err = store.NewLockTimeoutError(msg)
+		case store.IsConflictError(s.err):
+			s.err = store.NewConflictError(msg)
 		default:
-			s.err = fmt.Errorf(msg)
+			s.err = fmt.Errorf("%s: %w", prefix, s.err)
 		}
 	}
 }
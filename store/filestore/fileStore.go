@@ -1,9 +1,11 @@
 package filestore
 
 import (
+	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/fxnn/gone/authenticator"
@@ -18,16 +20,78 @@ type fileStore struct {
 	*pathIO
 	*mimeDetector
 	*accessControl
+	*locker
+	*history
+	*trash
+	*indexer
+	*searcher
+	*lister
+}
+
+// Store is a store.Store that also reveals where in the file system the
+// content for a request is located.
+type Store interface {
+	store.Store
+
+	// PathForRequest returns the path of the file the request points to.
+	// A caller must always check the Err() method.
+	PathForRequest(request *http.Request) gopath.GoPath
+
+	// StartIndex builds a search index of the content root and keeps it up
+	// to date from then on.
+	// Until it's started, searching walks the complete content root.
+	StartIndex() error
 }
 
 // New initializes a zeroe'd instance ready to use.
-func New(contentRoot gopath.GoPath, authenticator authenticator.Authenticator) store.Store {
+// Writers wait at most lockTimeout for others writing the same file.
+// Deleted files are kept in the trash for trashRetention, or for ever if
+// that's zero.
+func New(contentRoot gopath.GoPath, authenticator authenticator.Authenticator,
+	lockTimeout time.Duration, trashRetention time.Duration) Store {
+	return newFileStore(contentRoot, authenticator, true, lockTimeout, trashRetention)
+}
+
+// NewWithoutHistory initializes an instance that doesn't record revisions on
+// its own.
+// This is useful when revisions are kept elsewhere, e.g. by a version control
+// system.
+func NewWithoutHistory(contentRoot gopath.GoPath, authenticator authenticator.Authenticator,
+	lockTimeout time.Duration, trashRetention time.Duration) Store {
+	return newFileStore(contentRoot, authenticator, false, lockTimeout, trashRetention)
+}
+
+func newFileStore(contentRoot gopath.GoPath, authenticator authenticator.Authenticator,
+	keepHistory bool, lockTimeout time.Duration, trashRetention time.Duration) *fileStore {
+	var x = newIndexer(newPathIO(contentRoot, newErrStore()))
+	return newFileStoreHandle(contentRoot, authenticator, keepHistory, lockTimeout, trashRetention,
+		x, newACLCache())
+}
+
+// newFileStoreHandle creates a fileStore with its own errStore, sharing the
+// given search index and access control lists with other handles.
+func newFileStoreHandle(contentRoot gopath.GoPath, authenticator authenticator.Authenticator,
+	keepHistory bool, lockTimeout time.Duration, trashRetention time.Duration, x *indexer,
+	acls *aclCache) *fileStore {
 	var s = newErrStore()
 	var i = newIOUtil(s)
 	var p = newPathIO(contentRoot, s)
 	var m = newMimeDetector(p, s)
-	var a = newAccessControl(authenticator, p, s)
-	return &fileStore{s, i, p, m, a}
+	var a = newAccessControl(authenticator, acls, p, s)
+	var k = newLocker(lockTimeout, s)
+	var h = newHistory(authenticator, p, s, keepHistory)
+	var t = newTrash(authenticator, trashRetention, p, s)
+	var l = newLister(p, m, a, s)
+	var r = newSearcher(x, l, p, m, a, s)
+	return &fileStore{s, i, p, m, a, k, h, t, x, r, l}
+}
+
+// Handle returns a new handle on the same content root, whose Err() value is
+// independent from this one's.
+// The result also implements this package's Store interface.
+func (f *fileStore) Handle() store.Store {
+	return newFileStoreHandle(f.contentRoot, f.accessControl.authenticator, f.history.enabled,
+		f.locker.timeout, f.trash.retention, f.indexer, f.acls)
 }
 
 // Err returns and clears the recorder error.
@@ -39,6 +103,14 @@ func (f *fileStore) Err() error {
 	return f.errAndClear()
 }
 
+// PathForRequest returns the path of the file the request points to.
+// A caller must always check the Err() method.
+func (f *fileStore) PathForRequest(request *http.Request) gopath.GoPath {
+	var p = f.pathFromRequest(request)
+	f.assertPathValidForAnyAccess(p)
+	return f.syncedErrs(p)
+}
+
 func (f *fileStore) MimeTypeForRequest(request *http.Request) string {
 	if f.hasErr() {
 		return ""
@@ -70,6 +142,18 @@ func (f *fileStore) ModTimeForRequest(request *http.Request) time.Time {
 	return p.FileInfo().ModTime()
 }
 
+// URLPathForRequest returns the URL path of the file the request points to,
+// or sets the Err() value.
+func (f *fileStore) URLPathForRequest(request *http.Request) string {
+	p := f.pathFromRequest(request)
+	if p.HasErr() {
+		f.setErr(p.Err())
+		return ""
+	}
+
+	return f.urlPathForPath(p)
+}
+
 // ReadString returns the requested content as string.
 // A caller must always check the Err() method.
 func (f *fileStore) ReadString(request *http.Request) string {
@@ -88,18 +172,31 @@ func (f *fileStore) WriteString(request *http.Request, content string) {
 	f.writeAllAndClose(f.OpenWriter(request), content)
 }
 
+// WriteStringIfUnchanged writes the given content into a file pointed to by
+// the request, unless its current content has another hash than expected.
+// A caller must always check the Err() method.
+func (f *fileStore) WriteStringIfUnchanged(request *http.Request, content string,
+	expectedHash string) {
+	if f.hasErr() {
+		return
+	}
+	f.writeAllAndClose(f.OpenWriterIfUnchanged(request, expectedHash), content)
+}
+
 // OpenReader opens a reader for the given request.
 // A caller must close the reader after using it.
 // Also, he must always check the Err() method.
 //
 // The method handles access control.
+// Reading fails as soon as the request's context is done.
 func (f *fileStore) OpenReader(request *http.Request) io.ReadCloser {
 	if f.hasErr() {
 		return nil
 	}
 	f.assertPathExists(f.pathFromRequest(request))
 	f.assertHasReadAccessForRequest(request)
-	return f.openReaderAtPath(f.pathFromRequest(request))
+	f.assertNotCanceled(request)
+	return newCancelableReader(request, f.openReaderAtPath(f.pathFromRequest(request)))
 }
 
 // OpenWriter opens a writer for the given request.
@@ -107,15 +204,58 @@ func (f *fileStore) OpenReader(request *http.Request) io.ReadCloser {
 // Also, he must always check the Err() method.
 //
 // The method handles access control.
+// Any previous content is kept as a revision.
+// The file keeps its previous content until the writer is closed; when
+// writing fails, e.g. because the request's context is done, it's kept
+// altogether, and no revision is recorded.
+// Other writers of the same file wait until the writer is closed.
 func (f *fileStore) OpenWriter(request *http.Request) io.WriteCloser {
+	return f.openWriter(request, nil)
+}
+
+// OpenWriterIfUnchanged opens a writer like OpenWriter does, but records a
+// ConflictError unless the file's current content has the expected hash.
+// The content is checked while holding the file's lock.
+func (f *fileStore) OpenWriterIfUnchanged(request *http.Request, expectedHash string) io.WriteCloser {
+	return f.openWriter(request, func(p gopath.GoPath) {
+		f.assertContentHash(p, expectedHash)
+	})
+}
+
+// OpenWriterIfMissing opens a writer like OpenWriter does, but records a
+// ConflictError when the file already exists.
+// The file is checked while holding its lock.
+func (f *fileStore) OpenWriterIfMissing(request *http.Request) io.WriteCloser {
+	return f.openWriter(request, f.assertFileMissing)
+}
+
+// openWriter opens a writer for the given request.
+// Unless nil, the given function checks the file while its lock is held.
+func (f *fileStore) openWriter(request *http.Request, assertLocked func(gopath.GoPath)) io.WriteCloser {
 	if f.hasErr() {
 		return nil
 	}
 	f.assertHasWriteAccessForRequest(request)
-	return f.openWriterAtPath(f.pathFromRequest(request))
+	f.assertNotCanceled(request)
+	var p = f.pathFromRequest(request)
+	f.assertPathValidForAnyAccess(p)
+	var locks = f.lockPaths(request.Context(), p)
+	if assertLocked != nil && !f.hasErr() {
+		assertLocked(p)
+	}
+	var revision = f.recordRevision(request, p)
+	var writer = f.openWriterAtPath(request.Context(), p)
+	if f.hasErr() {
+		discardRevision(revision)
+		locks.release()
+		return nil
+	}
+	return f.indexOnClose(p, &unlockingWriter{f.discardRevisionOnClose(revision, writer), locks})
 }
 
 // Delete will delete the file or directory pointed to by the request.
+// Regular files are moved into the trash, while directories and symlinks are
+// removed right away.
 // A caller must always check the Err() method.
 func (f *fileStore) Delete(request *http.Request) {
 	if f.hasErr() {
@@ -129,6 +269,215 @@ func (f *fileStore) Delete(request *http.Request) {
 		return
 	}
 
-	var err = os.Remove(p.Path())
-	f.setErr(err)
+	var locks = f.lockPaths(request.Context(), p)
+	defer locks.release()
+	if f.hasErr() {
+		return
+	}
+	if info, err := os.Lstat(p.Path()); err == nil && info.Mode().IsRegular() {
+		f.moveToTrash(request, p)
+	} else {
+		f.setErr(os.Remove(p.Path()))
+	}
+	if !f.hasErr() {
+		f.removeFromIndexForPath(p)
+	}
+}
+
+// List returns the readable entries of the directory pointed to by the
+// request.
+// A caller must always check the Err() method.
+func (f *fileStore) List(request *http.Request) []store.Entry {
+	if f.hasErr() {
+		return nil
+	}
+	var dir = f.directoryFromRequest(request)
+	f.assertPathValidForAnyAccess(dir)
+	f.assertHasReadAccessForPath(request, dir)
+	return f.listDirectory(request, dir)
+}
+
+// Walk calls walkFn for each readable entry below the directory pointed to
+// by the request.
+// A caller must always check the Err() method.
+func (f *fileStore) Walk(request *http.Request, walkFn store.WalkFunc) {
+	if f.hasErr() {
+		return
+	}
+	var dir = f.directoryFromRequest(request)
+	f.assertPathValidForAnyAccess(dir)
+	f.assertHasReadAccessForPath(request, dir)
+	f.walkDirectory(request, dir, walkFn)
+}
+
+// Backlinks lists the readable pages linking to the file pointed to by the
+// request.
+// A caller must always check the Err() method.
+func (f *fileStore) Backlinks(request *http.Request) []string {
+	if f.hasErr() {
+		return nil
+	}
+	f.assertHasReadAccessForRequest(request)
+	var p = f.pathFromRequest(request)
+	f.assertPathValidForAnyAccess(p)
+	return f.backlinksForPath(request, p)
+}
+
+// IndexedBacklinks lists the readable pages linking to the file pointed to
+// by the request, as long as the search index is active.
+// A caller must always check the Err() method.
+func (f *fileStore) IndexedBacklinks(request *http.Request) []string {
+	if f.hasErr() {
+		return nil
+	}
+	f.assertHasReadAccessForRequest(request)
+	var p = f.pathFromRequest(request)
+	f.assertPathValidForAnyAccess(p)
+	return f.indexedBacklinksForPath(request, p)
+}
+
+// Revisions lists the former versions of the file pointed to by the request,
+// newest first.
+// A caller must always check the Err() method.
+func (f *fileStore) Revisions(request *http.Request) []store.Revision {
+	if f.hasErr() {
+		return nil
+	}
+	f.assertHasReadAccessForRequest(request)
+	var p = f.pathFromRequest(request)
+	f.assertPathValidForAnyAccess(p)
+	return f.revisionsForPath(p)
+}
+
+// OpenRevisionReader opens a reader for the content of the given revision.
+// A caller must close the reader after using it.
+// Also, he must always check the Err() method.
+func (f *fileStore) OpenRevisionReader(request *http.Request, revisionID string) io.ReadCloser {
+	if f.hasErr() {
+		return nil
+	}
+	f.assertHasReadAccessForRequest(request)
+	var p = f.pathFromRequest(request)
+	f.assertPathValidForAnyAccess(p)
+	return f.openRevisionReaderAtPath(p, revisionID)
+}
+
+// RestoreRevision replaces the content of the file pointed to by the request
+// with the content of the given revision.
+// A caller must always check the Err() method.
+func (f *fileStore) RestoreRevision(request *http.Request, revisionID string) {
+	if f.hasErr() {
+		return
+	}
+	var content = f.readAllAndClose(f.OpenRevisionReader(request, revisionID))
+	f.WriteString(request, content)
+}
+
+// TrashItems lists the deleted files that were located below the directory
+// pointed to by the request, and that the user may create again, newest
+// first.
+// A caller must always check the Err() method.
+func (f *fileStore) TrashItems(request *http.Request) []store.TrashItem {
+	if f.hasErr() {
+		return nil
+	}
+	var dir = f.directoryFromRequest(request)
+	f.assertPathValidForAnyAccess(dir)
+	f.assertHasReadAccessForPath(request, dir)
+	f.purgeExpiredTrashItems()
+	var items = f.allTrashItems()
+	if f.hasErr() {
+		return nil
+	}
+
+	var result = make([]store.TrashItem, 0, len(items))
+	for _, item := range items {
+		if isBelowURLPath(item.Path, f.urlPathForPath(dir)) &&
+			f.HasWriteAccessForRequest(requestForURLPath(request, item.Path)) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// RestoreTrashItem moves the given deleted file back to where it was
+// located, which must be below the directory pointed to by the request.
+// Restoring requires the same access as creating the file.
+// A caller must always check the Err() method.
+func (f *fileStore) RestoreTrashItem(request *http.Request, itemID string) string {
+	if f.hasErr() {
+		return ""
+	}
+	var item = f.trashItemBelowRequest(request, itemID)
+	var p = f.contentRoot.JoinPath(item.Path).Do(f.normalizePath)
+	f.assertPathValidForAnyAccess(p)
+	if f.hasErr() {
+		return ""
+	}
+
+	var locks = f.lockPaths(request.Context(), p)
+	defer locks.release()
+	f.assertPathDoesNotExist(p)
+	f.moveFromTrash(itemID, p)
+	if f.hasErr() {
+		return ""
+	}
+	f.updateIndexForPath(p)
+	return item.Path
+}
+
+// PurgeTrashItem deletes the given deleted file for good.
+// Its original location must be below the directory pointed to by the
+// request.
+// Purging requires the same access as restoring.
+// A caller must always check the Err() method.
+func (f *fileStore) PurgeTrashItem(request *http.Request, itemID string) {
+	if f.hasErr() {
+		return
+	}
+	f.trashItemBelowRequest(request, itemID)
+	f.purgeTrashItemWithID(itemID)
+}
+
+// trashItemBelowRequest returns the given trash item, after asserting that
+// it was located below the directory pointed to by the request, and that the
+// user may create it again.
+func (f *fileStore) trashItemBelowRequest(request *http.Request, itemID string) store.TrashItem {
+	var dir = f.directoryFromRequest(request)
+	f.assertPathValidForAnyAccess(dir)
+	var item = f.trashItem(itemID)
+	if f.hasErr() {
+		return item
+	}
+	if !isBelowURLPath(item.Path, f.urlPathForPath(dir)) {
+		f.setErr(store.NewPathNotFoundError(
+			fmt.Sprintf("trash item %s was not located below %s", itemID, dir)))
+		return item
+	}
+	f.assertHasWriteAccessForRequest(requestForURLPath(request, item.Path))
+	return item
+}
+
+// StartIndex builds the search index and starts watching the content root
+// for changes.
+// Any error is returned right away; the store then keeps searching without
+// an index.
+func (f *fileStore) StartIndex() error {
+	return f.startIndex()
+}
+
+// Search finds all readable Markdown and text files below the directory the
+// request points to that match the query, best matches first.
+// A caller must always check the Err() method.
+func (f *fileStore) Search(request *http.Request, query string) []store.SearchResult {
+	if f.hasErr() {
+		return nil
+	}
+	return f.searchBelowPath(request, f.searchDirFromRequest(request), query)
+}
+
+// isBelowURLPath returns true iff the URL path denotes an entry of the given
+// directory or of any directory below it.
+func isBelowURLPath(urlPath string, dirURLPath string) bool {
+	return strings.HasPrefix(urlPath, strings.TrimSuffix(dirURLPath, "/")+"/")
 }
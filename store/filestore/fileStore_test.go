@@ -1,6 +1,13 @@
 package filestore
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
 	"testing"
 
 	"github.com/fxnn/gone/store"
@@ -71,3 +78,156 @@ func TestReadInHiddenDirReturnsPathNotFoundError(t *testing.T) {
 		t.Fatalf("expected PathNotFoundError, but got %v", err)
 	}
 }
+
+func TestHandlesKeepTheirErrorsApart(t *testing.T) {
+	tmpdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(tmpdir)
+	writeTestFile(t, path.Join(tmpdir, "page.md"), "content")
+
+	sut := sutAuthenticated(t)
+	failing, succeeding := sut.Handle(), sut.Handle()
+
+	closed(failing.OpenReader(requestGET("/" + tmpdir + "/missing.md")))
+	if content := succeeding.ReadString(requestGET("/" + tmpdir + "/page.md")); content != "content" {
+		t.Fatalf("expected content, got %q", content)
+	}
+	if err := succeeding.Err(); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if err := failing.Err(); !store.IsPathNotFoundError(err) {
+		t.Fatalf("expected PathNotFoundError, got %v", err)
+	}
+}
+
+// Run with -race to detect data races between handles.
+func TestHandlesMayBeUsedConcurrently(t *testing.T) {
+	tmpdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(tmpdir)
+
+	sut := sutAuthenticated(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		var pagePath = fmt.Sprintf("/%s/page%d.md", tmpdir, i)
+		var missingPath = fmt.Sprintf("/%s/missing%d.md", tmpdir, i)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			var s = sut.Handle()
+			s.WriteString(requestGET(pagePath), pagePath)
+			var content = s.ReadString(requestGET(pagePath))
+			s.List(requestGET("/" + tmpdir + "/"))
+			if err := s.Err(); err != nil {
+				t.Errorf("expected no error on %s, got %s", pagePath, err)
+			} else if content != pagePath {
+				t.Errorf("expected content %q, got %q", pagePath, content)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			var s = sut.Handle()
+			closed(s.OpenReader(requestGET(missingPath)))
+			if err := s.Err(); !store.IsPathNotFoundError(err) {
+				t.Errorf("expected PathNotFoundError on %s, got %v", missingPath, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestReadingStopsWhenRequestIsCanceled(t *testing.T) {
+	tmpdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(tmpdir)
+	writeTestFile(t, path.Join(tmpdir, "page.md"), "content")
+
+	sut := sutAuthenticated(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	request := requestGET("/" + tmpdir + "/page.md").WithContext(ctx)
+	readCloser := sut.OpenReader(request)
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to open reader: %s", err)
+	}
+	defer readCloser.Close()
+
+	cancel()
+	if _, err := ioutil.ReadAll(readCloser); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	sut.OpenReader(request)
+	if err := sut.Err(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestWriteIfUnchangedDeniesWhenFileWasChanged(t *testing.T) {
+	tmpdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(tmpdir)
+	defer os.RemoveAll(historyDirectoryName)
+	var file = path.Join(tmpdir, "page.md")
+	writeTestFile(t, file, "their content")
+
+	sut := sutAuthenticated(t)
+	sut.WriteStringIfUnchanged(requestGET("/"+file), "my content", store.ContentHash("old content"))
+	if err := sut.Err(); !store.IsConflictError(err) {
+		t.Fatalf("expected ConflictError, got %v", err)
+	}
+	assertFileContent(t, file, "their content")
+
+	sut.WriteStringIfUnchanged(requestGET("/"+file), "my content", store.ContentHash("their content"))
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to write unchanged file: %s", err)
+	}
+	assertFileContent(t, file, "my content")
+}
+
+func TestWriteIfUnchangedCreatesFileExpectedToBeEmpty(t *testing.T) {
+	tmpdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(tmpdir)
+	var file = path.Join(tmpdir, "page.md")
+
+	sut := sutAuthenticated(t)
+	sut.WriteStringIfUnchanged(requestGET("/"+file), "content", store.ContentHash(""))
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to create file: %s", err)
+	}
+	assertFileContent(t, file, "content")
+}
+
+func TestWriteIfMissingDeniesWhenFileExists(t *testing.T) {
+	tmpdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(tmpdir)
+	defer os.RemoveAll(historyDirectoryName)
+	var file = path.Join(tmpdir, "image.png")
+
+	sut := sutAuthenticated(t)
+	writer := sut.OpenWriterIfMissing(requestGET("/" + file))
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to create file: %s", err)
+	}
+	writer.Write([]byte("first"))
+	writer.Close()
+
+	sut.OpenWriterIfMissing(requestGET("/" + file))
+	if err := sut.Err(); !store.IsConflictError(err) {
+		t.Fatalf("expected ConflictError, got %v", err)
+	}
+	assertFileContent(t, file, "first")
+}
+
+func TestURLPathForRequestGuessesExtensionAndIndex(t *testing.T) {
+	tmpdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(tmpdir)
+	writeTestFile(t, path.Join(tmpdir, "image.png"), "content")
+	writeTestFile(t, path.Join(tmpdir, "index.md"), "content")
+
+	sut := sutAuthenticated(t)
+	if actual := sut.URLPathForRequest(requestGET("/" + tmpdir + "/image")); actual != "/"+tmpdir+"/image.png" {
+		t.Fatalf("expected URL path with extension, but got %s", actual)
+	}
+	if actual := sut.URLPathForRequest(requestGET("/" + tmpdir + "/")); actual != "/"+tmpdir+"/index.md" {
+		t.Fatalf("expected URL path of index document, but got %s", actual)
+	}
+	if err := sut.Err(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,295 @@
+package filestore
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/fxnn/gone/authenticator"
+	"github.com/fxnn/gone/log"
+	"github.com/fxnn/gone/store"
+	"github.com/fxnn/gopath"
+)
+
+const (
+	// historyDirectoryName is the directory inside the content root that
+	// keeps the revisions.
+	// Being a hidden file, it's never delivered over HTTP.
+	historyDirectoryName = ".history"
+	revisionIDFormat     = "20060102T150405.000000000Z"
+	revisionMetaExt      = ".meta"
+)
+
+var revisionIDRegexp = regexp.MustCompile(`^[0-9]{8}T[0-9]{6}\.[0-9]{9}Z$`)
+
+// revisionMeta is what we store alongside each revision's content.
+type revisionMeta struct {
+	Author string    `json:"author"`
+	Time   time.Time `json:"time"`
+}
+
+// history keeps former versions of files.
+// For each file, a directory of the same relative path is created below the
+// history directory.
+// It contains one file per revision, named after the revision ID, and one
+// meta file per revision.
+// A revision is recorded right before its content gets replaced; it's
+// attributed to the user replacing it.
+// When the new content is discarded instead, the revision is removed again.
+//
+// When not enabled, no new revisions are recorded.
+type history struct {
+	authenticator authenticator.Authenticator
+	enabled       bool
+	*pathIO
+	*errStore
+}
+
+func newHistory(a authenticator.Authenticator, p *pathIO, s *errStore, enabled bool) *history {
+	return &history{a, enabled, p, s}
+}
+
+// recordRevision copies the current content of the given file into the
+// history, and returns the path of the revision's content.
+// Does nothing if the file doesn't exist yet or is empty, as there's nothing
+// to lose then; the result is the empty string in that case.
+func (h *history) recordRevision(request *http.Request, p gopath.GoPath) string {
+	if !h.enabled || h.hasErr() || p.HasErr() {
+		return ""
+	}
+	if stat := p.Stat(); stat.HasErr() || !stat.FileMode().IsRegular() ||
+		stat.FileInfo().Size() == 0 {
+		return ""
+	}
+
+	var dir = h.historyDirForPath(p)
+	if h.hasErr() {
+		return ""
+	}
+	h.setErr(os.MkdirAll(dir.Path(), 0700))
+	if h.hasErr() {
+		h.prependErr(fmt.Sprintf("couldn't create history directory for '%s'", p))
+		return ""
+	}
+
+	var now = time.Now().UTC()
+	var revision = dir.JoinPath(now.Format(revisionIDFormat))
+	h.copyFile(p, revision)
+	h.writeRevisionMeta(revision.Append(revisionMetaExt),
+		revisionMeta{h.authenticator.UserID(request), now})
+	h.prependErr(fmt.Sprintf("couldn't record revision of '%s'", p))
+	return revision.Path()
+}
+
+// discardRevisionOnClose wraps the writer, so that the given revision gets
+// discarded when closing the writer drops the new content.
+// Otherwise, each failed write would leave a revision identical to the file.
+func (h *history) discardRevisionOnClose(revision string, writer *atomicWriter) io.WriteCloser {
+	return &revisionDiscardingWriter{writer, revision}
+}
+
+// discardRevision removes a revision recorded by recordRevision, whose
+// content never got replaced.
+// Failures are only logged, as the revision is merely redundant.
+func discardRevision(revision string) {
+	if revision == "" {
+		return
+	}
+	for _, path := range []string{revision, revision + revisionMetaExt} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Warnf("couldn't discard revision: %s", err)
+		}
+	}
+}
+
+// moveRevisions moves the revisions of the given file, so that they belong
+// to the target file.
+func (h *history) moveRevisions(source gopath.GoPath, target gopath.GoPath) {
+	var sourceDir, targetDir = h.historyDirForPath(source), h.historyDirForPath(target)
+	if h.hasErr() {
+		return
+	}
+
+	fileInfos, err := ioutil.ReadDir(sourceDir.Path())
+	if os.IsNotExist(err) {
+		// HINT: No revisions recorded yet
+		return
+	}
+	h.setErr(err)
+	if !h.hasErr() {
+		h.setErr(os.MkdirAll(targetDir.Path(), 0700))
+	}
+	for _, fileInfo := range fileInfos {
+		if h.hasErr() {
+			break
+		}
+		h.setErr(os.Rename(sourceDir.JoinPath(fileInfo.Name()).Path(),
+			targetDir.JoinPath(fileInfo.Name()).Path()))
+	}
+	if !h.hasErr() {
+		h.setErr(os.Remove(sourceDir.Path()))
+	}
+	h.prependErr(fmt.Sprintf("couldn't move revisions of '%s' to '%s'", source, target))
+}
+
+// revisionsForPath lists all revisions of the given file, newest first.
+func (h *history) revisionsForPath(p gopath.GoPath) []store.Revision {
+	var dir = h.historyDirForPath(p)
+	if h.hasErr() {
+		return nil
+	}
+
+	fileInfos, err := ioutil.ReadDir(dir.Path())
+	if os.IsNotExist(err) {
+		// HINT: No revisions recorded yet
+		return []store.Revision{}
+	}
+	h.setErr(err)
+	if h.hasErr() {
+		h.prependErr(fmt.Sprintf("couldn't list revisions of '%s'", p))
+		return nil
+	}
+
+	var result = make([]store.Revision, 0, len(fileInfos)/2)
+	for _, fileInfo := range fileInfos {
+		var id = fileInfo.Name()
+		if !revisionIDRegexp.MatchString(id) {
+			continue
+		}
+		var meta = h.readRevisionMeta(dir.JoinPath(id + revisionMetaExt))
+		if h.hasErr() {
+			h.prependErr(fmt.Sprintf("couldn't read revision %s of '%s'", id, p))
+			return nil
+		}
+		result = append(result, store.Revision{
+			ID:     id,
+			Author: meta.Author,
+			Time:   meta.Time,
+			Size:   fileInfo.Size(),
+		})
+	}
+
+	sort.Sort(sort.Reverse(revisionsByID(result)))
+	return result
+}
+
+// openRevisionReaderAtPath opens the content of the given revision.
+func (h *history) openRevisionReaderAtPath(p gopath.GoPath, revisionID string) io.ReadCloser {
+	var revision = h.revisionPath(p, revisionID)
+	if h.hasErr() {
+		return nil
+	}
+
+	reader, err := os.Open(revision.Path())
+	h.setErr(err)
+	h.prependErr(fmt.Sprintf("couldn't open revision %s of '%s'", revisionID, p))
+
+	return reader
+}
+
+func (h *history) revisionPath(p gopath.GoPath, revisionID string) gopath.GoPath {
+	if h.hasErr() {
+		return gopath.FromErr(h.err)
+	}
+	if !revisionIDRegexp.MatchString(revisionID) {
+		h.setErr(store.NewPathNotFoundError(
+			fmt.Sprintf("'%s' is no valid revision id", revisionID)))
+		return gopath.FromErr(h.err)
+	}
+	return h.syncedErrs(h.historyDirForPath(p).JoinPath(revisionID))
+}
+
+// historyDirForPath returns the directory keeping the revisions of the given
+// file.
+func (h *history) historyDirForPath(p gopath.GoPath) gopath.GoPath {
+	if h.hasErr() {
+		return gopath.FromErr(h.err)
+	}
+	if !h.isPathInsideContentRoot(p) {
+		h.setErr(store.NewPathNotFoundError(
+			fmt.Sprintf("%s is not inside content root %s", p, h.contentRoot)))
+		return gopath.FromErr(h.err)
+	}
+	var rel = h.syncedErrs(h.contentRoot.Rel(h.normalizePath(p)))
+	if h.hasErr() {
+		return rel
+	}
+	return h.contentRoot.JoinPath(historyDirectoryName).Join(rel)
+}
+
+func (h *history) copyFile(source gopath.GoPath, target gopath.GoPath) {
+	if h.hasErr() {
+		return
+	}
+
+	in, err := os.Open(source.Path())
+	if err != nil {
+		h.setErr(err)
+		return
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(target.Path(), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		h.setErr(err)
+		return
+	}
+
+	_, err = io.Copy(out, in)
+	h.setErr(err)
+	if err = out.Close(); !h.hasErr() {
+		h.setErr(err)
+	}
+}
+
+func (h *history) writeRevisionMeta(p gopath.GoPath, meta revisionMeta) {
+	if h.hasErr() {
+		return
+	}
+	content, err := json.Marshal(meta)
+	if err != nil {
+		h.setErr(err)
+		return
+	}
+	h.setErr(ioutil.WriteFile(p.Path(), content, 0600))
+}
+
+func (h *history) readRevisionMeta(p gopath.GoPath) (meta revisionMeta) {
+	if h.hasErr() {
+		return
+	}
+	content, err := ioutil.ReadFile(p.Path())
+	if err != nil {
+		h.setErr(err)
+		return
+	}
+	h.setErr(json.Unmarshal(content, &meta))
+	return
+}
+
+// revisionDiscardingWriter discards the revision recorded when opening it,
+// if closing drops the new content.
+type revisionDiscardingWriter struct {
+	*atomicWriter
+	revision string
+}
+
+func (w *revisionDiscardingWriter) Close() error {
+	var err = w.atomicWriter.Close()
+	if w.discarded {
+		discardRevision(w.revision)
+	}
+	return err
+}
+
+type revisionsByID []store.Revision
+
+func (r revisionsByID) Len() int           { return len(r) }
+func (r revisionsByID) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r revisionsByID) Less(i, j int) bool { return r[i].ID < r[j].ID }
@@ -0,0 +1,84 @@
+package filestore
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/fxnn/gone/store"
+)
+
+func TestWriteRecordsPreviousContentAsRevision(t *testing.T) {
+	tempWd := createTempWdInCurrentwd(t, 0777)
+	defer removeTempWdFromCurrentwd(t, tempWd)
+	defer os.RemoveAll(historyDirectoryName)
+
+	sut := sutAuthenticated(t)
+	request := requestGET("/file.md")
+	defer removeTempFileFromCurrentwd(t, "file.md")
+
+	sut.WriteString(request, "first")
+	sut.WriteString(request, "second")
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to write file: %s", err)
+	}
+
+	revisions := sut.Revisions(request)
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to list revisions: %s", err)
+	}
+	if len(revisions) != 1 {
+		t.Fatalf("expected 1 revision, but got %d", len(revisions))
+	}
+
+	readCloser := sut.OpenRevisionReader(request, revisions[0].ID)
+	defer closed(readCloser)
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to open revision: %s", err)
+	}
+	content, _ := ioutil.ReadAll(readCloser)
+	if string(content) != "first" {
+		t.Fatalf("expected revision content 'first', but got '%s'", content)
+	}
+}
+
+func TestRestoreRevisionReplacesContent(t *testing.T) {
+	tempWd := createTempWdInCurrentwd(t, 0777)
+	defer removeTempWdFromCurrentwd(t, tempWd)
+	defer os.RemoveAll(historyDirectoryName)
+
+	sut := sutAuthenticated(t)
+	request := requestGET("/file.md")
+	defer removeTempFileFromCurrentwd(t, "file.md")
+
+	sut.WriteString(request, "first")
+	sut.WriteString(request, "second")
+	revisions := sut.Revisions(request)
+	if err := sut.Err(); err != nil || len(revisions) != 1 {
+		t.Fatalf("expected 1 revision without error, but got %d: %v", len(revisions), err)
+	}
+
+	sut.RestoreRevision(request, revisions[0].ID)
+	content := sut.ReadString(request)
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to restore revision: %s", err)
+	}
+	if content != "first" {
+		t.Fatalf("expected restored content 'first', but got '%s'", content)
+	}
+	if revisions = sut.Revisions(request); len(revisions) != 2 {
+		t.Fatalf("expected 2 revisions after restore, but got %d", len(revisions))
+	}
+}
+
+func TestOpenRevisionReaderDeniesInvalidRevisionID(t *testing.T) {
+	sut := sutAuthenticated(t)
+
+	readCloser := sut.OpenRevisionReader(requestGET("/file.md"), "../../etc/passwd")
+	closed(readCloser)
+	if err := sut.Err(); err == nil {
+		t.Fatalf("expected error, but got nil")
+	} else if !store.IsPathNotFoundError(err) {
+		t.Fatalf("expected PathNotFoundError, but got %v", err)
+	}
+}
@@ -0,0 +1,340 @@
+package filestore
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/fsnotify.v1"
+
+	"github.com/fxnn/gone/links"
+	"github.com/fxnn/gone/log"
+	"github.com/fxnn/gone/search"
+	"github.com/fxnn/gone/store"
+	"github.com/fxnn/gopath"
+)
+
+const (
+	// indexDirectoryName is the directory inside the content root that
+	// keeps the search index.
+	// Being a hidden file, it's never delivered over HTTP.
+	indexDirectoryName = ".index"
+	indexFileName      = "search.gob"
+
+	// indexSaveDelay is how long changes are collected before the index is
+	// written again.
+	indexSaveDelay = 5 * time.Second
+)
+
+// indexer keeps a search index of all files in the content root.
+// It's inactive until being started; it then loads the persisted index,
+// brings it up to date and keeps it so.
+// Updates come from writes through the store as well as from changes in the
+// file system.
+//
+// As the indexer works in the background, it doesn't record errors in the
+// errStore, but logs them.
+type indexer struct {
+	*pathIO
+	mutex     sync.Mutex
+	index     *search.Index
+	watcher   *fsnotify.Watcher
+	saveTimer *time.Timer
+}
+
+func newIndexer(p *pathIO) *indexer {
+	return &indexer{pathIO: p}
+}
+
+// startIndex builds the index and starts watching the content root.
+func (x *indexer) startIndex() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("couldn't open watcher: %s", err)
+	}
+
+	var index = x.loadIndex()
+	var seen = make(map[string]bool)
+	if err := x.indexDirectory(index, watcher, x.contentRoot, seen); err != nil {
+		watcher.Close()
+		return fmt.Errorf("couldn't build search index: %s", err)
+	}
+	for _, urlPath := range index.Paths() {
+		if !seen[urlPath] {
+			index.Remove(urlPath)
+		}
+	}
+
+	x.mutex.Lock()
+	x.index, x.watcher = index, watcher
+	x.mutex.Unlock()
+
+	x.saveIndex()
+	go x.processEvents(watcher)
+	return nil
+}
+
+// stopIndex stops watching and writes the index a last time.
+func (x *indexer) stopIndex() {
+	x.mutex.Lock()
+	var watcher = x.watcher
+	x.watcher = nil
+	if x.saveTimer != nil {
+		x.saveTimer.Stop()
+		x.saveTimer = nil
+	}
+	x.mutex.Unlock()
+
+	if watcher != nil {
+		watcher.Close()
+		x.saveIndex()
+	}
+}
+
+// activeIndex returns the index, or nil if it wasn't started.
+func (x *indexer) activeIndex() *search.Index {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	return x.index
+}
+
+// updateIndexForPath reindexes the given file after it was written.
+func (x *indexer) updateIndexForPath(p gopath.GoPath) {
+	if index := x.activeIndex(); index != nil {
+		x.indexFile(index, p)
+		x.scheduleSave()
+	}
+}
+
+// removeFromIndexForPath removes the given file or directory after it was
+// deleted.
+func (x *indexer) removeFromIndexForPath(p gopath.GoPath) {
+	if index := x.activeIndex(); index != nil {
+		var urlPath = x.urlPathForPath(p)
+		index.Remove(urlPath)
+		index.RemoveBelow(urlPath)
+		x.scheduleSave()
+	}
+}
+
+// indexOnClose wraps the writer, so that the file gets reindexed when the
+// writer is closed.
+func (x *indexer) indexOnClose(p gopath.GoPath, writer io.WriteCloser) io.WriteCloser {
+	return &indexingWriter{writer, func() { x.updateIndexForPath(p) }}
+}
+
+// indexDirectory watches the given directory and all directories below it,
+// and indexes all files inside them.
+// Hidden files are skipped.
+// The paths of all files found are added to seen.
+func (x *indexer) indexDirectory(index *search.Index, watcher *fsnotify.Watcher,
+	dir gopath.GoPath, seen map[string]bool) error {
+	return filepath.Walk(dir.Path(), func(path string, info os.FileInfo, err error) error {
+		if path == dir.Path() && err != nil {
+			return err
+		}
+		if err != nil {
+			log.Warnf("couldn't index %s: %s", path, err)
+			return nil
+		}
+		if x.isHiddenPath(gopath.FromPath(path)) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return watcher.Add(path)
+		}
+
+		var p = gopath.FromPath(path)
+		seen[x.urlPathForPath(p)] = true
+		x.indexFile(index, p)
+		return nil
+	})
+}
+
+// indexFile adds the given file to the index, unless it's up to date
+// already.
+// Files that can't be searched are removed from the index.
+func (x *indexer) indexFile(index *search.Index, p gopath.GoPath) {
+	var urlPath = x.urlPathForPath(p)
+	var stat = p.Stat()
+	if stat.HasErr() || !stat.FileMode().IsRegular() ||
+		stat.FileInfo().Size() > maxSearchableBytes {
+		index.Remove(urlPath)
+		return
+	}
+
+	var modTime, size = stat.FileInfo().ModTime(), stat.FileInfo().Size()
+	if index.IsUpToDate(urlPath, modTime, size) {
+		return
+	}
+	var mimeType = mime.TypeByExtension(p.Ext())
+	if mimeType != "" && !isSearchableMimeType(mimeType) {
+		index.Remove(urlPath)
+		return
+	}
+
+	content, err := ioutil.ReadFile(p.Path())
+	if err != nil {
+		log.Warnf("couldn't index %s: %s", p, err)
+		index.Remove(urlPath)
+		return
+	}
+	if mimeType == "" {
+		mimeType = http.DetectContentType(content)
+	}
+	if !isSearchableMimeType(mimeType) {
+		index.Remove(urlPath)
+		return
+	}
+
+	var targets []string
+	if strings.HasPrefix(mimeType, store.MarkdownMimeType) {
+		targets = links.Extract(urlPath, content)
+	}
+	index.Add(urlPath, modTime, size, string(content), targets)
+}
+
+func (x *indexer) processEvents(watcher *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				log.Printf("watching content root for search index stopped")
+				return
+			}
+			x.processEvent(watcher, event)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				log.Printf("watching content root for search index stopped")
+				return
+			}
+			log.Printf("error while watching content root for search index: %s", err)
+		}
+	}
+}
+
+// processEvent updates the index for the file the event refers to.
+// The kind of event doesn't matter, as the file's current state is
+// inspected anyway.
+func (x *indexer) processEvent(watcher *fsnotify.Watcher, event fsnotify.Event) {
+	var p = gopath.FromPath(event.Name)
+	var index = x.activeIndex()
+	if index == nil || x.isHiddenPath(p) {
+		return
+	}
+
+	var stat = p.Stat()
+	switch {
+	case stat.HasErr():
+		x.removeFromIndexForPath(p)
+		return
+	case stat.IsDirectory():
+		if event.Op&fsnotify.Create != 0 {
+			if err := x.indexDirectory(index, watcher, p, make(map[string]bool)); err != nil {
+				log.Warnf("couldn't index new directory %s: %s", p, err)
+			}
+		}
+	default:
+		x.indexFile(index, p)
+	}
+	x.scheduleSave()
+}
+
+// scheduleSave writes the index after some delay.
+// Subsequent changes within that delay are written together.
+func (x *indexer) scheduleSave() {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	if x.saveTimer != nil || x.watcher == nil {
+		return
+	}
+	x.saveTimer = time.AfterFunc(indexSaveDelay, func() {
+		x.mutex.Lock()
+		x.saveTimer = nil
+		x.mutex.Unlock()
+		x.saveIndex()
+	})
+}
+
+func (x *indexer) indexFilePath() gopath.GoPath {
+	return x.contentRoot.JoinPath(indexDirectoryName).JoinPath(indexFileName)
+}
+
+// loadIndex reads the persisted index.
+// When there's none or it can't be read, an empty index is returned.
+func (x *indexer) loadIndex() *search.Index {
+	var p = x.indexFilePath()
+	file, err := os.Open(p.Path())
+	if os.IsNotExist(err) {
+		return search.NewIndex()
+	}
+	if err != nil {
+		log.Warnf("couldn't open search index %s, building a new one: %s", p, err)
+		return search.NewIndex()
+	}
+	defer file.Close()
+
+	index, err := search.ReadIndex(file)
+	if err != nil {
+		log.Warnf("couldn't read search index %s, building a new one: %s", p, err)
+		return search.NewIndex()
+	}
+	return index
+}
+
+// saveIndex persists the index.
+// It's first written to a temporary file, which then replaces the old one.
+func (x *indexer) saveIndex() {
+	var index = x.activeIndex()
+	if index == nil {
+		return
+	}
+
+	var p = x.indexFilePath()
+	if err := x.writeIndex(index, p); err != nil {
+		log.Warnf("couldn't write search index %s: %s", p, err)
+	}
+}
+
+func (x *indexer) writeIndex(index *search.Index, p gopath.GoPath) error {
+	if err := os.MkdirAll(p.Dir().Path(), 0700); err != nil {
+		return err
+	}
+	file, err := ioutil.TempFile(p.Dir().Path(), indexFileName)
+	if err != nil {
+		return err
+	}
+	err = index.Write(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), p.Path())
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
+
+// indexingWriter updates the index when being closed.
+type indexingWriter struct {
+	io.WriteCloser
+	update func()
+}
+
+func (w *indexingWriter) Close() error {
+	var err = w.WriteCloser.Close()
+	w.update()
+	return err
+}
@@ -0,0 +1,93 @@
+package filestore
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/fxnn/gone/authenticator"
+)
+
+func startedIndexSut(t *testing.T) *fileStore {
+	sut := newFileStore(getwdPath(t), authenticator.NewAlwaysAuthenticated(), false, testLockTimeout, testTrashRetention)
+	if err := sut.StartIndex(); err != nil {
+		t.Fatalf("failed to start index: %s", err)
+	}
+	return sut
+}
+
+func TestIndexIsUpdatedByWritesAndDeletes(t *testing.T) {
+	tempWd := createTempWdInCurrentwd(t, 0777)
+	defer removeTempWdFromCurrentwd(t, tempWd)
+	defer os.RemoveAll(indexDirectoryName)
+	defer os.RemoveAll(TrashDirectoryName)
+
+	writeSearchFixture(t, "existing.md", "a wiki", 0644)
+	defer removeTempFileFromCurrentwd(t, "existing.md")
+
+	sut := startedIndexSut(t)
+	defer sut.stopIndex()
+	if paths := sut.activeIndex().Lookup("wiki"); len(paths) != 1 || paths[0] != "/existing.md" {
+		t.Fatalf("expected existing file to be indexed, but got %v", paths)
+	}
+
+	request := requestGET("/new.md")
+	sut.WriteString(request, "another wiki")
+	if results := sut.Search(requestGET("/"), "another"); len(results) != 1 || results[0].Path != "/new.md" {
+		t.Fatalf("expected written file to be found, but got %v", results)
+	}
+
+	sut.Delete(request)
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to write and delete: %s", err)
+	}
+	if paths := sut.activeIndex().Lookup("another"); len(paths) != 0 {
+		t.Fatalf("expected deleted file to be removed from index, but got %v", paths)
+	}
+}
+
+func TestIndexIsPersisted(t *testing.T) {
+	tempWd := createTempWdInCurrentwd(t, 0777)
+	defer removeTempWdFromCurrentwd(t, tempWd)
+	defer os.RemoveAll(indexDirectoryName)
+
+	writeSearchFixture(t, "file.md", "a wiki", 0644)
+	defer removeTempFileFromCurrentwd(t, "file.md")
+
+	startedIndexSut(t).stopIndex()
+	if _, err := os.Stat(indexFilePath(t)); err != nil {
+		t.Fatalf("expected index to be written: %s", err)
+	}
+
+	sut := newFileStore(getwdPath(t), authenticator.NewAlwaysAuthenticated(), false, testLockTimeout, testTrashRetention)
+	if paths := sut.loadIndex().Paths(); len(paths) != 1 || paths[0] != "/file.md" {
+		t.Fatalf("expected persisted index to contain /file.md, but got %v", paths)
+	}
+}
+
+func TestIndexIsUpdatedByExternalChanges(t *testing.T) {
+	tempWd := createTempWdInCurrentwd(t, 0777)
+	defer removeTempWdFromCurrentwd(t, tempWd)
+	defer os.RemoveAll(indexDirectoryName)
+
+	sut := startedIndexSut(t)
+	defer sut.stopIndex()
+
+	if err := ioutil.WriteFile("external.md", []byte("changed outside"), 0644); err != nil {
+		t.Fatalf("couldnt write file: %s", err)
+	}
+	defer removeTempFileFromCurrentwd(t, "external.md")
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		if paths := sut.activeIndex().Lookup("outside"); len(paths) == 1 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected external change to be indexed")
+}
+
+func indexFilePath(t *testing.T) string {
+	return getwdPath(t).JoinPath(indexDirectoryName).JoinPath(indexFileName).Path()
+}
@@ -29,6 +29,9 @@ func (i *ioUtil) writeAllAndClose(writeCloser io.WriteCloser, content string) {
 		return
 	}
 	_, err := io.WriteString(writeCloser, content)
+	// HINT: closing makes the content durable, which might fail as well
+	if closeErr := writeCloser.Close(); err == nil {
+		err = closeErr
+	}
 	i.setErr(err)
-	writeCloser.Close()
 }
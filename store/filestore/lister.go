@@ -0,0 +1,116 @@
+package filestore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/fxnn/gone/store"
+	"github.com/fxnn/gopath"
+)
+
+// lister enumerates the contents of directories.
+// Hidden entries and entries the user may not read are skipped.
+type lister struct {
+	*errStore
+	*pathIO
+	*mimeDetector
+	*accessControl
+}
+
+func newLister(p *pathIO, m *mimeDetector, a *accessControl, s *errStore) *lister {
+	return &lister{s, p, m, a}
+}
+
+// listDirectory returns the entries of the given directory, directories
+// first, each ordered by name.
+func (l *lister) listDirectory(request *http.Request, dir gopath.GoPath) []store.Entry {
+	l.assertNotCanceled(request)
+	if l.hasErr() {
+		return nil
+	}
+
+	fileInfos, err := ioutil.ReadDir(dir.Path())
+	l.setErr(err)
+	if l.hasErr() {
+		l.prependErr(fmt.Sprintf("couldn't list directory '%s'", dir))
+		return nil
+	}
+
+	var result = make([]store.Entry, 0, len(fileInfos))
+	for _, fileInfo := range fileInfos {
+		if strings.HasPrefix(fileInfo.Name(), ".") {
+			continue
+		}
+		// HINT: Stat follows symlinks, so that we see what's delivered
+		var p = dir.JoinPath(fileInfo.Name()).Stat()
+		if p.HasErr() || !l.hasReadAccessForPath(request, p) {
+			continue
+		}
+		result = append(result, store.Entry{
+			Name:        fileInfo.Name(),
+			Path:        l.urlPathForPath(p),
+			IsDirectory: p.IsDirectory(),
+			Size:        p.FileInfo().Size(),
+			ModTime:     p.FileInfo().ModTime(),
+			MimeType:    l.mimeTypeForPath(p),
+		})
+	}
+
+	sort.Sort(entriesByName(result))
+	return result
+}
+
+type entriesByName []store.Entry
+
+func (e entriesByName) Len() int      { return len(e) }
+func (e entriesByName) Swap(i, j int) { e[i], e[j] = e[j], e[i] }
+func (e entriesByName) Less(i, j int) bool {
+	if e[i].IsDirectory != e[j].IsDirectory {
+		return e[i].IsDirectory
+	}
+	return e[i].Name < e[j].Name
+}
+
+// walkDirectory calls walkFn for each entry below the given directory.
+func (l *lister) walkDirectory(request *http.Request, dir gopath.GoPath, walkFn store.WalkFunc) {
+	for _, entry := range l.listDirectory(request, dir) {
+		l.assertNotCanceled(request)
+		if l.hasErr() {
+			return
+		}
+
+		var err = walkFn(entry)
+		if err == store.SkipDir {
+			continue
+		}
+		if err != nil {
+			l.setErr(err)
+			return
+		}
+
+		var p = dir.JoinPath(entry.Name)
+		if entry.IsDirectory && !isSymlink(p) && isReadableDirectory(p) {
+			l.walkDirectory(request, p, walkFn)
+		}
+	}
+}
+
+func isSymlink(p gopath.GoPath) bool {
+	fileInfo, err := os.Lstat(p.Path())
+	return err == nil && fileInfo.Mode()&os.ModeSymlink != 0
+}
+
+// isReadableDirectory returns true iff this process may list the directory.
+// Directories it can't are left out when walking, just like files.
+func isReadableDirectory(p gopath.GoPath) bool {
+	file, err := os.Open(p.Path())
+	if err != nil {
+		return false
+	}
+	file.Close()
+	return true
+}
@@ -0,0 +1,165 @@
+package filestore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fxnn/gone/store"
+)
+
+func TestListReturnsDirectoriesFirstAndSkipsHiddenEntries(t *testing.T) {
+	tempWd := createTempWdInCurrentwd(t, 0777)
+	defer removeTempWdFromCurrentwd(t, tempWd)
+
+	writeSearchFixture(t, "b.md", "content", 0644)
+	defer removeTempFileFromCurrentwd(t, "b.md")
+	writeSearchFixture(t, ".hidden", "content", 0644)
+	defer removeTempFileFromCurrentwd(t, ".hidden")
+	if err := os.Mkdir("z", 0755); err != nil {
+		t.Fatalf("couldnt create directory: %s", err)
+	}
+	defer removeTempDirFromCurrentwd(t, "z")
+
+	sut := sutAuthenticated(t)
+	entries := sut.List(requestGET("/"))
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to list: %s", err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, but got %v", entries)
+	}
+	if entries[0].Path != "/z" || !entries[0].IsDirectory || entries[0].MimeType != store.DirectoryMimeType {
+		t.Fatalf("expected directory /z first, but got %v", entries[0])
+	}
+	if entries[1].Path != "/b.md" || entries[1].Size != 7 || !strings.HasPrefix(entries[1].MimeType, store.MarkdownMimeType) {
+		t.Fatalf("expected file /b.md second, but got %v", entries[1])
+	}
+}
+
+func TestListSkipsEntriesWithoutWorldReadPermission(t *testing.T) {
+	skipOnWindows(t)
+	tempWd := createTempWdInCurrentwd(t, 0777)
+	defer removeTempWdFromCurrentwd(t, tempWd)
+
+	writeSearchFixture(t, "public.md", "content", 0644)
+	defer removeTempFileFromCurrentwd(t, "public.md")
+	writeSearchFixture(t, "private.md", "content", 0640)
+	defer removeTempFileFromCurrentwd(t, "private.md")
+
+	sut := sutNotAuthenticated(t)
+	entries := sut.List(requestGET("/"))
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to list: %s", err)
+	}
+
+	if len(entries) != 1 || entries[0].Path != "/public.md" {
+		t.Fatalf("expected only /public.md, but got %v", entries)
+	}
+}
+
+func TestListOfFileReturnsPathNotFoundError(t *testing.T) {
+	tempWd := createTempWdInCurrentwd(t, 0777)
+	defer removeTempWdFromCurrentwd(t, tempWd)
+
+	writeSearchFixture(t, "file.md", "content", 0644)
+	defer removeTempFileFromCurrentwd(t, "file.md")
+
+	sut := sutAuthenticated(t)
+	sut.List(requestGET("/file.md"))
+	if err := sut.Err(); !store.IsPathNotFoundError(err) {
+		t.Fatalf("expected PathNotFoundError, but got %v", err)
+	}
+}
+
+func TestWalkVisitsDirectoriesBeforeTheirContents(t *testing.T) {
+	tempWd := createTempWdInCurrentwd(t, 0777)
+	defer removeTempWdFromCurrentwd(t, tempWd)
+
+	if err := os.MkdirAll("dir/sub", 0755); err != nil {
+		t.Fatalf("couldnt create directories: %s", err)
+	}
+	defer os.RemoveAll("dir")
+	if err := os.Mkdir(".hidden", 0755); err != nil {
+		t.Fatalf("couldnt create directory: %s", err)
+	}
+	defer removeTempDirFromCurrentwd(t, ".hidden")
+	writeSearchFixture(t, "dir/sub/file.md", "content", 0644)
+	writeSearchFixture(t, "top.md", "content", 0644)
+	defer removeTempFileFromCurrentwd(t, "top.md")
+
+	sut := sutAuthenticated(t)
+	var visited []string
+	sut.Walk(requestGET("/"), func(entry store.Entry) error {
+		visited = append(visited, entry.Path)
+		return nil
+	})
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to walk: %s", err)
+	}
+
+	if strings.Join(visited, ",") != "/dir,/dir/sub,/dir/sub/file.md,/top.md" {
+		t.Fatalf("unexpected walk order %v", visited)
+	}
+}
+
+func TestWalkSkipsDirectoriesAndStopsOnError(t *testing.T) {
+	tempWd := createTempWdInCurrentwd(t, 0777)
+	defer removeTempWdFromCurrentwd(t, tempWd)
+
+	if err := os.Mkdir("dir", 0755); err != nil {
+		t.Fatalf("couldnt create directory: %s", err)
+	}
+	defer os.RemoveAll("dir")
+	writeSearchFixture(t, "dir/file.md", "content", 0644)
+	writeSearchFixture(t, "top.md", "content", 0644)
+	defer removeTempFileFromCurrentwd(t, "top.md")
+
+	sut := sutAuthenticated(t)
+	var visited []string
+	var stop = errors.New("stop")
+	sut.Walk(requestGET("/"), func(entry store.Entry) error {
+		visited = append(visited, entry.Path)
+		if entry.IsDirectory {
+			return store.SkipDir
+		}
+		return stop
+	})
+
+	if err := sut.Err(); err != stop {
+		t.Fatalf("expected error from walk function, but got %v", err)
+	}
+	if strings.Join(visited, ",") != "/dir,/top.md" {
+		t.Fatalf("unexpected walk order %v", visited)
+	}
+}
+
+func TestWalkStopsWhenRequestIsCanceled(t *testing.T) {
+	tempWd := createTempWdInCurrentwd(t, 0777)
+	defer removeTempWdFromCurrentwd(t, tempWd)
+
+	writeSearchFixture(t, "a.md", "content", 0644)
+	defer removeTempFileFromCurrentwd(t, "a.md")
+	writeSearchFixture(t, "b.md", "content", 0644)
+	defer removeTempFileFromCurrentwd(t, "b.md")
+
+	sut := sutAuthenticated(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var visited []string
+	sut.Walk(requestGET("/").WithContext(ctx), func(entry store.Entry) error {
+		visited = append(visited, entry.Path)
+		cancel()
+		return nil
+	})
+
+	if err := sut.Err(); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, but got %v", err)
+	}
+	if strings.Join(visited, ",") != "/a.md" {
+		t.Fatalf("expected walk to stop after /a.md, but visited %v", visited)
+	}
+}
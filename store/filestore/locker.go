@@ -0,0 +1,213 @@
+package filestore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fxnn/gone/store"
+	"github.com/fxnn/gopath"
+)
+
+// LockFilePattern matches the names of the lock files created next to the
+// files being written, in the syntax of filepath.Match and gitignore.
+const LockFilePattern = ".*.lock"
+
+// lockRetryInterval is the time waited between two attempts to acquire a
+// lock held by someone else.
+const lockRetryInterval = 10 * time.Millisecond
+
+// locker serializes writers of the same file by advisory locks, which are
+// also respected by other processes.
+//
+// A file isn't locked itself, as writing replaces it by a new one.
+// Instead, each file gets a hidden lock file next to it, named
+// ".<name>.lock" as matched by LockFilePattern.
+// Symlinks are resolved first, so that writers of a symlink and of its target
+// lock the same file.
+// Once the file is gone, e.g. because it was deleted or moved, its lock file
+// is removed as well.
+type locker struct {
+	*errStore
+	timeout time.Duration
+}
+
+func newLocker(timeout time.Duration, s *errStore) *locker {
+	return &locker{s, timeout}
+}
+
+// fileLock is the lock held on a file.
+type fileLock struct {
+	file *os.File
+	// path is the path of the locked file, with symlinks resolved.
+	path string
+}
+
+// fileLocks are the locks held on one or more files.
+type fileLocks []fileLock
+
+// lockPaths locks all given files, waiting at most for the lock timeout.
+// Files are locked in a fixed order, so that two callers locking the same
+// files can't deadlock.
+// A caller must release the result, even when an error occured.
+func (l *locker) lockPaths(ctx context.Context, paths ...gopath.GoPath) fileLocks {
+	if l.hasErr() {
+		return nil
+	}
+
+	var resolvedPaths = make([]string, len(paths))
+	for i, p := range paths {
+		resolvedPaths[i] = resolvedPath(p.Path())
+	}
+	sort.Strings(resolvedPaths)
+
+	var locks = make(fileLocks, 0, len(resolvedPaths))
+	for i, path := range resolvedPaths {
+		if i > 0 && path == resolvedPaths[i-1] {
+			continue
+		}
+		var file, err = l.acquireLock(ctx, lockPathFor(path))
+		if err != nil {
+			l.setErr(err)
+			break
+		}
+		if file != nil {
+			locks = append(locks, fileLock{file, path})
+		}
+	}
+	return locks
+}
+
+// acquireLock opens the lock file and locks it, retrying until the lock
+// timeout passed or the context is done.
+// The result is nil when the lock file can't be created due to missing
+// permissions, as files in such directories can't be replaced anyway.
+func (l *locker) acquireLock(ctx context.Context, lockPath string) (*os.File, error) {
+	var deadline = time.Now().Add(l.timeout)
+	for {
+		var file, err = openLockFile(lockPath)
+		if file == nil || err != nil {
+			return nil, err
+		}
+		if err := l.waitForLock(ctx, file, lockPath, deadline); err != nil {
+			file.Close()
+			return nil, err
+		}
+		if isLockFileAt(file, lockPath) {
+			return file, nil
+		}
+
+		// HINT: the previous holder removed the lock file, so lock the new one
+		unlockFile(file)
+		file.Close()
+	}
+}
+
+// openLockFile opens the lock file, creating it if needed.
+// The result is nil when it can't be created due to missing permissions.
+func openLockFile(lockPath string) (*os.File, error) {
+	var file, err = os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0666)
+	if os.IsPermission(err) {
+		// HINT: locking also works on read-only files
+		file, err = os.Open(lockPath)
+		if os.IsNotExist(err) || os.IsPermission(err) {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// waitForLock locks the open lock file, retrying until the deadline passed
+// or the context is done.
+func (l *locker) waitForLock(ctx context.Context, file *os.File, lockPath string,
+	deadline time.Time) error {
+	for {
+		locked, err := tryLockFile(file)
+		if locked {
+			return nil
+		}
+		if err == nil && time.Now().After(deadline) {
+			err = store.NewLockTimeoutError(fmt.Sprintf(
+				"%s is locked by another writer for more than %s", lockPath, l.timeout))
+		}
+		if err == nil {
+			err = sleepUnlessDone(ctx, lockRetryInterval)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// isLockFileAt returns true iff the open lock file is still the one found at
+// the given path, i.e. it wasn't removed in the meantime.
+func isLockFileAt(file *os.File, lockPath string) bool {
+	var openInfo, err = file.Stat()
+	if err != nil {
+		return false
+	}
+	pathInfo, err := os.Stat(lockPath)
+	return err == nil && os.SameFile(openInfo, pathInfo)
+}
+
+// sleepUnlessDone waits for the given duration, but returns the context's
+// error as soon as it's done.
+func sleepUnlessDone(ctx context.Context, d time.Duration) error {
+	var timer = time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// release unlocks all files.
+// The lock files of files that are gone are removed before, while still
+// being locked, so that anybody waiting for them notices.
+func (locks fileLocks) release() {
+	for _, lock := range locks {
+		if _, err := os.Lstat(lock.path); os.IsNotExist(err) {
+			// HINT: fails on Windows, where open files can't be removed
+			os.Remove(lock.file.Name())
+		}
+		unlockFile(lock.file)
+		lock.file.Close()
+	}
+}
+
+// resolvedPath resolves all symlinks in the given path.
+// When the file doesn't exist, only its directory is resolved.
+func resolvedPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	if dir, err := filepath.EvalSymlinks(filepath.Dir(path)); err == nil {
+		return filepath.Join(dir, filepath.Base(path))
+	}
+	return path
+}
+
+// lockPathFor returns the path of the lock file guarding the given file.
+func lockPathFor(path string) string {
+	return filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".lock")
+}
+
+// unlockingWriter releases the locks when being closed.
+type unlockingWriter struct {
+	io.WriteCloser
+	locks fileLocks
+}
+
+func (w *unlockingWriter) Close() error {
+	defer w.locks.release()
+	return w.WriteCloser.Close()
+}
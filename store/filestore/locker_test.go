@@ -0,0 +1,191 @@
+package filestore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/fxnn/gone/authenticator"
+	"github.com/fxnn/gone/store"
+)
+
+func TestWriteFailsWhenLockIsHeldTooLong(t *testing.T) {
+	tmpdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(tmpdir)
+	var file = path.Join(tmpdir, "page.md")
+	writeTestFile(t, file, "old content")
+	defer holdLock(t, file)()
+
+	sut := New(getwdPath(t), authenticator.NewAlwaysAuthenticated(), 50*time.Millisecond,
+		testTrashRetention)
+	sut.WriteString(requestGET("/"+tmpdir+"/page.md"), "new content")
+	if err := sut.Err(); !store.IsLockTimeoutError(err) {
+		t.Fatalf("expected LockTimeoutError, got %v", err)
+	}
+	sut.Delete(requestGET("/" + tmpdir + "/page.md"))
+	if err := sut.Err(); !store.IsLockTimeoutError(err) {
+		t.Fatalf("expected LockTimeoutError on delete, got %v", err)
+	}
+	sut.Move(requestGET("/"+tmpdir+"/page.md"), "/"+tmpdir+"/other.md", false)
+	if err := sut.Err(); !store.IsLockTimeoutError(err) {
+		t.Fatalf("expected LockTimeoutError on move, got %v", err)
+	}
+	assertFileContent(t, file, "old content")
+}
+
+func TestWritersOfSameFileSerialize(t *testing.T) {
+	tmpdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(tmpdir)
+	defer os.RemoveAll(historyDirectoryName)
+	var request = requestGET("/" + tmpdir + "/page.md")
+
+	sut := sutAuthenticated(t)
+	first, second := sut.Handle(), sut.Handle()
+	writer := first.OpenWriter(request)
+	if err := first.Err(); err != nil {
+		t.Fatalf("failed to open writer: %s", err)
+	}
+
+	var done = make(chan error)
+	go func() {
+		second.WriteString(request, "second")
+		done <- second.Err()
+	}()
+
+	writer.Write([]byte("first"))
+	select {
+	case err := <-done:
+		t.Fatalf("expected second writer to wait, but it finished with %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close writer: %s", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("second writer failed: %s", err)
+	}
+	assertFileContent(t, path.Join(tmpdir, "page.md"), "second")
+}
+
+func TestWaitingForLockStopsWhenRequestIsCanceled(t *testing.T) {
+	tmpdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(tmpdir)
+	var file = path.Join(tmpdir, "page.md")
+	defer holdLock(t, file)()
+
+	sut := New(getwdPath(t), authenticator.NewAlwaysAuthenticated(), time.Minute, testTrashRetention)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	sut.WriteString(requestGET("/"+tmpdir+"/page.md").WithContext(ctx), "content")
+	if err := sut.Err(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestWritersOfSymlinkAndTargetSerialize(t *testing.T) {
+	tmpdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(tmpdir)
+	var file = path.Join(tmpdir, "page.md")
+	writeTestFile(t, file, "old content")
+	if err := os.Symlink("page.md", path.Join(tmpdir, "link.md")); err != nil {
+		t.Skipf("couldn't create symlink: %s", err)
+	}
+	defer holdLock(t, file)()
+
+	sut := New(getwdPath(t), authenticator.NewAlwaysAuthenticated(), 50*time.Millisecond,
+		testTrashRetention)
+	sut.WriteString(requestGET("/"+tmpdir+"/link.md"), "new content")
+	if err := sut.Err(); !store.IsLockTimeoutError(err) {
+		t.Fatalf("expected LockTimeoutError, got %v", err)
+	}
+	assertFileContent(t, file, "old content")
+}
+
+func TestLockFilesAreRemovedWithTheirFiles(t *testing.T) {
+	tmpdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(tmpdir)
+	defer os.RemoveAll(historyDirectoryName)
+	defer os.RemoveAll(TrashDirectoryName)
+
+	sut := sutAuthenticated(t)
+	sut.WriteString(requestGET("/"+tmpdir+"/page.md"), "content")
+	sut.WriteString(requestGET("/"+tmpdir+"/other.md"), "content")
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to write: %s", err)
+	}
+	assertFileExists(t, lockPathFor(path.Join(tmpdir, "page.md")))
+
+	sut.Move(requestGET("/"+tmpdir+"/page.md"), "/"+tmpdir+"/moved.md", false)
+	sut.Delete(requestGET("/" + tmpdir + "/other.md"))
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to move and delete: %s", err)
+	}
+	assertFileMissing(t, lockPathFor(path.Join(tmpdir, "page.md")))
+	assertFileMissing(t, lockPathFor(path.Join(tmpdir, "other.md")))
+	assertFileExists(t, lockPathFor(path.Join(tmpdir, "moved.md")))
+}
+
+func TestWaitingWriterLocksLockFileReplacedMeanwhile(t *testing.T) {
+	tmpdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(tmpdir)
+	defer os.RemoveAll(historyDirectoryName)
+	var file = path.Join(tmpdir, "page.md")
+	var releaseRemoved = holdLock(t, file)
+
+	sut := sutAuthenticated(t)
+	var done = make(chan error)
+	go func() {
+		sut.WriteString(requestGET("/"+tmpdir+"/page.md"), "content")
+		done <- sut.Err()
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// HINT: like a holder of the lock deleting the file
+	if err := os.Remove(lockPathFor(file)); err != nil {
+		t.Fatalf("couldn't remove lock file: %s", err)
+	}
+	var releaseReplacement = holdLock(t, file)
+	releaseRemoved()
+	select {
+	case err := <-done:
+		t.Fatalf("expected writer to wait for the new lock file, but it finished with %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	releaseReplacement()
+	if err := <-done; err != nil {
+		t.Fatalf("writer failed: %s", err)
+	}
+	assertFileContent(t, file, "content")
+}
+
+// holdLock locks the given file like another process would, and returns a
+// function releasing the lock.
+func holdLock(t *testing.T, file string) func() {
+	lockFile, err := os.OpenFile(lockPathFor(file), os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		t.Fatalf("couldn't open lock file: %s", err)
+	}
+	if locked, err := tryLockFile(lockFile); !locked {
+		t.Fatalf("couldn't lock %s: %v", file, err)
+	}
+	return func() {
+		unlockFile(lockFile)
+		lockFile.Close()
+	}
+}
+
+func assertFileExists(t *testing.T, name string) {
+	if _, err := os.Lstat(name); err != nil {
+		t.Fatalf("expected %s to exist, but got %s", name, err)
+	}
+}
+
+func assertFileMissing(t *testing.T, name string) {
+	if _, err := os.Lstat(name); !os.IsNotExist(err) {
+		t.Fatalf("expected %s to be missing, but got %v", name, err)
+	}
+}
@@ -19,9 +19,12 @@ func newMimeDetector(p *pathIO, s *errStore) *mimeDetector {
 
 func (m *mimeDetector) mimeTypeForPath(p gopath.GoPath) string {
 	p = p.EvalSymlinks()
-	if p.IsDirectory() || p.HasErr() {
+	if p.HasErr() {
 		return store.FallbackMimeType
 	}
+	if p.IsDirectory() {
+		return store.DirectoryMimeType
+	}
 
 	var ext = p.Ext()
 	if mimeType := mime.TypeByExtension(ext); mimeType != "" {
@@ -0,0 +1,138 @@
+package filestore
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/fxnn/gone/links"
+	"github.com/fxnn/gone/store"
+	"github.com/fxnn/gopath"
+)
+
+// Move moves the file pointed to by the request to the given URL path.
+// Moving requires the same access as deleting the file and creating the
+// target.
+// With rewriteLinks, links in other pages are rewritten, as far as the user
+// may write those pages.
+// A caller must always check the Err() method.
+func (f *fileStore) Move(request *http.Request, targetPath string, rewriteLinks bool) string {
+	if f.hasErr() {
+		return ""
+	}
+	f.assertHasDeleteAccessForRequest(request)
+	var source = f.pathFromRequest(request)
+	f.assertPathExists(source)
+	f.assertPathValidForAnyAccess(source)
+	f.assertIsRegularFile(source)
+	if f.hasErr() {
+		return ""
+	}
+
+	var sourceURLPath = f.urlPathForPath(source)
+	var targetURLPath = f.targetURLPathForMove(source, targetPath)
+	var target = f.contentRoot.JoinPath(targetURLPath).Do(f.normalizePath)
+	f.assertPathValidForAnyAccess(target)
+	f.assertHasWriteAccessForRequest(requestForURLPath(request, targetURLPath))
+	if f.hasErr() {
+		return ""
+	}
+
+	var backlinks []string
+	if rewriteLinks {
+		backlinks = f.backlinksForPath(request, source)
+	}
+	f.assertNotCanceled(request)
+	if !f.moveLocked(request, source, target) {
+		return ""
+	}
+
+	for _, backlink := range backlinks {
+		f.rewriteLinksInPage(requestForURLPath(request, backlink), sourceURLPath, targetURLPath)
+	}
+
+	return targetURLPath
+}
+
+// moveLocked renames the file while holding the locks of source and target,
+// and reports whether the file was renamed.
+// Pages linking to the file are rewritten afterwards, with their own locks.
+func (f *fileStore) moveLocked(request *http.Request, source gopath.GoPath, target gopath.GoPath) bool {
+	var locks = f.lockPaths(request.Context(), source, target)
+	defer locks.release()
+	f.assertPathExists(source)
+	f.assertPathDoesNotExist(target)
+	if f.hasErr() {
+		return false
+	}
+
+	f.setErr(os.Rename(source.Path(), target.Path()))
+	if f.hasErr() {
+		f.prependErr(fmt.Sprintf("couldn't move '%s' to '%s'", source, target))
+		return false
+	}
+	f.moveRevisions(source, target)
+	f.removeFromIndexForPath(source)
+	f.updateIndexForPath(target)
+	return true
+}
+
+// targetURLPathForMove completes the target given by the user.
+// Targets without extension get the source's extension; targets being
+// directories get the source's name.
+func (f *fileStore) targetURLPathForMove(source gopath.GoPath, targetPath string) string {
+	var result = path.Clean("/" + targetPath)
+	if strings.HasSuffix(targetPath, "/") || f.contentRoot.JoinPath(result).IsDirectory() {
+		return path.Join(result, source.Base())
+	}
+	if path.Ext(result) == "" {
+		result += source.Ext()
+	}
+	return result
+}
+
+// rewriteLinksInPage changes links pointing to the old URL path, if the
+// user may write the page.
+func (f *fileStore) rewriteLinksInPage(request *http.Request, oldURLPath string, newURLPath string) {
+	if f.hasErr() || !f.HasWriteAccessForRequest(request) {
+		return
+	}
+
+	var content = f.ReadString(request)
+	var rewritten, changed = links.Rewrite(request.URL.Path, []byte(content),
+		linkTargetsForURLPath(oldURLPath), newURLPath)
+	if changed {
+		f.WriteString(request, string(rewritten))
+	}
+	f.prependErr(fmt.Sprintf("couldn't rewrite links in '%s'", request.URL.Path))
+}
+
+func (f *fileStore) assertIsRegularFile(p gopath.GoPath) {
+	if f.hasErr() {
+		return
+	}
+	if stat := p.Stat(); stat.HasErr() || !stat.FileMode().IsRegular() {
+		f.setErr(store.NewPathNotFoundError(fmt.Sprintf("%s is no regular file", p)))
+	}
+}
+
+func (f *fileStore) assertPathDoesNotExist(p gopath.GoPath) {
+	if f.hasErr() {
+		return
+	}
+	if _, err := os.Lstat(p.Path()); err == nil {
+		f.setErr(store.NewAccessDeniedError(fmt.Sprintf("%s already exists", p)))
+	}
+}
+
+// requestForURLPath copies the request, letting it point to the given URL
+// path.
+func requestForURLPath(request *http.Request, urlPath string) *http.Request {
+	var u = *request.URL
+	u.Path, u.RawPath = urlPath, ""
+	var result = *request
+	result.URL = &u
+	return &result
+}
@@ -0,0 +1,67 @@
+package filestore
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/fxnn/gone/store"
+)
+
+func TestMoveRenamesFileAndRewritesLinks(t *testing.T) {
+	tempWd := createTempWdInCurrentwd(t, 0777)
+	defer removeTempWdFromCurrentwd(t, tempWd)
+	defer os.RemoveAll(historyDirectoryName)
+
+	writeSearchFixture(t, "old.md", "content", 0666)
+	writeSearchFixture(t, "wiki.md", "see [[old]]", 0666)
+	defer removeTempFileFromCurrentwd(t, "wiki.md")
+	writeSearchFixture(t, "readonly.md", "see [[old]]", 0644)
+	defer removeTempFileFromCurrentwd(t, "readonly.md")
+	defer removeTempFileFromCurrentwd(t, "new.md")
+
+	sut := sutNotAuthenticated(t)
+	newPath := sut.Move(requestGET("/old.md"), "new", true)
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to move: %s", err)
+	}
+
+	if newPath != "/new.md" {
+		t.Fatalf("expected /new.md, but got %s", newPath)
+	}
+	if _, err := os.Stat("old.md"); !os.IsNotExist(err) {
+		t.Fatalf("expected old.md to be gone, but got %v", err)
+	}
+	assertFileContent(t, "new.md", "content")
+	assertFileContent(t, "wiki.md", "see [[/new|old]]")
+	assertFileContent(t, "readonly.md", "see [[old]]")
+}
+
+func TestMoveDeniesExistingTarget(t *testing.T) {
+	tempWd := createTempWdInCurrentwd(t, 0777)
+	defer removeTempWdFromCurrentwd(t, tempWd)
+
+	writeSearchFixture(t, "old.md", "old content", 0666)
+	defer removeTempFileFromCurrentwd(t, "old.md")
+	writeSearchFixture(t, "new.md", "new content", 0666)
+	defer removeTempFileFromCurrentwd(t, "new.md")
+
+	sut := sutAuthenticated(t)
+	sut.Move(requestGET("/old.md"), "/new.md", false)
+	if err := sut.Err(); !store.IsAccessDeniedError(err) {
+		t.Fatalf("expected AccessDeniedError, but got %v", err)
+	}
+
+	assertFileContent(t, "old.md", "old content")
+	assertFileContent(t, "new.md", "new content")
+}
+
+func assertFileContent(t *testing.T, name string, expected string) {
+	content, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatalf("couldn't read %s: %s", name, err)
+	}
+	if string(content) != expected {
+		t.Fatalf("expected %s to contain %q, but got %q", name, expected, content)
+	}
+}
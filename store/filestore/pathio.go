@@ -1,8 +1,10 @@
 package filestore
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"strings"
@@ -37,7 +39,10 @@ func (i *pathIO) openReaderAtPath(p gopath.GoPath) (reader io.ReadCloser) {
 	return
 }
 
-func (i *pathIO) openWriterAtPath(p gopath.GoPath) (writer io.WriteCloser) {
+// openWriterAtPath opens an atomicWriter, so that the file keeps its
+// previous content until the writer is closed successfully.
+// Writing fails as soon as the given context is done.
+func (i *pathIO) openWriterAtPath(ctx context.Context, p gopath.GoPath) *atomicWriter {
 	i.assertPathValidForAnyAccess(p)
 	i.assertPathValidForWriteAccess(p)
 	if i.hasErr() {
@@ -45,11 +50,45 @@ func (i *pathIO) openWriterAtPath(p gopath.GoPath) (writer io.WriteCloser) {
 		return nil
 	}
 
-	writer, err := os.Create(p.Path())
+	writer, err := openAtomicWriter(ctx, p.Path())
 	i.setErr(err)
-	i.prependErr(fmt.Sprintf("couldn't open writer for '%s'", p))
+	if i.hasErr() {
+		i.prependErr(fmt.Sprintf("couldn't open writer for '%s'", p))
+		return nil
+	}
 
-	return
+	return writer
+}
+
+// assertContentHash sets a ConflictError unless the file's content has the
+// given hash.
+// A missing file is treated like an empty one.
+func (i *pathIO) assertContentHash(p gopath.GoPath, expectedHash string) {
+	if i.hasErr() {
+		return
+	}
+	content, err := ioutil.ReadFile(p.Path())
+	if err != nil && !os.IsNotExist(err) {
+		i.setErr(err)
+		i.prependErr(fmt.Sprintf("couldn't read current content of '%s'", p))
+		return
+	}
+	if store.ContentHash(string(content)) != expectedHash {
+		i.setErr(store.NewConflictError(fmt.Sprintf("'%s' was changed by someone else", p)))
+	}
+}
+
+// assertFileMissing sets a ConflictError when the file exists.
+func (i *pathIO) assertFileMissing(p gopath.GoPath) {
+	if i.hasErr() {
+		return
+	}
+	if _, err := os.Lstat(p.Path()); err == nil {
+		i.setErr(store.NewConflictError(fmt.Sprintf("'%s' already exists", p)))
+	} else if !os.IsNotExist(err) {
+		i.setErr(err)
+		i.prependErr(fmt.Sprintf("couldn't check whether '%s' exists", p))
+	}
 }
 
 func (i *pathIO) assertPathExists(p gopath.GoPath) {
@@ -152,6 +191,22 @@ func (i *pathIO) pathFromRequest(request *http.Request) gopath.GoPath {
 	return i.syncedErrs(p.PrependErr("couldn't retrieve path from request"))
 }
 
+// directoryFromRequest maps the request to a directory in the filesystem.
+// Other than pathFromRequest, it never resolves the index document.
+func (i *pathIO) directoryFromRequest(request *http.Request) gopath.GoPath {
+	if i.hasErr() {
+		return gopath.FromErr(i.err)
+	}
+
+	var p = i.contentRoot.JoinPath(request.URL.Path).Do(i.normalizePath)
+	if !p.HasErr() && !p.IsDirectory() {
+		i.setErr(store.NewPathNotFoundError(fmt.Sprintf("%s is no directory", p)))
+		return gopath.FromErr(i.err)
+	}
+
+	return i.syncedErrs(p.PrependErr("couldn't retrieve directory from request"))
+}
+
 // indexForDirectory finds the index document inside the given directory.
 // On success, it returns the path to the index document, otherwise it simply
 // returns the given path.
@@ -184,6 +239,27 @@ func (i *pathIO) normalizePath(p gopath.GoPath) gopath.GoPath {
 	return p.Abs().Clean()
 }
 
+// urlPathForPath returns the path under which the given file is served.
+func (i *pathIO) urlPathForPath(p gopath.GoPath) string {
+	var rel = i.contentRoot.Rel(i.normalizePath(p)).ToSlash().Path()
+	if rel == "." {
+		return "/"
+	}
+	return "/" + rel
+}
+
+// isHiddenPath returns true iff the given path or any of its parent
+// directories inside the content root is hidden.
+// Other than assertFileIsNotHidden, this doesn't touch the Err() value.
+func (i *pathIO) isHiddenPath(p gopath.GoPath) bool {
+	for _, component := range i.pathComponentsTo(i.normalizePath(p)) {
+		if strings.HasPrefix(component, ".") && component != "." && component != ".." {
+			return true
+		}
+	}
+	return false
+}
+
 // pathComponentsTo returns a list of all path components between the content
 // root directory and the given file.
 //
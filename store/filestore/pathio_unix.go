@@ -3,6 +3,10 @@
 package filestore
 
 import (
+	"os"
+	"syscall"
+
+	"github.com/fxnn/gone/authenticator"
 	"github.com/fxnn/gopath"
 	"golang.org/x/sys/unix"
 )
@@ -10,3 +14,69 @@ import (
 func isPathWriteable(p gopath.GoPath) bool {
 	return unix.Access(p.Path(), unix.W_OK) == nil
 }
+
+// unixPermissionBits returns the read, write and execute bits of the given
+// file's mode that apply to the given Unix user, which are the owner, group
+// or world bits.
+func unixPermissionBits(p gopath.GoPath, u authenticator.UnixUser) os.FileMode {
+	var mode = p.FileMode()
+	var stat, ok = p.FileInfo().Sys().(*syscall.Stat_t)
+	switch {
+	case !ok:
+		return mode & 0007
+	case u.UID == 0:
+		// HINT: root may do everything
+		return 0007
+	case stat.Uid == u.UID:
+		return mode >> 6 & 0007
+	case u.HasGroup(stat.Gid):
+		return mode >> 3 & 0007
+	}
+	return mode & 0007
+}
+
+// keepOwner lets the file have the owner and group described by the given
+// info.
+// Only privileged processes may change the owner to someone else.
+func keepOwner(file *os.File, info os.FileInfo) error {
+	var stat, ok = info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	if fileInfo, err := file.Stat(); err == nil {
+		if current, ok := fileInfo.Sys().(*syscall.Stat_t); ok &&
+			current.Uid == stat.Uid && current.Gid == stat.Gid {
+			return nil
+		}
+	}
+	return file.Chown(int(stat.Uid), int(stat.Gid))
+}
+
+// syncDirectory makes renames inside the directory durable.
+func syncDirectory(path string) error {
+	var dir, err = os.Open(path)
+	if err != nil {
+		return err
+	}
+	err = dir.Sync()
+	if closeErr := dir.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// tryLockFile places an exclusive flock(2) on the file, without waiting for
+// other holders.
+// The result is false when someone else holds the lock.
+func tryLockFile(file *os.File) (bool, error) {
+	var err = unix.Flock(int(file.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+	if err == unix.EWOULDBLOCK || err == unix.EINTR {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// unlockFile releases the lock placed by tryLockFile.
+func unlockFile(file *os.File) error {
+	return unix.Flock(int(file.Fd()), unix.LOCK_UN)
+}
@@ -1,9 +1,25 @@
 package filestore
 
+import "github.com/fxnn/gone/authenticator"
 import "github.com/fxnn/gopath"
 import "os"
 import "math/rand"
 import "strconv"
+import "syscall"
+import "unsafe"
+
+var (
+	kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = kernel32.NewProc("LockFileEx")
+	procUnlockFileEx = kernel32.NewProc("UnlockFileEx")
+)
+
+const (
+	lockfileFailImmediately = 0x1
+	lockfileExclusiveLock   = 0x2
+
+	errorLockViolation syscall.Errno = 33
+)
 
 func isPathWriteable(p gopath.GoPath) bool {
 	if p.IsDirectory() {
@@ -13,6 +29,50 @@ func isPathWriteable(p gopath.GoPath) bool {
 	return isFileWriteable(p)
 }
 
+// unixPermissionBits returns the world bits of the given file's mode, as
+// there are no Unix users on Windows.
+func unixPermissionBits(p gopath.GoPath, u authenticator.UnixUser) os.FileMode {
+	return p.FileMode() & 0007
+}
+
+// keepOwner does nothing, as files on Windows have no Unix owner.
+func keepOwner(file *os.File, info os.FileInfo) error {
+	return nil
+}
+
+// syncDirectory does nothing, as directories can't be synced on Windows.
+func syncDirectory(path string) error {
+	return nil
+}
+
+// tryLockFile locks the file's first byte exclusively using LockFileEx,
+// without waiting for other holders.
+// The result is false when someone else holds the lock.
+func tryLockFile(file *os.File) (bool, error) {
+	var overlapped syscall.Overlapped
+	var ok, _, err = procLockFileEx.Call(file.Fd(),
+		lockfileExclusiveLock|lockfileFailImmediately, 0, 1, 0,
+		uintptr(unsafe.Pointer(&overlapped)))
+	if ok != 0 {
+		return true, nil
+	}
+	if err == errorLockViolation {
+		return false, nil
+	}
+	return false, err
+}
+
+// unlockFile releases the lock placed by tryLockFile.
+func unlockFile(file *os.File) error {
+	var overlapped syscall.Overlapped
+	var ok, _, err = procUnlockFileEx.Call(file.Fd(), 0, 1, 0,
+		uintptr(unsafe.Pointer(&overlapped)))
+	if ok != 0 {
+		return nil
+	}
+	return err
+}
+
 func isFileWriteable(p gopath.GoPath) bool {
 	if p.IsExists() {
 		var closer, err = os.OpenFile(p.Path(), os.O_WRONLY, 0)
@@ -0,0 +1,162 @@
+package filestore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/fxnn/gone/search"
+	"github.com/fxnn/gone/store"
+	"github.com/fxnn/gopath"
+)
+
+const (
+	// maxSearchableBytes is the size up to which files are searched.
+	maxSearchableBytes = 10 * 1024 * 1024
+
+	// maxSnippetsPerResult is the number of excerpts shown for each file.
+	maxSnippetsPerResult = 3
+)
+
+// searcher implements the full-text search.
+// Candidates are taken from the index, if it's active; otherwise, the file
+// system is walked.
+// Only Markdown and text files are searched.
+// Hidden files and files the user may not read are skipped.
+type searcher struct {
+	indexer *indexer
+	*errStore
+	*pathIO
+	*mimeDetector
+	*accessControl
+	*lister
+}
+
+func newSearcher(x *indexer, l *lister, p *pathIO, m *mimeDetector, a *accessControl, s *errStore) *searcher {
+	return &searcher{x, s, p, m, a, l}
+}
+
+// searchBelowPath finds all files below the given directory matching the
+// query, best matches first.
+func (s *searcher) searchBelowPath(request *http.Request, dir gopath.GoPath, query string) []store.SearchResult {
+	s.assertPathValidForAnyAccess(dir)
+	if s.hasErr() {
+		return nil
+	}
+
+	var matcher = search.NewMatcher(query)
+	if matcher.IsEmpty() {
+		return make([]store.SearchResult, 0)
+	}
+
+	var result []store.SearchResult
+	if index := s.indexer.activeIndex(); index != nil {
+		result = s.searchIndex(request, index, dir, query, matcher)
+	} else {
+		result = s.searchFiles(request, dir, matcher)
+	}
+	if s.hasErr() {
+		s.prependErr(fmt.Sprintf("couldn't search below '%s'", dir))
+		return nil
+	}
+
+	sort.Sort(searchResultsByScore(result))
+	return result
+}
+
+// searchIndex matches all candidates the index returns for the query.
+func (s *searcher) searchIndex(request *http.Request, index *search.Index, dir gopath.GoPath,
+	query string, matcher *search.Matcher) []store.SearchResult {
+	var prefix = strings.TrimSuffix(s.urlPathForPath(dir), "/") + "/"
+	var result = make([]store.SearchResult, 0)
+	for _, urlPath := range index.Lookup(query) {
+		s.assertNotCanceled(request)
+		if s.hasErr() {
+			return nil
+		}
+		if !strings.HasPrefix(urlPath, prefix) {
+			continue
+		}
+		if r, ok := s.matchFile(request, s.contentRoot.JoinPath(urlPath), matcher); ok {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// searchFiles matches all files below the given directory.
+func (s *searcher) searchFiles(request *http.Request, dir gopath.GoPath,
+	matcher *search.Matcher) []store.SearchResult {
+	var result = make([]store.SearchResult, 0)
+	s.walkDirectory(request, dir, func(entry store.Entry) error {
+		if !entry.IsDirectory {
+			if r, ok := s.matchFile(request, s.contentRoot.JoinPath(entry.Path), matcher); ok {
+				result = append(result, r)
+			}
+		}
+		return nil
+	})
+	return result
+}
+
+// matchFile reads the given file and matches it against the query.
+// Files that can't be read are reported as no match.
+func (s *searcher) matchFile(request *http.Request, p gopath.GoPath, matcher *search.Matcher) (store.SearchResult, bool) {
+	var stat = p.Stat()
+	if stat.HasErr() || !stat.FileMode().IsRegular() ||
+		stat.FileInfo().Size() > maxSearchableBytes {
+		return store.SearchResult{}, false
+	}
+	if !isSearchableMimeType(s.mimeTypeForPath(stat)) ||
+		!s.hasReadAccessForPath(request, stat) {
+		return store.SearchResult{}, false
+	}
+
+	content, err := ioutil.ReadFile(p.Path())
+	if err != nil {
+		return store.SearchResult{}, false
+	}
+
+	var score, ok = matcher.Score(p.Base(), string(content))
+	if !ok {
+		return store.SearchResult{}, false
+	}
+
+	return store.SearchResult{
+		Path:     s.urlPathForPath(p),
+		Score:    score,
+		Snippets: matcher.Snippets(string(content), maxSnippetsPerResult),
+	}, true
+}
+
+func isSearchableMimeType(mimeType string) bool {
+	return strings.HasPrefix(mimeType, store.MarkdownMimeType) ||
+		strings.HasPrefix(mimeType, "text/plain")
+}
+
+// searchDirFromRequest returns the directory the request points to or, if it
+// points to a file, the file's directory.
+func (s *searcher) searchDirFromRequest(request *http.Request) gopath.GoPath {
+	if s.hasErr() {
+		return gopath.FromErr(s.err)
+	}
+
+	var p = s.contentRoot.JoinPath(request.URL.Path).Do(s.normalizePath)
+	if !p.HasErr() && !p.IsDirectory() {
+		p = p.Dir()
+	}
+	return s.syncedErrs(p.AssertExists().PrependErr("couldn't retrieve search directory from request"))
+}
+
+type searchResultsByScore []store.SearchResult
+
+func (r searchResultsByScore) Len() int      { return len(r) }
+func (r searchResultsByScore) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r searchResultsByScore) Less(i, j int) bool {
+	if r[i].Score != r[j].Score {
+		return r[i].Score > r[j].Score
+	}
+	return r[i].Path < r[j].Path
+}
@@ -0,0 +1,68 @@
+package filestore
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func writeSearchFixture(t *testing.T, name string, content string, mode os.FileMode) {
+	if err := ioutil.WriteFile(name, []byte(content), mode); err != nil {
+		t.Fatalf("couldnt write %s: %s", name, err)
+	}
+	if err := os.Chmod(name, mode); err != nil {
+		t.Fatalf("couldnt chmod %s: %s", name, err)
+	}
+}
+
+func TestSearchRanksMatchesAndSkipsHiddenFiles(t *testing.T) {
+	tempWd := createTempWdInCurrentwd(t, 0777)
+	defer removeTempWdFromCurrentwd(t, tempWd)
+
+	writeSearchFixture(t, "wiki.md", "all about the wiki", 0644)
+	defer removeTempFileFromCurrentwd(t, "wiki.md")
+	writeSearchFixture(t, "other.md", "a wiki, and another wiki", 0644)
+	defer removeTempFileFromCurrentwd(t, "other.md")
+	writeSearchFixture(t, "none.md", "nothing to see here", 0644)
+	defer removeTempFileFromCurrentwd(t, "none.md")
+	writeSearchFixture(t, ".hidden.md", "a hidden wiki", 0644)
+	defer removeTempFileFromCurrentwd(t, ".hidden.md")
+
+	sut := sutAuthenticated(t)
+	results := sut.Search(requestGET("/"), "Wiki")
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to search: %s", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, but got %v", results)
+	}
+	if results[0].Path != "/wiki.md" || results[1].Path != "/other.md" {
+		t.Fatalf("expected /wiki.md before /other.md, but got %s and %s",
+			results[0].Path, results[1].Path)
+	}
+	if len(results[1].Snippets) != 1 {
+		t.Fatalf("expected 1 snippet for /other.md, but got %v", results[1].Snippets)
+	}
+}
+
+func TestSearchSkipsFilesWithoutWorldReadPermission(t *testing.T) {
+	skipOnWindows(t)
+	tempWd := createTempWdInCurrentwd(t, 0777)
+	defer removeTempWdFromCurrentwd(t, tempWd)
+
+	writeSearchFixture(t, "public.md", "gone", 0644)
+	defer removeTempFileFromCurrentwd(t, "public.md")
+	writeSearchFixture(t, "private.md", "gone", 0640)
+	defer removeTempFileFromCurrentwd(t, "private.md")
+
+	sut := sutNotAuthenticated(t)
+	results := sut.Search(requestGET("/"), "gone")
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to search: %s", err)
+	}
+
+	if len(results) != 1 || results[0].Path != "/public.md" {
+		t.Fatalf("expected only /public.md, but got %v", results)
+	}
+}
@@ -0,0 +1,245 @@
+package filestore
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/fxnn/gone/authenticator"
+	"github.com/fxnn/gone/log"
+	"github.com/fxnn/gone/store"
+	"github.com/fxnn/gopath"
+)
+
+const (
+	// TrashDirectoryName is the directory inside the content root that keeps
+	// deleted files.
+	// Being a hidden file, it's never delivered over HTTP.
+	TrashDirectoryName = ".trash"
+	trashItemMetaExt   = ".meta"
+)
+
+// trashItemMeta is what we store alongside each deleted file.
+type trashItemMeta struct {
+	Path      string    `json:"path"`
+	DeletedBy string    `json:"deletedBy"`
+	Time      time.Time `json:"time"`
+}
+
+// trash keeps deleted files, so that they may be restored.
+// Each deleted file is moved into the trash directory, named after its item
+// ID, which is formatted like a revision ID.
+// A meta file next to it records the URL path it was deleted from, as well
+// as who deleted it and when.
+//
+// Items older than the retention period are purged whenever files are
+// deleted or the trash is listed; with a retention of zero, items are kept
+// for ever.
+type trash struct {
+	authenticator authenticator.Authenticator
+	retention     time.Duration
+	*pathIO
+	*errStore
+}
+
+func newTrash(a authenticator.Authenticator, retention time.Duration, p *pathIO, s *errStore) *trash {
+	return &trash{a, retention, p, s}
+}
+
+// moveToTrash moves the given file into the trash.
+func (t *trash) moveToTrash(request *http.Request, p gopath.GoPath) {
+	if t.hasErr() {
+		return
+	}
+	t.purgeExpiredTrashItems()
+
+	var dir = t.trashDir()
+	t.setErr(os.MkdirAll(dir.Path(), 0700))
+	if t.hasErr() {
+		t.prependErr(fmt.Sprintf("couldn't create trash directory for '%s'", p))
+		return
+	}
+
+	var now = time.Now().UTC()
+	var id = now.Format(revisionIDFormat)
+	var metaPath = dir.JoinPath(id + trashItemMetaExt)
+	t.writeTrashItemMeta(metaPath, trashItemMeta{t.urlPathForPath(p), t.authenticator.UserID(request), now})
+	if !t.hasErr() {
+		t.setErr(os.Rename(p.Path(), dir.JoinPath(id).Path()))
+		if t.hasErr() {
+			os.Remove(metaPath.Path())
+		}
+	}
+	t.prependErr(fmt.Sprintf("couldn't move '%s' to trash", p))
+}
+
+// allTrashItems lists all items in the trash, newest first.
+func (t *trash) allTrashItems() []store.TrashItem {
+	if t.hasErr() {
+		return nil
+	}
+	var dir = t.trashDir()
+	fileInfos, err := ioutil.ReadDir(dir.Path())
+	if os.IsNotExist(err) {
+		// HINT: Nothing deleted yet
+		return []store.TrashItem{}
+	}
+	t.setErr(err)
+	if t.hasErr() {
+		t.prependErr("couldn't list trash")
+		return nil
+	}
+
+	var result = make([]store.TrashItem, 0, len(fileInfos)/2)
+	for _, fileInfo := range fileInfos {
+		var id = fileInfo.Name()
+		if !revisionIDRegexp.MatchString(id) {
+			continue
+		}
+		var meta = t.readTrashItemMeta(dir.JoinPath(id + trashItemMetaExt))
+		if t.hasErr() {
+			t.prependErr(fmt.Sprintf("couldn't read trash item %s", id))
+			return nil
+		}
+		result = append(result, store.TrashItem{
+			ID:        id,
+			Path:      meta.Path,
+			DeletedBy: meta.DeletedBy,
+			Time:      meta.Time,
+			Size:      fileInfo.Size(),
+		})
+	}
+
+	sort.Sort(sort.Reverse(trashItemsByID(result)))
+	return result
+}
+
+// trashItem returns the item with the given ID.
+func (t *trash) trashItem(itemID string) store.TrashItem {
+	var meta = t.readTrashItemMeta(t.trashItemPath(itemID).Append(trashItemMetaExt))
+	var stat = t.syncedErrs(t.trashItemPath(itemID).Stat())
+	if t.hasErr() {
+		t.prependErr(fmt.Sprintf("couldn't read trash item %s", itemID))
+		return store.TrashItem{}
+	}
+	return store.TrashItem{
+		ID:        itemID,
+		Path:      meta.Path,
+		DeletedBy: meta.DeletedBy,
+		Time:      meta.Time,
+		Size:      stat.FileInfo().Size(),
+	}
+}
+
+// moveFromTrash moves the item's content to the given file and removes the
+// item.
+func (t *trash) moveFromTrash(itemID string, p gopath.GoPath) {
+	var itemPath = t.trashItemPath(itemID)
+	if t.hasErr() {
+		return
+	}
+	t.setErr(os.Rename(itemPath.Path(), p.Path()))
+	if !t.hasErr() {
+		t.setErr(os.Remove(itemPath.Append(trashItemMetaExt).Path()))
+	}
+	t.prependErr(fmt.Sprintf("couldn't restore trash item %s to '%s'", itemID, p))
+}
+
+// purgeTrashItemWithID deletes the item's content and meta file.
+func (t *trash) purgeTrashItemWithID(itemID string) {
+	var itemPath = t.trashItemPath(itemID)
+	if t.hasErr() {
+		return
+	}
+	t.setErr(os.Remove(itemPath.Path()))
+	if !t.hasErr() {
+		t.setErr(os.Remove(itemPath.Append(trashItemMetaExt).Path()))
+	}
+	t.prependErr(fmt.Sprintf("couldn't purge trash item %s", itemID))
+}
+
+// purgeExpiredTrashItems deletes all items older than the retention period.
+// Failures are only logged, as they shouldn't keep anybody from working.
+func (t *trash) purgeExpiredTrashItems() {
+	if t.retention <= 0 || t.hasErr() {
+		return
+	}
+	var items = t.allTrashItems()
+	if err := t.errAndClear(); err != nil {
+		log.Warnf("couldn't purge expired trash items: %s", err)
+		return
+	}
+
+	var expiry = time.Now().Add(-t.retention)
+	for _, item := range items {
+		if item.Time.After(expiry) {
+			continue
+		}
+		t.purgeTrashItemWithID(item.ID)
+		if err := t.errAndClear(); err != nil {
+			log.Warnf("couldn't purge expired trash item: %s", err)
+		}
+	}
+}
+
+func (t *trash) trashItemPath(itemID string) gopath.GoPath {
+	if t.hasErr() {
+		return gopath.FromErr(t.err)
+	}
+	if !revisionIDRegexp.MatchString(itemID) {
+		t.setErr(store.NewPathNotFoundError(
+			fmt.Sprintf("'%s' is no valid trash item id", itemID)))
+		return gopath.FromErr(t.err)
+	}
+	return t.trashDir().JoinPath(itemID)
+}
+
+func (t *trash) trashDir() gopath.GoPath {
+	return t.contentRoot.JoinPath(TrashDirectoryName)
+}
+
+func (t *trash) writeTrashItemMeta(p gopath.GoPath, meta trashItemMeta) {
+	if t.hasErr() {
+		return
+	}
+	content, err := json.Marshal(meta)
+	if err != nil {
+		t.setErr(err)
+		return
+	}
+
+	// HINT: never replace another item deleted at the same time
+	file, err := os.OpenFile(p.Path(), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		t.setErr(err)
+		return
+	}
+	_, err = file.Write(content)
+	t.setErr(err)
+	if err = file.Close(); !t.hasErr() {
+		t.setErr(err)
+	}
+}
+
+func (t *trash) readTrashItemMeta(p gopath.GoPath) (meta trashItemMeta) {
+	if t.hasErr() {
+		return
+	}
+	content, err := ioutil.ReadFile(p.Path())
+	if err != nil {
+		t.setErr(err)
+		return
+	}
+	t.setErr(json.Unmarshal(content, &meta))
+	return
+}
+
+type trashItemsByID []store.TrashItem
+
+func (r trashItemsByID) Len() int           { return len(r) }
+func (r trashItemsByID) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r trashItemsByID) Less(i, j int) bool { return r[i].ID < r[j].ID }
@@ -0,0 +1,143 @@
+package filestore
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/fxnn/gone/authenticator"
+	"github.com/fxnn/gone/store"
+)
+
+func TestDeleteMovesFileIntoTrash(t *testing.T) {
+	tmpdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(tmpdir)
+	defer os.RemoveAll(TrashDirectoryName)
+	var file = path.Join(tmpdir, "page.md")
+	writeTestFile(t, file, "content")
+
+	sut := sutAuthenticated(t)
+	sut.Delete(requestGET("/" + file))
+	var items = sut.TrashItems(requestGET("/" + tmpdir + "/"))
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to delete and list trash: %s", err)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Fatalf("expected %s to be deleted, got %v", file, err)
+	}
+	if len(items) != 1 || items[0].Path != "/"+file || items[0].Size != 7 {
+		t.Fatalf("expected %s of 7 bytes in trash, got %v", file, items)
+	}
+
+	var restoredPath = sut.RestoreTrashItem(requestGET("/"+tmpdir+"/"), items[0].ID)
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to restore: %s", err)
+	}
+	if restoredPath != "/"+file {
+		t.Fatalf("expected /%s to be restored, got %s", file, restoredPath)
+	}
+	assertFileContent(t, file, "content")
+	if items = sut.TrashItems(requestGET("/")); len(items) != 0 {
+		t.Fatalf("expected trash to be empty, got %v", items)
+	}
+}
+
+func TestRestoreDeniesWhenFileWasCreatedAgain(t *testing.T) {
+	tmpdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(tmpdir)
+	defer os.RemoveAll(TrashDirectoryName)
+	var file = path.Join(tmpdir, "page.md")
+	writeTestFile(t, file, "deleted")
+
+	sut := sutAuthenticated(t)
+	sut.Delete(requestGET("/" + file))
+	var items = sut.TrashItems(requestGET("/" + tmpdir + "/"))
+	if err := sut.Err(); err != nil || len(items) != 1 {
+		t.Fatalf("expected one item in trash, got %v: %v", items, err)
+	}
+	writeTestFile(t, file, "created again")
+
+	sut.RestoreTrashItem(requestGET("/"+tmpdir+"/"), items[0].ID)
+	if err := sut.Err(); !store.IsAccessDeniedError(err) {
+		t.Fatalf("expected AccessDeniedError, got %v", err)
+	}
+	assertFileContent(t, file, "created again")
+}
+
+func TestPurgeDeletesTrashItem(t *testing.T) {
+	tmpdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(tmpdir)
+	defer os.RemoveAll(TrashDirectoryName)
+	var file = path.Join(tmpdir, "page.md")
+	writeTestFile(t, file, "content")
+
+	sut := sutAuthenticated(t)
+	sut.Delete(requestGET("/" + file))
+	var items = sut.TrashItems(requestGET("/" + tmpdir + "/"))
+	if err := sut.Err(); err != nil || len(items) != 1 {
+		t.Fatalf("expected one item in trash, got %v: %v", items, err)
+	}
+
+	var id = items[0].ID
+	sut.PurgeTrashItem(requestGET("/"+tmpdir+"/"), id)
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to purge: %s", err)
+	}
+	if items = sut.TrashItems(requestGET("/")); len(items) != 0 {
+		t.Fatalf("expected trash to be empty, got %v", items)
+	}
+	sut.RestoreTrashItem(requestGET("/"), id)
+	if err := sut.Err(); !store.IsPathNotFoundError(err) {
+		t.Fatalf("expected PathNotFoundError, got %v", err)
+	}
+}
+
+func TestTrashItemsLeaveOutOtherDirectories(t *testing.T) {
+	tmpdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(tmpdir)
+	defer os.RemoveAll(TrashDirectoryName)
+	otherdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(otherdir)
+	var file = path.Join(tmpdir, "page.md")
+	writeTestFile(t, file, "content")
+
+	sut := sutAuthenticated(t)
+	sut.Delete(requestGET("/" + file))
+	var items = sut.TrashItems(requestGET("/" + otherdir + "/"))
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to list trash: %s", err)
+	}
+	if len(items) != 0 {
+		t.Fatalf("expected no items in %s, got %v", otherdir, items)
+	}
+
+	sut.RestoreTrashItem(requestGET("/"+otherdir+"/"), sut.TrashItems(requestGET("/"))[0].ID)
+	if err := sut.Err(); !store.IsPathNotFoundError(err) {
+		t.Fatalf("expected PathNotFoundError, got %v", err)
+	}
+}
+
+func TestExpiredTrashItemsArePurged(t *testing.T) {
+	tmpdir := createTempDirInCurrentwd(t, 0777)
+	defer os.RemoveAll(tmpdir)
+	defer os.RemoveAll(TrashDirectoryName)
+	var file = path.Join(tmpdir, "page.md")
+	writeTestFile(t, file, "content")
+
+	sut := New(getwdPath(t), authenticator.NewAlwaysAuthenticated(), testLockTimeout,
+		50*time.Millisecond)
+	sut.Delete(requestGET("/" + file))
+	if items := sut.TrashItems(requestGET("/")); len(items) != 1 {
+		t.Fatalf("expected one item in trash, got %v", items)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	var items = sut.TrashItems(requestGET("/"))
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to list trash: %s", err)
+	}
+	if len(items) != 0 {
+		t.Fatalf("expected expired item to be purged, got %v", items)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"path"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/fxnn/gone/authenticator"
 	"github.com/fxnn/gone/store"
@@ -15,6 +16,13 @@ import (
 	"path/filepath"
 )
 
+const (
+	// testLockTimeout is the time tests wait for locked files.
+	testLockTimeout = time.Second
+	// testTrashRetention is the time tests keep deleted files.
+	testTrashRetention = time.Hour
+)
+
 func skipOnWindows(t *testing.T) {
 	skipOnOs("windows", t)
 }
@@ -26,11 +34,11 @@ func skipOnOs(os string, t *testing.T) {
 }
 
 func sutNotAuthenticated(t *testing.T) store.Store {
-	return New(getwdPath(t), authenticator.NewNeverAuthenticated())
+	return New(getwdPath(t), authenticator.NewNeverAuthenticated(), testLockTimeout, testTrashRetention)
 }
 
 func sutAuthenticated(t *testing.T) store.Store {
-	return New(getwdPath(t), authenticator.NewAlwaysAuthenticated())
+	return New(getwdPath(t), authenticator.NewAlwaysAuthenticated(), testLockTimeout, testTrashRetention)
 }
 
 func requestGET(path string) (request *http.Request) {
@@ -119,12 +127,26 @@ func removeTempWdFromCurrentwd(t *testing.T, tmpdir string) {
 func removeTempDirFromCurrentwd(t *testing.T, tmpdir string) {
 	wd := getwd(t)
 	tmpdirPath := path.Join(wd, tmpdir)
+	removeLockFiles(t, tmpdirPath)
 	err := os.Remove(tmpdirPath)
 	if err != nil {
 		t.Fatalf("couldnt remove tmpdir %s: %s", tmpdirPath, err)
 	}
 }
 
+// removeLockFiles removes the lock files left behind by writes.
+func removeLockFiles(t *testing.T, dir string) {
+	lockFiles, err := filepath.Glob(path.Join(dir, LockFilePattern))
+	if err != nil {
+		t.Fatalf("couldnt find lock files in %s: %s", dir, err)
+	}
+	for _, lockFile := range lockFiles {
+		if err := os.Remove(lockFile); err != nil {
+			t.Fatalf("couldnt remove lock file %s: %s", lockFile, err)
+		}
+	}
+}
+
 func removeTempFileFromCurrentwd(t *testing.T, tmpfile string) {
 	wd := getwd(t)
 	tmpfilePath := path.Join(wd, tmpfile)
@@ -0,0 +1,12 @@
+// Package gitstore implements the storage via filesystem, with all changes
+// being committed to a local git repository.
+//
+// Reading content, access control and the like are delegated to the
+// filestore, so that the working tree is served just as with the filestore.
+// Each write and delete however becomes a git commit, authored by the
+// authenticated user.
+// The revisions of a file are taken from the git log.
+//
+// The git executable must be available in the PATH.
+// No remote repository is needed or used.
+package gitstore
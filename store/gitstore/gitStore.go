@@ -0,0 +1,348 @@
+package gitstore
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fxnn/gone/authenticator"
+	"github.com/fxnn/gone/store"
+	"github.com/fxnn/gone/store/filestore"
+	"github.com/fxnn/gopath"
+)
+
+var revisionIDRegexp = regexp.MustCompile(`^[0-9a-f]{40,64}$`)
+
+// gitStore implements the storage using the file system and a git
+// repository.
+//
+// In addition to the errors recorded by the embedded filestore, it records
+// errors occuring while working with the repository.
+// Both are returned by the Err() method.
+type gitStore struct {
+	filestore.Store
+	authenticator authenticator.Authenticator
+	repository    *repository
+	contentRoot   gopath.GoPath
+	err           error
+}
+
+// New initializes an instance ready to use.
+// When the content root is no git repository yet, a new one is initialized.
+// Writers wait at most lockTimeout for others writing the same file.
+// Deleted files are kept in the trash for trashRetention, or for ever if
+// that's zero.
+func New(contentRoot gopath.GoPath, authenticator authenticator.Authenticator,
+	lockTimeout time.Duration, trashRetention time.Duration) (filestore.Store, error) {
+	contentRoot = contentRoot.Abs().Clean()
+	if contentRoot.HasErr() {
+		return nil, fmt.Errorf("invalid content root: %s", contentRoot.Err())
+	}
+
+	repository, err := openRepository(contentRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gitStore{
+		filestore.NewWithoutHistory(contentRoot, authenticator, lockTimeout, trashRetention),
+		authenticator,
+		repository,
+		contentRoot,
+		nil,
+	}, nil
+}
+
+// Handle returns a new handle on the same repository, with its own Err()
+// value.
+func (s *gitStore) Handle() store.Store {
+	// HINT: filestore handles are filestore.Stores as well
+	return &gitStore{
+		s.Store.Handle().(filestore.Store),
+		s.authenticator,
+		s.repository,
+		s.contentRoot,
+		nil,
+	}
+}
+
+// Err returns and clears the recorded error.
+func (s *gitStore) Err() error {
+	var result = s.Store.Err()
+	if s.err != nil {
+		result = s.err
+	}
+	s.err = nil
+	return result
+}
+
+func (s *gitStore) hasErr() bool {
+	return s.err != nil
+}
+
+// setErr records the given error, unless there's already one.
+func (s *gitStore) setErr(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// OpenWriter opens a writer for the given request.
+// Closing the writer commits the new content.
+// A caller must always check the Err() method.
+func (s *gitStore) OpenWriter(request *http.Request) io.WriteCloser {
+	return s.openCommittingWriter(request, func() io.WriteCloser {
+		return s.Store.OpenWriter(request)
+	})
+}
+
+// OpenWriterIfUnchanged opens a writer like OpenWriter does, unless the
+// file's current content has another hash than expected.
+// A caller must always check the Err() method.
+func (s *gitStore) OpenWriterIfUnchanged(request *http.Request, expectedHash string) io.WriteCloser {
+	return s.openCommittingWriter(request, func() io.WriteCloser {
+		return s.Store.OpenWriterIfUnchanged(request, expectedHash)
+	})
+}
+
+// OpenWriterIfMissing opens a writer like OpenWriter does, unless the file
+// already exists.
+// A caller must always check the Err() method.
+func (s *gitStore) OpenWriterIfMissing(request *http.Request) io.WriteCloser {
+	return s.openCommittingWriter(request, func() io.WriteCloser {
+		return s.Store.OpenWriterIfMissing(request)
+	})
+}
+
+// WriteString writes the given content into the file pointed to by the
+// request and commits it.
+// A caller must always check the Err() method.
+func (s *gitStore) WriteString(request *http.Request, content string) {
+	if s.hasErr() {
+		return
+	}
+	s.writeAllAndClose(s.OpenWriter(request), content)
+}
+
+// WriteStringIfUnchanged writes and commits the given content like
+// WriteString does, unless the file's current content has another hash than
+// expected.
+// A caller must always check the Err() method.
+func (s *gitStore) WriteStringIfUnchanged(request *http.Request, content string,
+	expectedHash string) {
+	if s.hasErr() {
+		return
+	}
+	s.writeAllAndClose(s.OpenWriterIfUnchanged(request, expectedHash), content)
+}
+
+// openCommittingWriter opens a writer of the embedded filestore using the
+// given function, and lets closing it commit the new content.
+func (s *gitStore) openCommittingWriter(request *http.Request, open func() io.WriteCloser) io.WriteCloser {
+	var relPath = s.relPathForRequest(request)
+	if s.hasErr() {
+		return nil
+	}
+	var writer = open()
+	s.setErr(s.Store.Err())
+	if s.hasErr() {
+		return nil
+	}
+
+	return &committingWriter{writer, func() error {
+		return s.repository.commit(s.authenticator.UserID(request), "Update "+relPath, relPath)
+	}}
+}
+
+func (s *gitStore) writeAllAndClose(writer io.WriteCloser, content string) {
+	if s.hasErr() {
+		return
+	}
+	_, err := io.WriteString(writer, content)
+	s.setErr(err)
+	s.setErr(writer.Close())
+}
+
+// Delete deletes the file pointed to by the request and commits the
+// deletion.
+// A caller must always check the Err() method.
+func (s *gitStore) Delete(request *http.Request) {
+	if s.hasErr() {
+		return
+	}
+	var relPath = s.relPathForRequest(request)
+	if s.hasErr() {
+		return
+	}
+
+	s.Store.Delete(request)
+	s.setErr(s.Store.Err())
+	if s.hasErr() {
+		return
+	}
+
+	s.setErr(s.repository.commit(s.authenticator.UserID(request), "Delete "+relPath, relPath))
+}
+
+// Move moves the file pointed to by the request and commits the move,
+// together with all rewritten links, as one commit.
+// A caller must always check the Err() method.
+func (s *gitStore) Move(request *http.Request, targetPath string, rewriteLinks bool) string {
+	if s.hasErr() {
+		return ""
+	}
+	var relPath = s.relPathForRequest(request)
+	var backlinks []string
+	if rewriteLinks {
+		backlinks = s.Backlinks(request)
+		s.setErr(s.Store.Err())
+	}
+	if s.hasErr() {
+		return ""
+	}
+
+	var targetURLPath = s.Store.Move(request, targetPath, rewriteLinks)
+	s.setErr(s.Store.Err())
+	if s.hasErr() {
+		return ""
+	}
+
+	var relTargetPath = strings.TrimPrefix(targetURLPath, "/")
+	var relPaths = []string{relPath, relTargetPath}
+	for _, backlink := range backlinks {
+		relPaths = append(relPaths, strings.TrimPrefix(backlink, "/"))
+	}
+	s.setErr(s.repository.commit(s.authenticator.UserID(request),
+		fmt.Sprintf("Move %s to %s", relPath, relTargetPath), relPaths...))
+	return targetURLPath
+}
+
+// RestoreTrashItem moves the given deleted file back to where it was
+// located and commits it.
+// A caller must always check the Err() method.
+func (s *gitStore) RestoreTrashItem(request *http.Request, itemID string) string {
+	if s.hasErr() {
+		return ""
+	}
+
+	var urlPath = s.Store.RestoreTrashItem(request, itemID)
+	s.setErr(s.Store.Err())
+	if s.hasErr() {
+		return ""
+	}
+
+	var relPath = strings.TrimPrefix(urlPath, "/")
+	s.setErr(s.repository.commit(s.authenticator.UserID(request), "Restore "+relPath, relPath))
+	return urlPath
+}
+
+// Revisions lists the commits containing a version of the file pointed to
+// by the request, newest first.
+// A caller must always check the Err() method.
+func (s *gitStore) Revisions(request *http.Request) []store.Revision {
+	if s.hasErr() {
+		return nil
+	}
+	s.assertHasReadAccessForRequest(request)
+	var relPath = s.relPathForRequest(request)
+	if s.hasErr() {
+		return nil
+	}
+
+	revisions, err := s.repository.revisions(request.Context(), relPath)
+	s.setErr(err)
+	return revisions
+}
+
+// OpenRevisionReader opens a reader for the content of the file pointed to
+// by the request, as contained in the given commit.
+// A caller must always check the Err() method.
+func (s *gitStore) OpenRevisionReader(request *http.Request, revisionID string) io.ReadCloser {
+	if s.hasErr() {
+		return nil
+	}
+	if !revisionIDRegexp.MatchString(revisionID) {
+		s.setErr(store.NewPathNotFoundError(
+			fmt.Sprintf("'%s' is no valid revision id", revisionID)))
+		return nil
+	}
+	s.assertHasReadAccessForRequest(request)
+	var relPath = s.relPathForRequest(request)
+	if s.hasErr() {
+		return nil
+	}
+
+	content, err := s.repository.content(request.Context(), relPath, revisionID)
+	s.setErr(err)
+	if s.hasErr() {
+		return nil
+	}
+	return ioutil.NopCloser(bytes.NewReader(content))
+}
+
+// RestoreRevision replaces the content of the file pointed to by the request
+// with its content in the given commit, and commits that.
+// A caller must always check the Err() method.
+func (s *gitStore) RestoreRevision(request *http.Request, revisionID string) {
+	if s.hasErr() {
+		return
+	}
+	var reader = s.OpenRevisionReader(request, revisionID)
+	if s.hasErr() {
+		return
+	}
+	defer reader.Close()
+
+	var writer = s.OpenWriter(request)
+	if s.hasErr() {
+		return
+	}
+	_, err := io.Copy(writer, reader)
+	s.setErr(err)
+	s.setErr(writer.Close())
+}
+
+func (s *gitStore) assertHasReadAccessForRequest(request *http.Request) {
+	if s.hasErr() {
+		return
+	}
+	if !s.HasReadAccessForRequest(request) {
+		s.setErr(store.NewAccessDeniedError(fmt.Sprintf("read access denied on %s", request.URL)))
+	}
+}
+
+// relPathForRequest returns the path of the file the request points to,
+// relative to the repository root and separated by slashes, as git expects
+// it.
+func (s *gitStore) relPathForRequest(request *http.Request) string {
+	if s.hasErr() {
+		return ""
+	}
+	var p = s.PathForRequest(request)
+	s.setErr(s.Store.Err())
+	if s.hasErr() {
+		return ""
+	}
+
+	var rel = s.contentRoot.Rel(p).ToSlash()
+	s.setErr(rel.Err())
+	return rel.Path()
+}
+
+// committingWriter commits the written content when being closed.
+type committingWriter struct {
+	io.WriteCloser
+	commit func() error
+}
+
+func (w *committingWriter) Close() error {
+	if err := w.WriteCloser.Close(); err != nil {
+		return err
+	}
+	return w.commit()
+}
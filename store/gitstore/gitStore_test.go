@@ -0,0 +1,234 @@
+package gitstore
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fxnn/gone/store"
+	"github.com/fxnn/gopath"
+)
+
+func TestWriteStringCommitsWithAuthor(t *testing.T) {
+	var sut, cleanUp = createSut(t, "Aladdin")
+	defer cleanUp()
+	var request = requestGET("/page.md")
+
+	sut.WriteString(request, "first")
+	sut.WriteString(request, "second")
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to write: %s", err)
+	}
+
+	var revisions = sut.Revisions(request)
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to list revisions: %s", err)
+	}
+	if len(revisions) != 2 {
+		t.Fatalf("expected 2 revisions, but got %d", len(revisions))
+	}
+	if revisions[0].Author != "Aladdin" {
+		t.Fatalf("expected author Aladdin, but got '%s'", revisions[0].Author)
+	}
+	if revisions[1].Size != int64(len("first")) {
+		t.Fatalf("expected size %d, but got %d", len("first"), revisions[1].Size)
+	}
+}
+
+func TestRestoreRevisionCommitsOldContent(t *testing.T) {
+	var sut, cleanUp = createSut(t, "")
+	defer cleanUp()
+	var request = requestGET("/page.md")
+
+	sut.WriteString(request, "first")
+	sut.WriteString(request, "second")
+	var revisions = sut.Revisions(request)
+	if err := sut.Err(); err != nil || len(revisions) != 2 {
+		t.Fatalf("expected 2 revisions without error, but got %d: %v", len(revisions), err)
+	}
+
+	sut.RestoreRevision(request, revisions[1].ID)
+	var content = sut.ReadString(request)
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to restore: %s", err)
+	}
+	if content != "first" {
+		t.Fatalf("expected content 'first', but got '%s'", content)
+	}
+	if revisions = sut.Revisions(request); len(revisions) != 3 {
+		t.Fatalf("expected 3 revisions, but got %d", len(revisions))
+	}
+}
+
+func TestDeleteCommitsDeletion(t *testing.T) {
+	var sut, cleanUp = createSut(t, "")
+	defer cleanUp()
+	var request = requestGET("/page.md")
+
+	sut.WriteString(request, "content")
+	sut.Delete(request)
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to delete: %s", err)
+	}
+
+	var s = sut.(*gitStore)
+	if out, err := s.repository.git(context.Background(), "status", "--porcelain"); err != nil || len(out) != 0 {
+		t.Fatalf("expected clean working tree, but got '%s': %v", out, err)
+	}
+}
+
+func TestRestoreTrashItemCommitsRestoredFile(t *testing.T) {
+	var sut, cleanUp = createSut(t, "")
+	defer cleanUp()
+	var request = requestGET("/page.md")
+
+	sut.WriteString(request, "content")
+	sut.Delete(request)
+	var items = sut.TrashItems(requestGET("/"))
+	if err := sut.Err(); err != nil || len(items) != 1 {
+		t.Fatalf("expected one item in trash, got %v: %v", items, err)
+	}
+	sut.RestoreTrashItem(requestGET("/"), items[0].ID)
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to restore: %s", err)
+	}
+
+	var s = sut.(*gitStore)
+	if out, err := s.repository.git(context.Background(), "status", "--porcelain"); err != nil || len(out) != 0 {
+		t.Fatalf("expected clean working tree, but got '%s': %v", out, err)
+	}
+	if revisions := sut.Revisions(request); len(revisions) != 2 {
+		t.Fatalf("expected versions from write and restore, but got %v", revisions)
+	}
+}
+
+func TestMoveCommitsMoveAndRewrittenLinks(t *testing.T) {
+	var sut, cleanUp = createSut(t, "")
+	defer cleanUp()
+
+	sut.WriteString(requestGET("/old.md"), "content")
+	sut.WriteString(requestGET("/page.md"), "see [[old]]")
+	var newPath = sut.Move(requestGET("/old.md"), "new", true)
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to move: %s", err)
+	}
+	if newPath != "/new.md" {
+		t.Fatalf("expected /new.md, but got %s", newPath)
+	}
+
+	var s = sut.(*gitStore)
+	if out, err := s.repository.git(context.Background(), "status", "--porcelain"); err != nil || len(out) != 0 {
+		t.Fatalf("expected clean working tree, but got '%s': %v", out, err)
+	}
+	if content := sut.ReadString(requestGET("/page.md")); content != "see [[/new|old]]" {
+		t.Fatalf("expected rewritten link, but got '%s'", content)
+	}
+}
+
+func TestOpenRevisionReaderDeniesInvalidRevisionID(t *testing.T) {
+	var sut, cleanUp = createSut(t, "")
+	defer cleanUp()
+
+	sut.OpenRevisionReader(requestGET("/page.md"), "HEAD~1")
+	if err := sut.Err(); !store.IsPathNotFoundError(err) {
+		t.Fatalf("expected PathNotFoundError, but got %v", err)
+	}
+}
+
+func TestRevisionsStopWhenRequestIsCanceled(t *testing.T) {
+	var sut, cleanUp = createSut(t, "")
+	defer cleanUp()
+	sut.WriteString(requestGET("/page.md"), "content")
+	if err := sut.Err(); err != nil {
+		t.Fatalf("failed to write: %s", err)
+	}
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	sut.Revisions(requestGET("/page.md").WithContext(ctx))
+	if err := sut.Err(); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, but got %v", err)
+	}
+}
+
+// Run with -race to detect data races between handles.
+func TestHandlesMayBeUsedConcurrently(t *testing.T) {
+	var sut, cleanUp = createSut(t, "")
+	defer cleanUp()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		var request = requestGET(fmt.Sprintf("/page%d.md", i))
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			var s = sut.Handle()
+			s.WriteString(request, "content")
+			var revisions = s.Revisions(request)
+			if err := s.Err(); err != nil {
+				t.Errorf("failed to write %s: %s", request.URL, err)
+			} else if len(revisions) != 1 {
+				t.Errorf("expected 1 revision of %s, but got %d", request.URL, len(revisions))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			var s = sut.Handle()
+			s.OpenRevisionReader(request, "HEAD~1")
+			if err := s.Err(); !store.IsPathNotFoundError(err) {
+				t.Errorf("expected PathNotFoundError, but got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func createSut(t *testing.T, userID string) (store.Store, func()) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skipf("git executable not found: %s", err)
+	}
+
+	dir, err := ioutil.TempDir("", "gone_test_")
+	if err != nil {
+		t.Fatalf("couldn't create temp dir: %s", err)
+	}
+
+	sut, err := New(gopath.FromPath(dir), &userAuthenticator{userID}, time.Second, time.Hour)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("couldn't create git store: %s", err)
+	}
+
+	return sut, func() { os.RemoveAll(dir) }
+}
+
+func requestGET(path string) (request *http.Request) {
+	request, _ = http.NewRequest("GET", path, nil)
+	return
+}
+
+// userAuthenticator always authenticates the same user.
+type userAuthenticator struct {
+	userID string
+}
+
+func (a *userAuthenticator) IsAuthenticated(request *http.Request) bool {
+	return true
+}
+
+func (a *userAuthenticator) UserID(request *http.Request) string {
+	return a.userID
+}
+
+func (a *userAuthenticator) Groups(request *http.Request) []string {
+	return nil
+}
+
+func (a *userAuthenticator) SetUserID(writer http.ResponseWriter, request *http.Request, userID string) {
+}
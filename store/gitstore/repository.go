@@ -0,0 +1,270 @@
+package gitstore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fxnn/gone/log"
+	"github.com/fxnn/gone/store"
+	"github.com/fxnn/gone/store/filestore"
+	"github.com/fxnn/gopath"
+)
+
+const (
+	gitDirectoryName  = ".git"
+	committerName     = "gone"
+	anonymousUserName = "Anonymous"
+)
+
+// repository runs git commands on a local repository.
+// Commands changing the repository are serialized, as git doesn't allow
+// concurrent changes to its index.
+type repository struct {
+	root  gopath.GoPath
+	mutex sync.Mutex
+}
+
+// openRepository opens the repository located in the given directory.
+// If there is none, a new repository is initialized.
+func openRepository(root gopath.GoPath) (*repository, error) {
+	var r = &repository{root: root}
+	if !root.JoinPath(gitDirectoryName).IsExists() {
+		if _, err := r.git(context.Background(), "init", "--quiet"); err != nil {
+			return nil, fmt.Errorf("couldn't initialize git repository in %s: %s", root.Path(), err)
+		}
+		log.Printf("initialized git repository in %s", root.Path())
+	}
+
+	for _, pattern := range []string{filestore.LockFilePattern, "/" + filestore.TrashDirectoryName + "/"} {
+		if err := r.exclude(pattern); err != nil {
+			return nil, fmt.Errorf("couldn't exclude %s from git repository in %s: %s", pattern, root.Path(), err)
+		}
+	}
+	return r, nil
+}
+
+// exclude lets git ignore files matching the given pattern, without
+// changing any tracked .gitignore file.
+func (r *repository) exclude(pattern string) error {
+	var out, err = r.git(context.Background(), "rev-parse", "--git-path", "info/exclude")
+	if err != nil {
+		return err
+	}
+	var excludePath = strings.TrimSpace(string(out))
+	if !filepath.IsAbs(excludePath) {
+		excludePath = r.root.JoinPath(excludePath).Path()
+	}
+
+	content, err := ioutil.ReadFile(excludePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) == pattern {
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(excludePath), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(excludePath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		pattern = "\n" + pattern
+	}
+	_, err = fmt.Fprintln(file, pattern)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// commit records all changes to the given files as a new commit.
+// Does nothing if there are no changes.
+//
+// userID is the unique id of the user that authored the changes; the empty
+// string for an anonymous user.
+//
+// Committing can't be canceled, as the changes are already made.
+func (r *repository) commit(userID string, message string, relPaths ...string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var ctx = context.Background()
+	relPaths = r.knownPaths(ctx, relPaths)
+	if len(relPaths) == 0 {
+		return nil
+	}
+	var pathArgs = append([]string{"--"}, relPaths...)
+	var names = strings.Join(relPaths, ", ")
+	if _, err := r.git(ctx, append([]string{"add", "--all"}, pathArgs...)...); err != nil {
+		return fmt.Errorf("couldn't stage %s: %s", names, err)
+	}
+	if _, err := r.git(ctx, append([]string{"diff", "--cached", "--quiet"}, pathArgs...)...); err == nil {
+		// HINT: nothing changed
+		return nil
+	}
+	if _, err := r.run(ctx, authorEnv(userID), nil,
+		append([]string{"commit", "--quiet", "--message", message}, pathArgs...)...); err != nil {
+		return fmt.Errorf("couldn't commit %s: %s", names, err)
+	}
+
+	return nil
+}
+
+// knownPaths filters out those paths that neither exist nor are tracked, as
+// git refuses to stage them.
+func (r *repository) knownPaths(ctx context.Context, relPaths []string) []string {
+	var result = make([]string, 0, len(relPaths))
+	for _, relPath := range relPaths {
+		if r.root.JoinPath(relPath).IsExists() {
+			result = append(result, relPath)
+		} else if _, err := r.git(ctx, "ls-files", "--error-unmatch", "--", relPath); err == nil {
+			result = append(result, relPath)
+		}
+	}
+	return result
+}
+
+// revisions lists all commits containing a version of the given file,
+// newest first.
+// The git commands are killed when the context is done.
+func (r *repository) revisions(ctx context.Context, relPath string) ([]store.Revision, error) {
+	if _, err := r.git(ctx, "rev-parse", "--quiet", "--verify", "HEAD"); err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		// HINT: no commits yet
+		return []store.Revision{}, nil
+	}
+
+	out, err := r.git(ctx, "log", "--format=%H%x00%ae%x00%at", "--", relPath)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read log of %s: %s", relPath, err)
+	}
+
+	var result = make([]store.Revision, 0)
+	var objectNames bytes.Buffer
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		var fields = strings.Split(line, "\x00")
+		if len(fields) != 3 {
+			continue
+		}
+		seconds, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse log of %s: %s", relPath, err)
+		}
+		result = append(result, store.Revision{
+			ID:     fields[0],
+			Author: fields[1],
+			Time:   time.Unix(seconds, 0),
+		})
+		fmt.Fprintf(&objectNames, "%s:%s\n", fields[0], relPath)
+	}
+
+	return r.withSizes(ctx, result, objectNames.Bytes())
+}
+
+// withSizes fills in the size of each revision, and drops those revisions
+// that don't contain the file (e.g. as they delete it).
+func (r *repository) withSizes(ctx context.Context, revisions []store.Revision, objectNames []byte) ([]store.Revision, error) {
+	out, err := r.run(ctx, nil, bytes.NewReader(objectNames), "cat-file", "--batch-check")
+	if err != nil {
+		return nil, fmt.Errorf("couldn't determine revision sizes: %s", err)
+	}
+
+	var result = make([]store.Revision, 0, len(revisions))
+	var scanner = bufio.NewScanner(bytes.NewReader(out))
+	for i := 0; scanner.Scan() && i < len(revisions); i++ {
+		// HINT: format is "<object> <type> <size>" or "<name> missing"
+		var fields = strings.Fields(scanner.Text())
+		if len(fields) != 3 || fields[1] != "blob" {
+			continue
+		}
+		if size, err := strconv.ParseInt(fields[2], 10, 64); err == nil {
+			revisions[i].Size = size
+			result = append(result, revisions[i])
+		}
+	}
+
+	return result, nil
+}
+
+// content returns the content of the given file in the given commit.
+func (r *repository) content(ctx context.Context, relPath string, revisionID string) ([]byte, error) {
+	out, err := r.git(ctx, "cat-file", "blob", revisionID+":"+relPath)
+	if err != nil && ctx.Err() != nil {
+		return nil, err
+	}
+	if err != nil {
+		return nil, store.NewPathNotFoundError(fmt.Sprintf(
+			"revision %s of %s not found: %s", revisionID, relPath, err))
+	}
+	return out, nil
+}
+
+// git runs the git executable with the given arguments inside the
+// repository and returns its standard output.
+// The process is killed when the context is done.
+func (r *repository) git(ctx context.Context, args ...string) ([]byte, error) {
+	return r.run(ctx, nil, nil, args...)
+}
+
+// run is like git, but allows to pass additional environment variables and
+// the standard input.
+// Both env and stdin might be nil.
+func (r *repository) run(ctx context.Context, env []string, stdin io.Reader,
+	args ...string) ([]byte, error) {
+	var cmd = exec.CommandContext(ctx, "git", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Dir = r.root.Path()
+	cmd.Env = append(os.Environ(),
+		"GIT_COMMITTER_NAME="+committerName,
+		"GIT_COMMITTER_EMAIL=")
+	cmd.Env = append(cmd.Env, env...)
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			// HINT: the process was killed
+			return nil, ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// authorEnv returns the environment variables denoting the given user as
+// author.
+// The user id is used as email address, as this is what we read back as
+// the revision's author.
+func authorEnv(userID string) []string {
+	var name = userID
+	if name == "" {
+		name = anonymousUserName
+	}
+	return []string{
+		"GIT_AUTHOR_NAME=" + name,
+		"GIT_AUTHOR_EMAIL=" + userID,
+	}
+}
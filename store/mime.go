@@ -10,6 +10,8 @@ const (
 	FallbackMimeType = "application/octet-stream"
 	MarkdownMimeType = "text/markdown"
 	UrlMimeType = "text/url"
+	// DirectoryMimeType is reported for directories without index document.
+	DirectoryMimeType = "inode/directory"
 )
 
 func init() {
@@ -1,9 +1,12 @@
 package mockstore
 
 import (
+	"bytes"
 	"errors"
 	"io"
 	"net/http"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/fxnn/gone/store"
@@ -17,6 +20,10 @@ type MockStore struct {
 	writeAccess  bool
 	deleteAccess bool
 	mimeType     string
+	content      string
+	entries      []store.Entry
+	backlinks    []string
+	trashItems   []store.TrashItem
 	exists       bool
 }
 
@@ -72,23 +79,215 @@ func (s *MockStore) OpenReader(request *http.Request) io.ReadCloser {
 }
 
 func (s *MockStore) OpenWriter(request *http.Request) io.WriteCloser {
+	return &contentWriter{store: s}
+}
+
+func (s *MockStore) OpenWriterIfUnchanged(request *http.Request, expectedHash string) io.WriteCloser {
+	if !s.hasContentHash(expectedHash) {
+		s.err = store.NewConflictError("mocked ConflictError")
+		return nil
+	}
+	return s.OpenWriter(request)
+}
+
+func (s *MockStore) OpenWriterIfMissing(request *http.Request) io.WriteCloser {
+	if s.exists {
+		s.err = store.NewConflictError("mocked ConflictError")
+		return nil
+	}
+	return s.OpenWriter(request)
+}
+
+// hasContentHash returns true iff the mocked content has the given hash.
+func (s *MockStore) hasContentHash(expectedHash string) bool {
+	if !s.exists {
+		return expectedHash == store.ContentHash("")
+	}
+	return expectedHash == store.ContentHash(s.content)
+}
+
+// contentWriter replaces the mocked content when being closed, letting the
+// file exist.
+type contentWriter struct {
+	bytes.Buffer
+	store *MockStore
+}
+
+func (w *contentWriter) Close() error {
+	w.store.content = w.String()
+	w.store.exists = true
 	return nil
 }
 
+func (s *MockStore) GivenContent(content string) {
+	s.content = content
+}
+
 func (s *MockStore) ReadString(request *http.Request) string {
 	if !s.exists {
 		s.err = store.NewPathNotFoundError("mocked PathNotFoundError")
+		return ""
 	}
-	return ""
+	return s.content
 }
 
 func (s *MockStore) WriteString(request *http.Request, content string) {
+	s.content = content
+	s.exists = true
+}
+
+func (s *MockStore) WriteStringIfUnchanged(request *http.Request, content string,
+	expectedHash string) {
+	if !s.hasContentHash(expectedHash) {
+		s.err = store.NewConflictError("mocked ConflictError")
+		return
+	}
+	s.WriteString(request, content)
 }
 
 func (s *MockStore) Delete(request *http.Request) {
 	if !s.exists {
 		s.err = store.NewPathNotFoundError("mocked PathNotFoundError")
 	}
+	s.exists = false
+}
+
+func (s *MockStore) Move(request *http.Request, targetPath string, rewriteLinks bool) string {
+	if !s.exists {
+		s.err = store.NewPathNotFoundError("mocked PathNotFoundError")
+		return ""
+	}
+	return targetPath
+}
+
+// GivenEntries sets the entries below the content root.
+// Each entry's Path denotes where it's located.
+func (s *MockStore) GivenEntries(entries ...store.Entry) {
+	s.entries = entries
+}
+
+func (s *MockStore) List(request *http.Request) []store.Entry {
+	if !s.exists {
+		s.err = store.NewPathNotFoundError("mocked PathNotFoundError")
+		return nil
+	}
+	var result []store.Entry
+	for _, entry := range s.entries {
+		if path.Dir(entry.Path) == path.Clean(request.URL.Path) {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// Walk visits the given entries below the requested directory in the order
+// they were given.
+func (s *MockStore) Walk(request *http.Request, walkFn store.WalkFunc) {
+	if !s.exists {
+		s.err = store.NewPathNotFoundError("mocked PathNotFoundError")
+		return
+	}
+	var skipped []string
+	for _, entry := range s.entries {
+		if !isBelow(entry.Path, request.URL.Path) || isBelowAny(entry.Path, skipped) {
+			continue
+		}
+		var err = walkFn(entry)
+		if err == store.SkipDir {
+			skipped = append(skipped, entry.Path)
+			continue
+		}
+		if err != nil {
+			s.err = err
+			return
+		}
+	}
+}
+
+func isBelow(p string, dir string) bool {
+	return strings.HasPrefix(path.Clean(p), strings.TrimSuffix(path.Clean(dir), "/")+"/")
+}
+
+func isBelowAny(p string, dirs []string) bool {
+	for _, dir := range dirs {
+		if isBelow(p, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MockStore) GivenBacklinks(backlinks ...string) {
+	s.backlinks = backlinks
+}
+
+func (s *MockStore) Backlinks(request *http.Request) []string {
+	if !s.exists {
+		s.err = store.NewPathNotFoundError("mocked PathNotFoundError")
+	}
+	return s.backlinks
+}
+
+func (s *MockStore) IndexedBacklinks(request *http.Request) []string {
+	return s.Backlinks(request)
+}
+
+func (s *MockStore) Revisions(request *http.Request) []store.Revision {
+	if !s.exists {
+		s.err = store.NewPathNotFoundError("mocked PathNotFoundError")
+	}
+	return nil
+}
+
+func (s *MockStore) OpenRevisionReader(request *http.Request, revisionID string) io.ReadCloser {
+	if !s.exists {
+		s.err = store.NewPathNotFoundError("mocked PathNotFoundError")
+	}
+	return nil
+}
+
+func (s *MockStore) RestoreRevision(request *http.Request, revisionID string) {
+	if !s.exists {
+		s.err = store.NewPathNotFoundError("mocked PathNotFoundError")
+	}
+}
+
+func (s *MockStore) GivenTrashItems(items ...store.TrashItem) {
+	s.trashItems = items
+}
+
+func (s *MockStore) TrashItems(request *http.Request) []store.TrashItem {
+	return s.trashItems
+}
+
+func (s *MockStore) RestoreTrashItem(request *http.Request, itemID string) string {
+	var i = s.trashItemIndex(itemID)
+	if i < 0 {
+		return ""
+	}
+	var item = s.trashItems[i]
+	s.trashItems = append(s.trashItems[:i], s.trashItems[i+1:]...)
+	return item.Path
+}
+
+func (s *MockStore) PurgeTrashItem(request *http.Request, itemID string) {
+	if i := s.trashItemIndex(itemID); i >= 0 {
+		s.trashItems = append(s.trashItems[:i], s.trashItems[i+1:]...)
+	}
+}
+
+func (s *MockStore) trashItemIndex(itemID string) int {
+	for i, item := range s.trashItems {
+		if item.ID == itemID {
+			return i
+		}
+	}
+	s.err = store.NewPathNotFoundError("mocked PathNotFoundError")
+	return -1
+}
+
+func (s *MockStore) Search(request *http.Request, query string) []store.SearchResult {
+	return nil
 }
 
 func (s *MockStore) FileSizeForRequest(request *http.Request) int64 {
@@ -105,6 +304,13 @@ func (s *MockStore) ModTimeForRequest(request *http.Request) time.Time {
 	return time.Now()
 }
 
+func (s *MockStore) URLPathForRequest(request *http.Request) string {
+	if !s.exists {
+		s.err = store.NewPathNotFoundError("mocked PathNotFoundError")
+	}
+	return request.URL.Path
+}
+
 func (s *MockStore) GivenMimeType(mimeType string) {
 	s.mimeType = mimeType
 }
@@ -121,3 +327,9 @@ func (s *MockStore) Err() error {
 	s.err = nil
 	return result
 }
+
+// Handle returns the MockStore itself, so that the configured answers apply to
+// all handles.
+func (s *MockStore) Handle() store.Store {
+	return s
+}
@@ -0,0 +1,132 @@
+package thumbnail
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cache keeps scaled images in a directory.
+// Each scaled image is identified by the source's path and modification
+// time, so that changed sources are scaled again.
+// Variants of older modification times are removed when a new one is
+// stored.
+//
+// A Cache is safe for concurrent use.
+// As scaling is expensive, only as many images as there are CPUs are scaled
+// at the same time; further callers wait.
+type Cache struct {
+	dir     string
+	scaling chan struct{}
+}
+
+// NewCache creates a cache keeping its files in the given directory.
+// The directory is created when needed.
+func NewCache(dir string) *Cache {
+	return &Cache{dir, make(chan struct{}, runtime.NumCPU())}
+}
+
+// Open returns a reader for the given source scaled to the given width,
+// together with its mime type.
+// When no such scaled image with the given modification time is cached, the
+// source is opened using the given function and scaled.
+// The caller must close the reader.
+func (c *Cache) Open(sourcePath string, modTime time.Time, width int,
+	openSource func() (io.ReadCloser, error)) (io.ReadCloser, string, error) {
+	var entryDir = c.entryDir(sourcePath, width)
+	var version = modTime.UnixNano()
+
+	if file, mimeType, ok := openEntry(entryDir, version); ok {
+		return file, mimeType, nil
+	}
+
+	c.scaling <- struct{}{}
+	defer func() { <-c.scaling }()
+	// HINT: a concurrent caller might have scaled it while we waited
+	if file, mimeType, ok := openEntry(entryDir, version); ok {
+		return file, mimeType, nil
+	}
+
+	source, err := openSource()
+	if err != nil {
+		return nil, "", err
+	}
+	defer source.Close()
+
+	if err := os.MkdirAll(entryDir, 0700); err != nil {
+		return nil, "", fmt.Errorf("couldn't create cache directory: %s", err)
+	}
+	tempFile, err := ioutil.TempFile(entryDir, ".tmp")
+	if err != nil {
+		return nil, "", fmt.Errorf("couldn't create cache file: %s", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	mimeType, err := Scale(source, width, tempFile)
+	if closeErr := tempFile.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("couldn't write cache file: %s", closeErr)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	var fileName = entryFile(entryDir, version, mimeType)
+	if err := os.Rename(tempFile.Name(), fileName); err != nil {
+		return nil, "", fmt.Errorf("couldn't store cache file: %s", err)
+	}
+	removeEntriesOlderThan(entryDir, version)
+
+	file, err := os.Open(fileName)
+	return file, mimeType, err
+}
+
+// entryDir returns the directory keeping all variants of the given source
+// scaled to the given width.
+func (c *Cache) entryDir(sourcePath string, width int) string {
+	var sum = sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d", sourcePath, width)))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
+
+// openEntry opens the cached variant of the given version, if any.
+func openEntry(entryDir string, version int64) (*os.File, string, bool) {
+	for _, mimeType := range []string{JPEGMimeType, PNGMimeType} {
+		if file, err := os.Open(entryFile(entryDir, version, mimeType)); err == nil {
+			return file, mimeType, true
+		}
+	}
+	return nil, "", false
+}
+
+func entryFile(entryDir string, version int64, mimeType string) string {
+	var name = strconv.FormatInt(version, 10)
+	if mimeType == JPEGMimeType {
+		return filepath.Join(entryDir, name+".jpg")
+	}
+	return filepath.Join(entryDir, name+".png")
+}
+
+// removeEntriesOlderThan removes the cached variants in the given directory
+// whose version is older than the given one.
+// Newer variants are kept, as concurrent requests might have just stored
+// them.
+// Errors are ignored, as outdated variants are never served anyway.
+func removeEntriesOlderThan(entryDir string, version int64) {
+	for _, pattern := range []string{"*.jpg", "*.png"} {
+		var fileNames, _ = filepath.Glob(filepath.Join(entryDir, pattern))
+		for _, fileName := range fileNames {
+			var base = filepath.Base(fileName)
+			var entryVersion, err = strconv.ParseInt(strings.TrimSuffix(base, filepath.Ext(base)), 10, 64)
+			if err == nil && entryVersion < version {
+				os.Remove(fileName)
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCacheScalesOnlyWhenSourceChanged(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gone_test_")
+	if err != nil {
+		t.Fatalf("couldn't create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var sut = NewCache(dir)
+	var opened = 0
+	var openSource = func() (io.ReadCloser, error) {
+		opened++
+		var buf bytes.Buffer
+		png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 20, 10)))
+		return ioutil.NopCloser(&buf), nil
+	}
+	var modTime = time.Now()
+
+	assertCachedWidth(t, sut, modTime, openSource, 10)
+	assertCachedWidth(t, sut, modTime, openSource, 10)
+	if opened != 1 {
+		t.Fatalf("expected source to be opened once, but was opened %d times", opened)
+	}
+
+	assertCachedWidth(t, sut, modTime.Add(time.Second), openSource, 10)
+	if opened != 2 {
+		t.Fatalf("expected changed source to be opened again, but was opened %d times", opened)
+	}
+}
+
+func TestCacheKeepsNewerEntries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gone_test_")
+	if err != nil {
+		t.Fatalf("couldn't create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var sut = NewCache(dir)
+	var opened = 0
+	var openSource = func() (io.ReadCloser, error) {
+		opened++
+		var buf bytes.Buffer
+		png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 20, 10)))
+		return ioutil.NopCloser(&buf), nil
+	}
+	var modTime = time.Now()
+
+	// HINT: a request that read the modification time before the source changed
+	assertCachedWidth(t, sut, modTime.Add(time.Second), openSource, 10)
+	assertCachedWidth(t, sut, modTime, openSource, 10)
+	assertCachedWidth(t, sut, modTime.Add(time.Second), openSource, 10)
+	if opened != 2 {
+		t.Fatalf("expected newer entry to be kept, but source was opened %d times", opened)
+	}
+}
+
+func assertCachedWidth(t *testing.T, sut *Cache, modTime time.Time,
+	openSource func() (io.ReadCloser, error), width int) {
+	reader, mimeType, err := sut.Open("/image.png", modTime, width, openSource)
+	if err != nil {
+		t.Fatalf("failed to open: %s", err)
+	}
+	defer reader.Close()
+
+	if mimeType != PNGMimeType {
+		t.Fatalf("expected %s, but got %s", PNGMimeType, mimeType)
+	}
+	config, err := png.DecodeConfig(reader)
+	if err != nil || config.Width != width {
+		t.Fatalf("expected width %d, but got %d: %v", width, config.Width, err)
+	}
+}
@@ -0,0 +1,6 @@
+// Package thumbnail scales images down to a requested width and caches the
+// results on disk, so that pages embedding large photos load quickly.
+//
+// Only the image packages of the standard library are used; JPEG, PNG and
+// GIF images are read, and JPEG or PNG images are written.
+package thumbnail
@@ -0,0 +1,127 @@
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif" // HINT: registers the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+const (
+	// MaxSourcePixels limits the size of images being scaled, as each one is
+	// decoded into memory as a whole.
+	MaxSourcePixels = 40 * 1000 * 1000
+
+	jpegQuality = 85
+)
+
+// Mime types of the scaled images.
+const (
+	JPEGMimeType = "image/jpeg"
+	PNGMimeType  = "image/png"
+)
+
+// Widths are the widths images are scaled to, in ascending order.
+// Other widths are rounded by RoundWidth, so that each image is scaled and
+// cached only a few times.
+var Widths = []int{100, 200, 400, 800, 1600, 3200}
+
+// ErrUnsupportedImage is returned when the image can't be decoded.
+var ErrUnsupportedImage = errors.New("unsupported image format")
+
+// ErrImageTooLarge is returned when the image has more than MaxSourcePixels.
+var ErrImageTooLarge = errors.New("image too large to be scaled")
+
+// RoundWidth returns the smallest of Widths not less than the given width,
+// or the largest one for widths beyond.
+func RoundWidth(width int) int {
+	for _, w := range Widths {
+		if w >= width {
+			return w
+		}
+	}
+	return Widths[len(Widths)-1]
+}
+
+// IsSupportedMimeType returns true iff images of the given mime type can be
+// scaled.
+func IsSupportedMimeType(mimeType string) bool {
+	switch mimeType {
+	case JPEGMimeType, PNGMimeType, "image/gif":
+		return true
+	}
+	return false
+}
+
+// Scale reads an image and writes it scaled to the given width, keeping its
+// aspect ratio.
+// Images are never enlarged, and images larger than MaxSourcePixels are
+// rejected before being decoded.
+// JPEG images are written as JPEG, all others as PNG; the returned mime type
+// tells which.
+func Scale(reader io.Reader, width int, writer io.Writer) (string, error) {
+	// HINT: the header read for the dimensions is decoded again with the rest
+	var header bytes.Buffer
+	config, _, err := image.DecodeConfig(io.TeeReader(reader, &header))
+	if err != nil {
+		return "", ErrUnsupportedImage
+	}
+	if int64(config.Width)*int64(config.Height) > MaxSourcePixels {
+		return "", ErrImageTooLarge
+	}
+
+	src, format, err := image.Decode(io.MultiReader(&header, reader))
+	if err != nil {
+		return "", ErrUnsupportedImage
+	}
+
+	var dst = src
+	if width < src.Bounds().Dx() {
+		dst = scaleToWidth(src, width)
+	}
+
+	if format == "jpeg" {
+		return JPEGMimeType, jpeg.Encode(writer, dst, &jpeg.Options{Quality: jpegQuality})
+	}
+	return PNGMimeType, png.Encode(writer, dst)
+}
+
+// scaleToWidth scales the image down by averaging all source pixels that
+// fall into a target pixel.
+func scaleToWidth(src image.Image, width int) image.Image {
+	var bounds = src.Bounds()
+	var height = bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	var sums = make([]uint64, 4*width*height)
+	var counts = make([]uint64, width*height)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		var row = (y - bounds.Min.Y) * height / bounds.Dy() * width
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			var i = row + (x-bounds.Min.X)*width/bounds.Dx()
+			r, g, b, a := src.At(x, y).RGBA()
+			sums[4*i] += uint64(r)
+			sums[4*i+1] += uint64(g)
+			sums[4*i+2] += uint64(b)
+			sums[4*i+3] += uint64(a)
+			counts[i]++
+		}
+	}
+
+	// HINT: the sums are alpha-premultiplied, as returned by Color.RGBA()
+	var dst = image.NewRGBA(image.Rect(0, 0, width, height))
+	for i, count := range counts {
+		if count == 0 {
+			continue
+		}
+		for c := 0; c < 4; c++ {
+			dst.Pix[4*i+c] = uint8(sums[4*i+c] / count >> 8)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,84 @@
+package thumbnail
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestScaleAveragesPixelsAndKeepsAspectRatio(t *testing.T) {
+	var src = image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 2; y++ {
+			if x%2 == 0 {
+				src.Set(x, y, color.RGBA{255, 0, 0, 255})
+			} else {
+				src.Set(x, y, color.RGBA{0, 0, 255, 255})
+			}
+		}
+	}
+
+	var scaled = scaleToWidth(src, 2)
+
+	if scaled.Bounds().Dx() != 2 || scaled.Bounds().Dy() != 1 {
+		t.Fatalf("expected 2x1 image, but got %v", scaled.Bounds())
+	}
+	r, g, b, a := scaled.At(0, 0).RGBA()
+	if r>>8 != 127 || g != 0 || b>>8 != 127 || a>>8 != 255 {
+		t.Fatalf("expected averaged purple, but got %d %d %d %d", r>>8, g>>8, b>>8, a>>8)
+	}
+}
+
+func TestScaleDoesNotEnlarge(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 10, 5)))
+
+	var out bytes.Buffer
+	mimeType, err := Scale(&buf, 100, &out)
+	if err != nil {
+		t.Fatalf("failed to scale: %s", err)
+	}
+	if mimeType != PNGMimeType {
+		t.Fatalf("expected %s, but got %s", PNGMimeType, mimeType)
+	}
+
+	config, err := png.DecodeConfig(&out)
+	if err != nil || config.Width != 10 {
+		t.Fatalf("expected width 10, but got %d: %v", config.Width, err)
+	}
+}
+
+func TestScaleRejectsNonImages(t *testing.T) {
+	var out bytes.Buffer
+	if _, err := Scale(strings.NewReader("no image"), 100, &out); err != ErrUnsupportedImage {
+		t.Fatalf("expected ErrUnsupportedImage, but got %v", err)
+	}
+}
+
+func TestScaleRejectsImagesWithTooManyPixels(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1)))
+	// HINT: claim 50000x50000 pixels in the IHDR chunk, fixing its checksum
+	var data = buf.Bytes()
+	binary.BigEndian.PutUint32(data[16:20], 50000)
+	binary.BigEndian.PutUint32(data[20:24], 50000)
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+
+	var out bytes.Buffer
+	if _, err := Scale(bytes.NewReader(data), 100, &out); err != ErrImageTooLarge {
+		t.Fatalf("expected ErrImageTooLarge, but got %v", err)
+	}
+}
+
+func TestRoundWidth(t *testing.T) {
+	for width, expected := range map[int]int{1: 100, 100: 100, 101: 200, 400: 400, 3200: 3200, 5000: 3200} {
+		if actual := RoundWidth(width); actual != expected {
+			t.Fatalf("expected width %d to be rounded to %d, but got %d", width, expected, actual)
+		}
+	}
+}